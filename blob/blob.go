@@ -0,0 +1,129 @@
+// Package blob stores files behind a Store interface, so services can switch between S3, the local filesystem and
+// memory by config.
+package blob
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNotFound is returned, wrapped, when an object doesn't exist.
+var ErrNotFound = errors.New("blob: not found")
+
+// Store is a key value store for files. Keys use forward slashes to separate path segments.
+type Store interface {
+	// Put stores b under key, replacing any existing object.
+	Put(ctx context.Context, key string, b []byte, opts ...PutOption) error
+	// PutStream stores the contents of r under key without buffering it in memory.
+	PutStream(ctx context.Context, key string, r io.Reader, opts ...PutOption) error
+	// Get returns the content of the object stored under key.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Open returns a reader for the object stored under key, which must be closed by the caller.
+	Open(ctx context.Context, key string) (io.ReadCloser, Info, error)
+	// Stat returns the details of the object stored under key.
+	Stat(ctx context.Context, key string) (Info, error)
+	// List calls fn for each object with a key starting with prefix, in key order. Listing stops at the first error
+	// returned by fn, which is returned by List.
+	List(ctx context.Context, prefix string, fn func(Info) error) error
+	// Delete removes the object stored under key. Deleting an object which doesn't exist isn't an error.
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL which grants temporary read access to the object stored under key.
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+	// URL returns the public URL of the object stored under key, see WithURLResolver.
+	URL(key string) string
+}
+
+// Info describes a stored object. ContentType and Metadata aren't returned by List.
+type Info struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+	Metadata     map[string]string
+}
+
+// IsNotFound returns whether err was caused by the object not existing.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// URLResolver returns the public URL of the object stored under key.
+type URLResolver func(key string) string
+
+// BaseURLResolver resolves keys relative to baseURL, e.g. the URL of a Cloudfront distribution.
+func BaseURLResolver(baseURL string) URLResolver {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return func(key string) string {
+		return fmt.Sprintf("%s/%s", baseURL, key)
+	}
+}
+
+// Option configures a Store.
+type Option func(*options)
+
+type options struct {
+	resolver URLResolver
+}
+
+func newOptions(resolver URLResolver, opts []Option) *options {
+	o := &options{resolver: resolver}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithURLResolver sets how the Store resolves public URLs, replacing the default of the backend.
+func WithURLResolver(resolver URLResolver) Option {
+	return func(o *options) {
+		o.resolver = resolver
+	}
+}
+
+// WithCloudfront resolves public URLs on a Cloudfront distribution (or any other CDN) in front of the store. An empty
+// cdnURL keeps the default of the backend, so it can be passed straight from config.
+func WithCloudfront(cdnURL string) Option {
+	return func(o *options) {
+		if cdnURL != "" {
+			o.resolver = BaseURLResolver(cdnURL)
+		}
+	}
+}
+
+// PutOption configures Put and PutStream.
+type PutOption func(*PutOptions)
+
+// PutOptions are the options of a Put, available to Store implementations.
+type PutOptions struct {
+	ContentType string
+	Metadata    map[string]string
+}
+
+// NewPutOptions applies opts.
+func NewPutOptions(opts []PutOption) *PutOptions {
+	o := &PutOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithContentType sets the content type of the object.
+func WithContentType(contentType string) PutOption {
+	return func(o *PutOptions) {
+		o.ContentType = contentType
+	}
+}
+
+// WithMetadata sets user metadata stored with the object.
+func WithMetadata(metadata map[string]string) PutOption {
+	return func(o *PutOptions) {
+		o.Metadata = metadata
+	}
+}
@@ -0,0 +1,52 @@
+package blob
+
+import (
+	"context"
+
+	"github.com/HomesNZ/go-common/blob/config"
+	"github.com/HomesNZ/go-common/s3"
+	"github.com/pkg/errors"
+)
+
+// NewFromEnv returns the Store configured with BLOB_BACKEND.
+func NewFromEnv(ctx context.Context, opts ...Option) (Store, error) {
+	cfg, err := config.NewFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return New(ctx, cfg, opts...)
+}
+
+// New returns the Store for cfg.Backend. Public URLs are resolved on cfg.CDNURL when it's set, which opts can
+// override.
+func New(ctx context.Context, cfg *config.Config, opts ...Option) (Store, error) {
+	if cfg == nil {
+		return nil, errors.New("blob config was not provided")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	opts = append([]Option{WithCloudfront(cfg.CDNURL)}, opts...)
+
+	switch cfg.Backend {
+	case config.BackendFilesystem:
+		return NewFilesystem(cfg.Path, opts...)
+	case config.BackendMemory:
+		return NewMemory(opts...), nil
+	case config.BackendS3:
+		service, err := s3.New(ctx, cfg.S3)
+		if err != nil {
+			return nil, err
+		}
+		var presign s3.PresignService
+		if cfg.Presign != nil {
+			presign, err = s3.NewPresignService(ctx, cfg.Presign)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return NewS3(service, presign, cfg.S3, opts...), nil
+	default:
+		return nil, errors.Errorf("unknown blob backend %q", cfg.Backend)
+	}
+}
@@ -0,0 +1,144 @@
+package blob
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/HomesNZ/go-common/blob/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestBlob(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Blob")
+}
+
+// describeStore runs the behaviour every Store must have against the store returned by newStore.
+func describeStore(newStore func() Store) {
+	var (
+		ctx   = context.Background()
+		store Store
+	)
+
+	BeforeEach(func() {
+		store = newStore()
+	})
+
+	It("gets what was put", func() {
+		Expect(store.Put(ctx, "photos/1.jpg", []byte("photo"), WithContentType("image/jpeg"))).To(Succeed())
+		b, err := store.Get(ctx, "photos/1.jpg")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal("photo"))
+	})
+	It("streams", func() {
+		Expect(store.PutStream(ctx, "imports/data.csv", strings.NewReader("a,b,c"))).To(Succeed())
+		r, info, err := store.Open(ctx, "imports/data.csv")
+		Expect(err).NotTo(HaveOccurred())
+		defer r.Close()
+		b, err := ioutil.ReadAll(r)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal("a,b,c"))
+		Expect(info.Size).To(Equal(int64(5)))
+	})
+	It("stats", func() {
+		Expect(store.Put(ctx, "photos/1.jpg", []byte("photo"))).To(Succeed())
+		info, err := store.Stat(ctx, "photos/1.jpg")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Key).To(Equal("photos/1.jpg"))
+		Expect(info.Size).To(Equal(int64(5)))
+		Expect(info.ETag).NotTo(BeEmpty())
+	})
+	It("returns ErrNotFound for missing objects", func() {
+		_, err := store.Get(ctx, "missing")
+		Expect(IsNotFound(err)).To(BeTrue())
+		_, err = store.Stat(ctx, "missing")
+		Expect(IsNotFound(err)).To(BeTrue())
+		_, _, err = store.Open(ctx, "missing")
+		Expect(IsNotFound(err)).To(BeTrue())
+	})
+	It("lists by prefix in key order", func() {
+		for _, key := range []string{"photos/2.jpg", "photos/1.jpg", "plans/1.pdf"} {
+			Expect(store.Put(ctx, key, []byte(key))).To(Succeed())
+		}
+		var keys []string
+		Expect(store.List(ctx, "photos/", func(info Info) error {
+			keys = append(keys, info.Key)
+			return nil
+		})).To(Succeed())
+		Expect(keys).To(Equal([]string{"photos/1.jpg", "photos/2.jpg"}))
+	})
+	It("deletes", func() {
+		Expect(store.Put(ctx, "photos/1.jpg", []byte("photo"))).To(Succeed())
+		Expect(store.Delete(ctx, "photos/1.jpg")).To(Succeed())
+		_, err := store.Stat(ctx, "photos/1.jpg")
+		Expect(IsNotFound(err)).To(BeTrue())
+		Expect(store.Delete(ctx, "photos/1.jpg")).To(Succeed())
+	})
+}
+
+var _ = Describe("New", func() {
+	It("returns the configured store", func() {
+		store, err := New(context.Background(), &config.Config{Backend: config.BackendMemory})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store).To(BeAssignableToTypeOf(&MemoryStore{}))
+	})
+	It("rejects a missing config or backend", func() {
+		_, err := New(context.Background(), nil)
+		Expect(err).To(HaveOccurred())
+		_, err = New(context.Background(), &config.Config{})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("MemoryStore", func() {
+	describeStore(func() Store {
+		return NewMemory()
+	})
+
+	It("keeps metadata", func() {
+		store := NewMemory()
+		Expect(store.Put(context.Background(), "1.jpg", nil, WithMetadata(map[string]string{"source": "import"}))).To(Succeed())
+		info, err := store.Stat(context.Background(), "1.jpg")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Metadata).To(HaveKeyWithValue("source", "import"))
+	})
+	It("resolves URLs on the CDN", func() {
+		store := NewMemory(WithCloudfront("https://cdn.example.com/"))
+		Expect(store.URL("photos/1.jpg")).To(Equal("https://cdn.example.com/photos/1.jpg"))
+	})
+})
+
+var _ = Describe("filesystemStore", func() {
+	var dirs []string
+
+	AfterEach(func() {
+		for _, dir := range dirs {
+			os.RemoveAll(dir)
+		}
+	})
+
+	describeStore(func() Store {
+		dir, err := ioutil.TempDir("", "blob")
+		Expect(err).NotTo(HaveOccurred())
+		dirs = append(dirs, dir)
+		store, err := NewFilesystem(dir)
+		Expect(err).NotTo(HaveOccurred())
+		return store
+	})
+
+	It("keeps keys inside the root", func() {
+		dir, err := ioutil.TempDir("", "blob")
+		Expect(err).NotTo(HaveOccurred())
+		dirs = append(dirs, dir)
+		store, err := NewFilesystem(dir + "/root")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(store.Put(context.Background(), "../../escaped", []byte("x"))).To(Succeed())
+		_, err = os.Stat(dir + "/root/escaped")
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
@@ -0,0 +1,44 @@
+package config
+
+import (
+	s3Config "github.com/HomesNZ/go-common/s3/config"
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+const (
+	// BackendS3 stores objects in S3 with the s3 package.
+	BackendS3 = "s3"
+	// BackendFilesystem stores objects in files under Path, for local development.
+	BackendFilesystem = "filesystem"
+	// BackendMemory stores objects in memory, for tests.
+	BackendMemory = "memory"
+)
+
+type Config struct {
+	Backend string                  // - is where objects are stored, one of s3, filesystem or memory
+	Path    string                  // - is the directory objects are stored in by the filesystem backend
+	CDNURL  string                  // - is the base URL public URLs are resolved on, e.g. a Cloudfront distribution
+	S3      *s3Config.Config        // - is the config of the s3 backend
+	Presign *s3Config.PresignConfig // - is the config used to sign URLs by the s3 backend, optional
+}
+
+func (c Config) Validate() error {
+	err := validation.ValidateStruct(&c,
+		validation.Field(&c.Backend, validation.Required.Error("BLOB_BACKEND was not provided"), validation.In(BackendS3, BackendFilesystem, BackendMemory).Error("BLOB_BACKEND must be one of s3, filesystem or memory")),
+	)
+	if err != nil {
+		return err
+	}
+
+	switch c.Backend {
+	case BackendFilesystem:
+		return validation.ValidateStruct(&c,
+			validation.Field(&c.Path, validation.Required.Error("BLOB_PATH was not provided")),
+		)
+	case BackendS3:
+		return validation.ValidateStruct(&c,
+			validation.Field(&c.S3, validation.Required.Error("s3 config was not provided")),
+		)
+	}
+	return nil
+}
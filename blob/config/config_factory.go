@@ -0,0 +1,28 @@
+package config
+
+import (
+	"github.com/HomesNZ/go-common/env"
+	s3Config "github.com/HomesNZ/go-common/s3/config"
+)
+
+func NewFromEnv() (*Config, error) {
+	cfg := &Config{
+		Backend: env.GetString("BLOB_BACKEND", BackendS3),
+		Path:    env.GetString("BLOB_PATH", "./blob"),
+		CDNURL:  env.GetString("CDN_URL", ""),
+	}
+	if cfg.Backend == BackendS3 {
+		cfg.S3 = s3Config.NewFromEnv()
+		// Signing needs static keys, so signed URLs aren't available with credentials from the default chain, e.g. an
+		// IAM role.
+		if presign := s3Config.NewPresignConfigFromEnv(); presign.Validate() == nil {
+			cfg.Presign = presign
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
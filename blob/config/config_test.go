@@ -0,0 +1,54 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Config")
+}
+
+var _ = Describe("Config", func() {
+	Describe("#NewFromEnv", func() {
+		BeforeEach(func() {
+			os.Setenv("AWS_S3_BUCKET", "test-bucket")
+		})
+		AfterEach(func() {
+			os.Unsetenv("AWS_S3_BUCKET")
+			os.Unsetenv("AWS_ACCESS_KEY_ID")
+			os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+		})
+
+		It("doesn't require static keys for the s3 backend", func() {
+			os.Unsetenv("AWS_ACCESS_KEY_ID")
+			os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+			cfg, err := NewFromEnv()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.S3).NotTo(BeNil())
+			Expect(cfg.Presign).To(BeNil())
+		})
+		It("signs URLs with static keys when they're set", func() {
+			os.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+			os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+			cfg, err := NewFromEnv()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Presign).NotTo(BeNil())
+			Expect(cfg.Presign.AccessKeyID).To(Equal("AKID"))
+		})
+	})
+	Describe("#Validate", func() {
+		It("requires a backend", func() {
+			Expect(Config{}.Validate()).To(HaveOccurred())
+			Expect(Config{Backend: "gcs"}.Validate()).To(HaveOccurred())
+			Expect(Config{Backend: BackendMemory}.Validate()).NotTo(HaveOccurred())
+		})
+		It("requires the s3 config for the s3 backend", func() {
+			Expect(Config{Backend: BackendS3}.Validate()).To(HaveOccurred())
+		})
+	})
+})
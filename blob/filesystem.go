@@ -0,0 +1,198 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// filesystemStore is a Store backed by a directory, intended for local development. The content type is derived
+// from the file extension and metadata isn't persisted.
+type filesystemStore struct {
+	root    string
+	options *options
+}
+
+// NewFilesystem returns a Store which keeps objects in files under root, creating it if needed. Public URLs default
+// to file:// URLs, use WithURLResolver if the directory is served over HTTP.
+func NewFilesystem(root string, opts ...Option) (Store, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, errors.Wrap(err, "create blob root")
+	}
+	return &filesystemStore{
+		root: root,
+		options: newOptions(func(key string) string {
+			return "file://" + filepath.ToSlash(filepath.Join(root, filepath.FromSlash(key)))
+		}, opts),
+	}, nil
+}
+
+// path returns the file for key, rejecting keys which would escape the root.
+func (s *filesystemStore) path(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if cleaned == "/" || strings.HasSuffix(key, "/") {
+		return "", errors.Errorf("blob: invalid key %q", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(cleaned)), nil
+}
+
+func (s *filesystemStore) Put(ctx context.Context, key string, b []byte, opts ...PutOption) error {
+	return s.PutStream(ctx, key, bytes.NewReader(b), opts...)
+}
+
+// PutStream writes to a temporary file which is renamed once complete, so readers never see a partial object.
+func (s *filesystemStore) PutStream(ctx context.Context, key string, r io.Reader, opts ...PutOption) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(p), ".blob-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (s *filesystemStore) Get(ctx context.Context, key string) ([]byte, error) {
+	r, _, err := s.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+func (s *filesystemStore) Open(ctx context.Context, key string) (io.ReadCloser, Info, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, Info{}, err
+	}
+	f, err := os.Open(p)
+	if os.IsNotExist(err) {
+		return nil, Info{}, errors.Wrap(ErrNotFound, key)
+	}
+	if err != nil {
+		return nil, Info{}, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, Info{}, err
+	}
+	return f, s.info(key, stat), nil
+}
+
+func (s *filesystemStore) Stat(ctx context.Context, key string) (Info, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return Info{}, err
+	}
+	stat, err := os.Stat(p)
+	if os.IsNotExist(err) || (err == nil && stat.IsDir()) {
+		return Info{}, errors.Wrap(ErrNotFound, key)
+	}
+	if err != nil {
+		return Info{}, err
+	}
+	return s.info(key, stat), nil
+}
+
+func (s *filesystemStore) info(key string, stat os.FileInfo) Info {
+	return Info{
+		Key:          key,
+		Size:         stat.Size(),
+		ContentType:  mime.TypeByExtension(path.Ext(key)),
+		ETag:         etag(key, stat.Size(), stat.ModTime()),
+		LastModified: stat.ModTime(),
+	}
+}
+
+func (s *filesystemStore) List(ctx context.Context, prefix string, fn func(Info) error) error {
+	var infos []Info
+	err := filepath.Walk(s.root, func(p string, stat os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if stat.IsDir() || strings.HasPrefix(stat.Name(), ".blob-") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if strings.HasPrefix(key, prefix) {
+			info := s.info(key, stat)
+			info.ContentType = ""
+			infos = append(infos, info)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Key < infos[j].Key })
+	for _, info := range infos {
+		if err := fn(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *filesystemStore) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// SignedURL returns the public URL, as files can't be signed.
+func (s *filesystemStore) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return s.URL(key), nil
+}
+
+func (s *filesystemStore) URL(key string) string {
+	return s.options.resolver(key)
+}
+
+// etag returns a weak identifier of an object version, used by the backends which don't hash the content.
+func etag(key string, size int64, modified time.Time) string {
+	sum := md5.Sum([]byte(fmt.Sprintf("%s|%d|%d", key, size, modified.UnixNano())))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,17 @@
+module github.com/HomesNZ/go-common/blob
+
+go 1.15
+
+require (
+	github.com/HomesNZ/go-common/env v0.0.0-20211028023116-06d601bd3f83
+	github.com/HomesNZ/go-common/s3 v0.0.0-00010101000000-000000000000
+	github.com/aws/aws-sdk-go-v2 v1.11.0
+	github.com/aws/smithy-go v1.9.0
+	github.com/go-ozzo/ozzo-validation v3.6.0+incompatible
+	github.com/golang/mock v1.6.0
+	github.com/onsi/ginkgo v1.16.5
+	github.com/onsi/gomega v1.17.0
+	github.com/pkg/errors v0.9.1
+)
+
+replace github.com/HomesNZ/go-common/s3 => ../s3
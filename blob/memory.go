@@ -0,0 +1,143 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MemoryStore is a Store which keeps objects in memory, intended for tests.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+	options *options
+}
+
+type memoryObject struct {
+	data []byte
+	info Info
+}
+
+// NewMemory returns an empty MemoryStore. Public URLs default to mem:// URLs.
+func NewMemory(opts ...Option) *MemoryStore {
+	return &MemoryStore{
+		objects: map[string]memoryObject{},
+		options: newOptions(func(key string) string {
+			return "mem://" + key
+		}, opts),
+	}
+}
+
+func (s *MemoryStore) Put(ctx context.Context, key string, b []byte, opts ...PutOption) error {
+	o := NewPutOptions(opts)
+	data := append([]byte(nil), b...)
+	sum := md5.Sum(data)
+	metadata := map[string]string{}
+	for k, v := range o.Metadata {
+		metadata[k] = v
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = memoryObject{
+		data: data,
+		info: Info{
+			Key:          key,
+			Size:         int64(len(data)),
+			ContentType:  o.ContentType,
+			ETag:         hex.EncodeToString(sum[:]),
+			LastModified: time.Now(),
+			Metadata:     metadata,
+		},
+	}
+	return nil
+}
+
+func (s *MemoryStore) PutStream(ctx context.Context, key string, r io.Reader, opts ...PutOption) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, key, b, opts...)
+}
+
+func (s *MemoryStore) get(key string) (memoryObject, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	object, ok := s.objects[key]
+	if !ok {
+		return memoryObject{}, errors.Wrap(ErrNotFound, key)
+	}
+	return object, nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	object, err := s.get(key)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), object.data...), nil
+}
+
+func (s *MemoryStore) Open(ctx context.Context, key string) (io.ReadCloser, Info, error) {
+	object, err := s.get(key)
+	if err != nil {
+		return nil, Info{}, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(object.data)), object.info, nil
+}
+
+func (s *MemoryStore) Stat(ctx context.Context, key string) (Info, error) {
+	object, err := s.get(key)
+	if err != nil {
+		return Info{}, err
+	}
+	return object.info, nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, prefix string, fn func(Info) error) error {
+	s.mu.RLock()
+	infos := make([]Info, 0, len(s.objects))
+	for key, object := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			info := object.info
+			info.ContentType = ""
+			info.Metadata = nil
+			infos = append(infos, info)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Key < infos[j].Key })
+	for _, info := range infos {
+		if err := fn(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	return nil
+}
+
+// SignedURL returns the public URL, as objects in memory can't be signed.
+func (s *MemoryStore) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return s.URL(key), nil
+}
+
+func (s *MemoryStore) URL(key string) string {
+	return s.options.resolver(key)
+}
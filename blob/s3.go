@@ -0,0 +1,113 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"time"
+
+	"github.com/HomesNZ/go-common/s3"
+	"github.com/HomesNZ/go-common/s3/config"
+	"github.com/pkg/errors"
+)
+
+// s3Store is a Store backed by the s3 package.
+type s3Store struct {
+	service s3.Service
+	presign s3.PresignService
+	bucket  string
+	options *options
+}
+
+// NewS3 returns a Store backed by service, using the bucket in cfg. Public URLs are resolved with cfg.AssetURL, as
+// s3.Service does. presign is used for SignedURL and can be nil if signed URLs aren't needed.
+func NewS3(service s3.Service, presign s3.PresignService, cfg *config.Config, opts ...Option) Store {
+	return &s3Store{
+		service: service,
+		presign: presign,
+		bucket:  cfg.BucketName,
+		options: newOptions(cfg.AssetURL, opts),
+	}
+}
+
+func (s *s3Store) Put(ctx context.Context, key string, b []byte, opts ...PutOption) error {
+	return s.PutStream(ctx, key, bytes.NewReader(b), opts...)
+}
+
+func (s *s3Store) PutStream(ctx context.Context, key string, r io.Reader, opts ...PutOption) error {
+	o := NewPutOptions(opts)
+	transferOpts := []s3.TransferOption{s3.WithMetadata(o.Metadata)}
+	if o.ContentType != "" {
+		transferOpts = append(transferOpts, s3.WithContentType(o.ContentType))
+	}
+	_, err := s.service.UploadStream(ctx, key, r, transferOpts...)
+	return err
+}
+
+func (s *s3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	b, err := s.service.Download(ctx, key)
+	if err != nil {
+		return nil, notFound(err, key)
+	}
+	return b, nil
+}
+
+func (s *s3Store) Open(ctx context.Context, key string) (io.ReadCloser, Info, error) {
+	r, info, err := s.service.Open(ctx, key)
+	if err != nil {
+		return nil, Info{}, notFound(err, key)
+	}
+	return r, objectInfo(info), nil
+}
+
+func (s *s3Store) Stat(ctx context.Context, key string) (Info, error) {
+	info, err := s.service.Head(ctx, key)
+	if err != nil {
+		return Info{}, notFound(err, key)
+	}
+	return objectInfo(info), nil
+}
+
+func (s *s3Store) List(ctx context.Context, prefix string, fn func(Info) error) error {
+	it := s.service.List(ctx, prefix)
+	for it.Next() {
+		if err := fn(objectInfo(it.Object())); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
+func (s *s3Store) Delete(ctx context.Context, key string) error {
+	return s.service.Delete(ctx, key)
+}
+
+func (s *s3Store) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if s.presign == nil {
+		return "", errors.New("blob: s3 store has no presign service")
+	}
+	return s.presign.SignedGetObjectUrl(ctx, s.bucket, key, "", expiry)
+}
+
+func (s *s3Store) URL(key string) string {
+	return s.options.resolver(key)
+}
+
+func objectInfo(info s3.ObjectInfo) Info {
+	return Info{
+		Key:          info.Key,
+		Size:         info.Size,
+		ContentType:  info.ContentType,
+		ETag:         info.ETag,
+		LastModified: info.LastModified,
+		Metadata:     info.Metadata,
+	}
+}
+
+// notFound wraps ErrNotFound if err was caused by the object not existing.
+func notFound(err error, key string) error {
+	if s3.IsNotFound(err) {
+		return errors.Wrap(ErrNotFound, key)
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+package blob
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/HomesNZ/go-common/s3"
+
+	"github.com/HomesNZ/go-common/s3/config"
+	mock_s3 "github.com/HomesNZ/go-common/s3/mock"
+	awsHttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	smithyHttp "github.com/aws/smithy-go/transport/http"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("s3Store", func() {
+	var (
+		ctrl    *gomock.Controller
+		service *mock_s3.MockService
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		service = mock_s3.NewMockService(ctrl)
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("returns ErrNotFound for missing objects", func() {
+		notFound := &awsHttp.ResponseError{ResponseError: &smithyHttp.ResponseError{Response: &smithyHttp.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}}}
+		service.EXPECT().Download(gomock.Any(), "missing").Return(nil, notFound)
+
+		store := NewS3(service, nil, &config.Config{BucketName: "test-bucket"})
+		_, err := store.Get(context.Background(), "missing")
+		Expect(IsNotFound(err)).To(BeTrue())
+	})
+	It("returns the object info", func() {
+		modified := time.Date(2022, 4, 1, 9, 30, 0, 0, time.UTC)
+		service.EXPECT().Head(gomock.Any(), "1.jpg").Return(s3.ObjectInfo{
+			Key:          "1.jpg",
+			Size:         1024,
+			ContentType:  "image/jpeg",
+			ETag:         `"abc"`,
+			LastModified: modified,
+			Metadata:     map[string]string{"listing": "1"},
+		}, nil)
+
+		store := NewS3(service, nil, &config.Config{BucketName: "test-bucket"})
+		info, err := store.Stat(context.Background(), "1.jpg")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info).To(Equal(Info{
+			Key:          "1.jpg",
+			Size:         1024,
+			ContentType:  "image/jpeg",
+			ETag:         `"abc"`,
+			LastModified: modified,
+			Metadata:     map[string]string{"listing": "1"},
+		}))
+	})
+	It("resolves URLs like s3.Service", func() {
+		store := NewS3(service, nil, &config.Config{BucketName: "test-bucket", Endpoint: "http://localhost:9000", UsePathStyle: true})
+		Expect(store.URL("1.jpg")).To(Equal("http://localhost:9000/test-bucket/1.jpg"))
+
+		store = NewS3(service, nil, &config.Config{CloudfrontURL: "https://cdn.example.com"})
+		Expect(store.URL("1.jpg")).To(Equal("https://cdn.example.com/1.jpg"))
+	})
+})
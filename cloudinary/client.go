@@ -0,0 +1,247 @@
+package cloudinary
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HomesNZ/go-common/cloudinary/config"
+	"github.com/pkg/errors"
+)
+
+const (
+	ResourceTypeImage = "image"
+	ResourceTypeVideo = "video"
+	ResourceTypeRaw   = "raw"
+	ResourceTypeAuto  = "auto"
+)
+
+// ErrNotFound is returned by Delete when the asset doesn't exist.
+var ErrNotFound = errors.New("cloudinary: asset not found")
+
+// Client is a Cloudinary API client.
+type Client struct {
+	config *config.Config
+	http   *http.Client
+	now    func() time.Time
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for API requests. Defaults to a client with a 60 second timeout.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.http = client
+	}
+}
+
+// NewFromEnv returns a Client configured with the CLOUDINARY_* environment variables.
+func NewFromEnv(options ...Option) (*Client, error) {
+	cfg, err := config.NewFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return New(cfg, options...)
+}
+
+// New returns a Client for cfg.
+func New(cfg *config.Config, options ...Option) (*Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	c := &Client{
+		config: cfg,
+		http:   &http.Client{Timeout: 60 * time.Second},
+		now:    time.Now,
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+	return c, nil
+}
+
+// UploadOption configures Upload.
+type UploadOption func(*uploadOptions)
+
+type uploadOptions struct {
+	resourceType string
+	params       map[string]string
+}
+
+// WithPublicID sets the public ID of the uploaded asset. Cloudinary generates a random ID if it isn't set.
+func WithPublicID(publicID string) UploadOption {
+	return func(o *uploadOptions) {
+		o.params["public_id"] = publicID
+	}
+}
+
+// WithFolder sets the folder the asset is uploaded to, which prefixes its public ID.
+func WithFolder(folder string) UploadOption {
+	return func(o *uploadOptions) {
+		o.params["folder"] = folder
+	}
+}
+
+// WithTags tags the uploaded asset.
+func WithTags(tags ...string) UploadOption {
+	return func(o *uploadOptions) {
+		o.params["tags"] = strings.Join(tags, ",")
+	}
+}
+
+// WithOverwrite sets whether an existing asset with the same public ID is replaced. Cloudinary defaults to true.
+func WithOverwrite(overwrite bool) UploadOption {
+	return func(o *uploadOptions) {
+		o.params["overwrite"] = strconv.FormatBool(overwrite)
+	}
+}
+
+// WithEager generates the transformations on upload rather than on first request.
+func WithEager(transformations ...Transformation) UploadOption {
+	return func(o *uploadOptions) {
+		eager := make([]string, 0, len(transformations))
+		for _, t := range transformations {
+			eager = append(eager, t.String())
+		}
+		o.params["eager"] = strings.Join(eager, "|")
+	}
+}
+
+// WithResourceType sets the resource type of the upload, one of the ResourceType constants. Defaults to image.
+func WithResourceType(resourceType string) UploadOption {
+	return func(o *uploadOptions) {
+		o.resourceType = resourceType
+	}
+}
+
+// UploadResult is the response of a successful upload.
+type UploadResult struct {
+	PublicID     string        `json:"public_id"`
+	Version      int64         `json:"version"`
+	Format       string        `json:"format"`
+	ResourceType string        `json:"resource_type"`
+	Width        int           `json:"width"`
+	Height       int           `json:"height"`
+	Bytes        int64         `json:"bytes"`
+	URL          string        `json:"url"`
+	SecureURL    string        `json:"secure_url"`
+	Eager        []EagerResult `json:"eager"`
+}
+
+// EagerResult is an eager transformation generated on upload.
+type EagerResult struct {
+	Transformation string `json:"transformation"`
+	URL            string `json:"url"`
+	SecureURL      string `json:"secure_url"`
+}
+
+// apiError is the body of an unsuccessful API response.
+type apiError struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Upload uploads data to Cloudinary.
+func (c *Client) Upload(ctx context.Context, data io.Reader, options ...UploadOption) (*UploadResult, error) {
+	o := &uploadOptions{resourceType: ResourceTypeImage, params: map[string]string{}}
+	for _, opt := range options {
+		opt(o)
+	}
+
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	for k, v := range c.signedParams(o.params) {
+		if err := form.WriteField(k, v); err != nil {
+			return nil, err
+		}
+	}
+	name := o.params["public_id"]
+	if name == "" {
+		name = "file"
+	}
+	file, err := form.CreateFormFile("file", name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(file, data); err != nil {
+		return nil, errors.Wrap(err, "read upload")
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	result := &UploadResult{}
+	if err := c.post(ctx, c.apiURL(o.resourceType, "upload"), form.FormDataContentType(), body, result); err != nil {
+		return nil, errors.Wrap(err, "cloudinary upload")
+	}
+	return result, nil
+}
+
+// Delete deletes the asset with the public ID. resourceType defaults to image if it's empty.
+func (c *Client) Delete(ctx context.Context, publicID, resourceType string) error {
+	if resourceType == "" {
+		resourceType = ResourceTypeImage
+	}
+
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	for k, v := range c.signedParams(map[string]string{"public_id": publicID, "invalidate": "true"}) {
+		if err := form.WriteField(k, v); err != nil {
+			return err
+		}
+	}
+	if err := form.Close(); err != nil {
+		return err
+	}
+
+	result := struct {
+		Result string `json:"result"`
+	}{}
+	if err := c.post(ctx, c.apiURL(resourceType, "destroy"), form.FormDataContentType(), body, &result); err != nil {
+		return errors.Wrap(err, "cloudinary destroy")
+	}
+	switch result.Result {
+	case "ok":
+		return nil
+	case "not found":
+		return errors.Wrap(ErrNotFound, publicID)
+	default:
+		return errors.Errorf("cloudinary destroy: unexpected result %q", result.Result)
+	}
+}
+
+func (c *Client) apiURL(resourceType, action string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", strings.TrimSuffix(c.config.APIURL, "/"), c.config.CloudName, resourceType, action)
+}
+
+func (c *Client) post(ctx context.Context, url, contentType string, body io.Reader, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := apiError{}
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err == nil && apiErr.Error.Message != "" {
+			return errors.Errorf("%d: %s", resp.StatusCode, apiErr.Error.Message)
+		}
+		return errors.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,218 @@
+package cloudinary_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/HomesNZ/go-common/cloudinary"
+	"github.com/HomesNZ/go-common/cloudinary/config"
+	"github.com/HomesNZ/go-common/testutil"
+	"github.com/pkg/errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		server *testutil.MockFixtureServer
+		client *Client
+	)
+
+	BeforeEach(func() {
+		server = testutil.NewMockFixtureServer()
+
+		var err error
+		client, err = New(&config.Config{
+			CloudName:   "testcloud",
+			APIKey:      "key",
+			APISecret:   "abcd",
+			APIURL:      server.URL,
+			DeliveryURL: config.DefaultDeliveryURL,
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe(".Upload", func() {
+		It("returns the upload result", func() {
+			server.Fixture = []byte(`{"public_id":"listings/test","version":1369431906,"format":"jpg","resource_type":"image","width":800,"height":600,"secure_url":"https://res.cloudinary.com/testcloud/image/upload/v1369431906/listings/test.jpg"}`)
+
+			result, err := client.Upload(context.Background(), strings.NewReader("data"),
+				WithPublicID("test"),
+				WithFolder("listings"),
+				WithTags("listing", "photo"),
+				WithOverwrite(false),
+				WithEager(Transformation{Crop: "fill", Width: 200, Height: 200}),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(server.RequestCount).To(Equal(int64(1)))
+			Expect(result.PublicID).To(Equal("listings/test"))
+			Expect(result.Width).To(Equal(800))
+		})
+
+		It("returns the API error message", func() {
+			server.Status = http.StatusBadRequest
+			server.Fixture = []byte(`{"error":{"message":"Invalid image file"}}`)
+
+			_, err := client.Upload(context.Background(), strings.NewReader("data"))
+			Expect(err).To(MatchError(ContainSubstring("Invalid image file")))
+		})
+	})
+
+	Describe(".Delete", func() {
+		It("deletes the asset", func() {
+			server.Fixture = []byte(`{"result":"ok"}`)
+
+			Expect(client.Delete(context.Background(), "listings/test", "")).To(Succeed())
+			Expect(server.RequestCount).To(Equal(int64(1)))
+		})
+
+		It("returns ErrNotFound when the asset doesn't exist", func() {
+			server.Fixture = []byte(`{"result":"not found"}`)
+
+			err := client.Delete(context.Background(), "listings/test", "")
+			Expect(errors.Cause(err)).To(Equal(ErrNotFound))
+		})
+	})
+
+	Describe(".URL", func() {
+		It("builds a delivery URL with chained transformations", func() {
+			u := client.URL("listings/test.jpg",
+				Transformation{Crop: "fill", Gravity: "auto", Width: 400, Height: 300},
+				Transformation{Quality: "auto", Format: "webp"},
+			)
+			Expect(u).To(Equal("https://res.cloudinary.com/testcloud/image/upload/c_fill,g_auto,w_400,h_300/q_auto,f_webp/listings/test.jpg"))
+		})
+
+		It("leaves out empty transformations", func() {
+			Expect(client.URL("test.jpg", Transformation{})).To(Equal("https://res.cloudinary.com/testcloud/image/upload/test.jpg"))
+		})
+	})
+
+	Describe(".Sign", func() {
+		It("signs the sorted parameters with the API secret", func() {
+			signature := client.Sign(map[string]string{
+				"eager":     "w_400,h_300,c_pad|w_260,h_200,c_crop",
+				"public_id": "sample_image",
+				"timestamp": "1315060510",
+				"api_key":   "key",
+				"file":      "ignored",
+			})
+			Expect(signature).To(Equal("bfd09f95f331f558cbd1320e67aa8d488770583e"))
+		})
+	})
+
+	Describe(".SignUpload", func() {
+		It("returns the upload URL and signed parameters", func() {
+			upload := client.SignUpload(map[string]string{"folder": "listings"})
+			Expect(upload.URL).To(Equal(server.URL + "/testcloud/auto/upload"))
+			Expect(upload.Params).To(HaveKeyWithValue("api_key", "key"))
+			Expect(upload.Params).To(HaveKeyWithValue("folder", "listings"))
+			Expect(upload.Params).To(HaveKey("timestamp"))
+			Expect(upload.Params["signature"]).To(Equal(client.Sign(upload.Params)))
+		})
+	})
+})
+
+// formRequest is a multipart form posted to a formServer.
+type formRequest struct {
+	path   string
+	fields map[string]string
+	file   string
+}
+
+// formServer records the multipart forms posted to it and responds with fixture.
+func formServer(fixture string, requests *[]formRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Expect(r.ParseMultipartForm(1 << 20)).To(Succeed())
+		req := formRequest{path: r.URL.Path, fields: map[string]string{}}
+		for k, v := range r.MultipartForm.Value {
+			Expect(v).To(HaveLen(1))
+			req.fields[k] = v[0]
+		}
+		if files := r.MultipartForm.File["file"]; len(files) > 0 {
+			f, err := files[0].Open()
+			Expect(err).NotTo(HaveOccurred())
+			b, err := ioutil.ReadAll(f)
+			Expect(err).NotTo(HaveOccurred())
+			req.file = string(b)
+		}
+		*requests = append(*requests, req)
+		w.Write([]byte(fixture))
+	}))
+}
+
+var _ = Describe("Client signed requests", func() {
+	var requests []formRequest
+
+	newClient := func(server *httptest.Server) *Client {
+		client, err := New(&config.Config{
+			CloudName:   "testcloud",
+			APIKey:      "key",
+			APISecret:   "abcd",
+			APIURL:      server.URL,
+			DeliveryURL: config.DefaultDeliveryURL,
+		}, WithNow(func() time.Time { return time.Unix(1315060510, 0) }))
+		Expect(err).NotTo(HaveOccurred())
+		return client
+	}
+
+	BeforeEach(func() {
+		requests = nil
+	})
+
+	It("posts the upload parameters with their signature", func() {
+		server := formServer(`{"public_id":"listings/test"}`, &requests)
+		defer server.Close()
+
+		_, err := newClient(server).Upload(context.Background(), strings.NewReader("data"),
+			WithPublicID("test"),
+			WithFolder("listings"),
+			WithTags("listing", "photo"),
+			WithOverwrite(false),
+			WithEager(Transformation{Crop: "fill", Width: 200, Height: 200}),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].path).To(Equal("/testcloud/image/upload"))
+		Expect(requests[0].file).To(Equal("data"))
+		// sha1("eager=c_fill,w_200,h_200&folder=listings&overwrite=false&public_id=test&tags=listing,photo&timestamp=1315060510abcd")
+		Expect(requests[0].fields).To(Equal(map[string]string{
+			"public_id": "test",
+			"folder":    "listings",
+			"tags":      "listing,photo",
+			"overwrite": "false",
+			"eager":     "c_fill,w_200,h_200",
+			"timestamp": "1315060510",
+			"api_key":   "key",
+			"signature": "4ce6bc26b1032c21b2dd918777d47682b829c6da",
+		}))
+	})
+
+	It("posts the delete parameters with their signature", func() {
+		server := formServer(`{"result":"ok"}`, &requests)
+		defer server.Close()
+
+		Expect(newClient(server).Delete(context.Background(), "listings/test", ResourceTypeVideo)).To(Succeed())
+
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].path).To(Equal("/testcloud/video/destroy"))
+		// sha1("invalidate=true&public_id=listings/test&timestamp=1315060510abcd")
+		Expect(requests[0].fields).To(Equal(map[string]string{
+			"public_id":  "listings/test",
+			"invalidate": "true",
+			"timestamp":  "1315060510",
+			"api_key":    "key",
+			"signature":  "a508935637ae1f6151ca2b5d98e898bfd00fbcd0",
+		}))
+	})
+})
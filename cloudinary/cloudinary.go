@@ -15,6 +15,8 @@ var (
 )
 
 // Service returns a Cloudinary Service singleton.
+//
+// Deprecated: use New or NewFromEnv, which take a context and support upload options and deletion by public ID.
 func Service() (*CDNService, error) {
 	if service != nil {
 		return service, nil
@@ -51,6 +53,8 @@ func Service() (*CDNService, error) {
 }
 
 // CDNService is a Cloudinary concrete implementation of cdn.Interface
+//
+// Deprecated: use Client.
 type CDNService struct {
 	service *cloudinary.Service
 }
@@ -0,0 +1,30 @@
+package config
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+const (
+	// DefaultAPIURL is the base URL of the Cloudinary upload API.
+	DefaultAPIURL = "https://api.cloudinary.com/v1_1"
+	// DefaultDeliveryURL is the base URL assets are delivered from.
+	DefaultDeliveryURL = "https://res.cloudinary.com"
+)
+
+type Config struct {
+	CloudName   string
+	APIKey      string
+	APISecret   string
+	APIURL      string // - is the base URL of the upload API, the cloud name is appended to it
+	DeliveryURL string // - is the base URL of delivered assets, the cloud name is appended to it
+}
+
+func (c Config) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.CloudName, validation.Required.Error("CLOUDINARY_CLOUD_NAME is not set")),
+		validation.Field(&c.APIKey, validation.Required.Error("CLOUDINARY_API_KEY is not set")),
+		validation.Field(&c.APISecret, validation.Required.Error("CLOUDINARY_API_SECRET is not set")),
+		validation.Field(&c.APIURL, validation.Required.Error("CLOUDINARY_API_URL is not set")),
+		validation.Field(&c.DeliveryURL, validation.Required.Error("CLOUDINARY_DELIVERY_URL is not set")),
+	)
+}
@@ -0,0 +1,21 @@
+package config
+
+import (
+	"github.com/HomesNZ/go-common/env"
+)
+
+func NewFromEnv() (*Config, error) {
+	cfg := &Config{
+		CloudName:   env.GetString("CLOUDINARY_CLOUD_NAME", ""),
+		APIKey:      env.GetString("CLOUDINARY_API_KEY", ""),
+		APISecret:   env.GetString("CLOUDINARY_API_SECRET", ""),
+		APIURL:      env.GetString("CLOUDINARY_API_URL", DefaultAPIURL),
+		DeliveryURL: env.GetString("CLOUDINARY_DELIVERY_URL", DefaultDeliveryURL),
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,10 @@
+package cloudinary
+
+import "time"
+
+// WithNow sets the clock used to timestamp signed requests, so that tests can check the signatures.
+func WithNow(now func() time.Time) Option {
+	return func(c *Client) {
+		c.now = now
+	}
+}
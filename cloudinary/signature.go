@@ -0,0 +1,62 @@
+package cloudinary
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Sign returns the signature of the API request parameters. The parameters are sorted and joined as a query string
+// without URL encoding, then hashed with the API secret.
+// https://cloudinary.com/documentation/signatures
+func (c *Client) Sign(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k, v := range params {
+		// These parameters aren't signed.
+		if v == "" || k == "file" || k == "api_key" || k == "resource_type" || k == "cloud_name" || k == "signature" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, params[k]))
+	}
+	sum := sha1.Sum([]byte(strings.Join(pairs, "&") + c.config.APISecret))
+	return hex.EncodeToString(sum[:])
+}
+
+// signedParams returns params with the timestamp, API key and signature added.
+func (c *Client) signedParams(params map[string]string) map[string]string {
+	signed := make(map[string]string, len(params)+3)
+	for k, v := range params {
+		if v != "" {
+			signed[k] = v
+		}
+	}
+	signed["timestamp"] = strconv.FormatInt(c.now().Unix(), 10)
+	signed["signature"] = c.Sign(signed)
+	signed["api_key"] = c.config.APIKey
+	return signed
+}
+
+// SignedUpload is what a browser needs to upload directly to Cloudinary: it posts a multipart form to URL with
+// Params and the file.
+type SignedUpload struct {
+	URL    string            `json:"url"`
+	Params map[string]string `json:"params"`
+}
+
+// SignUpload signs the upload parameters, e.g. folder and tags, for a direct upload from the browser. The browser
+// must send the parameters unchanged, and the signature expires after an hour.
+func (c *Client) SignUpload(params map[string]string) SignedUpload {
+	return SignedUpload{
+		URL:    c.apiURL(ResourceTypeAuto, "upload"),
+		Params: c.signedParams(params),
+	}
+}
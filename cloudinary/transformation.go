@@ -0,0 +1,55 @@
+package cloudinary
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Transformation is a Cloudinary image transformation. Empty fields are left out.
+// https://cloudinary.com/documentation/transformation_reference
+type Transformation struct {
+	Crop    string // - is the crop mode, e.g. fill, fit, limit, thumb or pad
+	Gravity string // - is the focus of a crop, e.g. auto, face or center
+	Width   int
+	Height  int
+	Quality string // - is a quality level 1-100, or auto
+	Format  string // - is the delivery format, e.g. jpg, webp or auto
+}
+
+// String returns the transformation as a URL component, e.g. c_fill,g_auto,w_200,h_200.
+func (t Transformation) String() string {
+	var parts []string
+	add := func(prefix, value string) {
+		if value != "" {
+			parts = append(parts, prefix+"_"+value)
+		}
+	}
+	add("c", t.Crop)
+	add("g", t.Gravity)
+	if t.Width > 0 {
+		add("w", strconv.Itoa(t.Width))
+	}
+	if t.Height > 0 {
+		add("h", strconv.Itoa(t.Height))
+	}
+	add("q", t.Quality)
+	add("f", t.Format)
+	return strings.Join(parts, ",")
+}
+
+// URL returns the delivery URL of an image with the transformations applied in order.
+func (c *Client) URL(publicID string, transformations ...Transformation) string {
+	return c.ResourceURL(ResourceTypeImage, publicID, transformations...)
+}
+
+// ResourceURL returns the delivery URL of an asset of resourceType with the transformations applied in order.
+func (c *Client) ResourceURL(resourceType, publicID string, transformations ...Transformation) string {
+	parts := []string{strings.TrimSuffix(c.config.DeliveryURL, "/"), c.config.CloudName, resourceType, "upload"}
+	for _, t := range transformations {
+		if s := t.String(); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	parts = append(parts, publicID)
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,223 @@
+package dbclient
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Column is a struct field which is mapped to a database column by its `db:"column"` tag.
+type Column struct {
+	Name  string
+	Index []int // - is the field index for reflect.Value.FieldByIndex
+}
+
+// Columns returns the columns of the struct type t, or a pointer to one, in field order. Fields of embedded structs
+// without a db tag are included as if they were fields of t, and fields tagged `db:"-"` or without a tag are left out.
+func Columns(t reflect.Type) ([]Column, error) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("dbclient: %s is not a struct", t)
+	}
+
+	var columns []Column
+	seen := map[string]bool{}
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag, tagged := f.Tag.Lookup("db")
+			fieldIndex := append(append([]int{}, index...), i)
+			if f.Anonymous && !tagged {
+				ft := f.Type
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					walk(ft, fieldIndex)
+					continue
+				}
+			}
+			name := strings.Split(tag, ",")[0]
+			if f.PkgPath != "" || name == "" || name == "-" || seen[name] {
+				continue
+			}
+			seen[name] = true
+			columns = append(columns, Column{Name: name, Index: fieldIndex})
+		}
+	}
+	walk(t, nil)
+	return columns, nil
+}
+
+// Statement is a SQL statement and its arguments.
+type Statement struct {
+	SQL  string
+	Args []interface{}
+}
+
+// BulkInsert builds INSERT statements for a slice of structs, using their db tags as the column list.
+//
+//	statements, err := dbclient.BulkInsert{
+//		Table:           "listing",
+//		Exclude:         []string{"created_at"},
+//		ConflictColumns: []string{"listing_id"},
+//	}.Statements(listings)
+type BulkInsert struct {
+	Table           string
+	Exclude         []string // - are columns which are left out, e.g. those with database defaults
+	ConflictColumns []string // - adds ON CONFLICT (columns) DO UPDATE SET for the UpdateColumns
+	UpdateColumns   []string // - are the columns updated on conflict, defaulting to every inserted non-conflict column
+	DoNothing       bool     // - adds ON CONFLICT DO NOTHING, or ON CONFLICT (columns) DO NOTHING with ConflictColumns
+	Returning       []string // - adds RETURNING columns
+}
+
+// Statements returns the statements inserting rows, a slice of structs or struct pointers. The rows are split into as
+// many statements as needed to keep each under the SQL_MAX_PLACEHOLDERS parameter limit.
+func (b BulkInsert) Statements(rows interface{}) ([]Statement, error) {
+	columns, values, err := BulkRows(rows, b.Exclude...)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	prefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES ", quoteIdentifier(b.Table), quoteIdentifiers(columns))
+	suffix, err := b.suffix(columns)
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []Statement
+	batchSize := SQL_MAX_PLACEHOLDERS / len(columns)
+	for i := 0; i < len(values); i += batchSize {
+		to := i + batchSize
+		if to > len(values) {
+			to = len(values)
+		}
+		statements = append(statements, Statement{
+			SQL:  prefix + generatePlaceholders(values[i:to]) + suffix,
+			Args: flattenArgs(values[i:to]),
+		})
+	}
+	return statements, nil
+}
+
+func (b BulkInsert) suffix(columns []string) (string, error) {
+	var suffix strings.Builder
+	switch {
+	case b.DoNothing && len(b.ConflictColumns) > 0:
+		fmt.Fprintf(&suffix, " ON CONFLICT (%s) DO NOTHING", quoteIdentifiers(b.ConflictColumns))
+	case b.DoNothing:
+		suffix.WriteString(" ON CONFLICT DO NOTHING")
+	case len(b.ConflictColumns) > 0:
+		update := b.UpdateColumns
+		if len(update) == 0 {
+			update = without(columns, b.ConflictColumns)
+		}
+		if len(update) == 0 {
+			return "", errors.New("dbclient: upsert has no columns to update")
+		}
+		set := make([]string, 0, len(update))
+		for _, c := range update {
+			set = append(set, fmt.Sprintf("%s = EXCLUDED.%s", quoteIdentifier(c), quoteIdentifier(c)))
+		}
+		fmt.Fprintf(&suffix, " ON CONFLICT (%s) DO UPDATE SET %s", quoteIdentifiers(b.ConflictColumns), strings.Join(set, ", "))
+	}
+	if len(b.Returning) > 0 {
+		fmt.Fprintf(&suffix, " RETURNING %s", quoteIdentifiers(b.Returning))
+	}
+	return suffix.String(), nil
+}
+
+// BulkRows returns the column names of rows, a slice of structs or struct pointers, and the values of each row in
+// column order. The excluded columns are left out.
+func BulkRows(rows interface{}, exclude ...string) ([]string, [][]interface{}, error) {
+	v := reflect.ValueOf(rows)
+	if v.Kind() != reflect.Slice {
+		return nil, nil, fmt.Errorf("dbclient: rows must be a slice, not %T", rows)
+	}
+
+	columns, err := Columns(v.Type().Elem())
+	if err != nil {
+		return nil, nil, err
+	}
+	excluded := map[string]bool{}
+	for _, c := range exclude {
+		excluded[c] = true
+	}
+	included := columns[:0:0]
+	names := make([]string, 0, len(columns))
+	for _, c := range columns {
+		if !excluded[c.Name] {
+			included = append(included, c)
+			names = append(names, c.Name)
+		}
+	}
+	if len(included) == 0 {
+		return nil, nil, errors.New("dbclient: rows have no db tagged columns")
+	}
+
+	values := make([][]interface{}, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		row := reflect.Indirect(v.Index(i))
+		if !row.IsValid() {
+			return nil, nil, fmt.Errorf("dbclient: row %d is nil", i)
+		}
+		rowValues := make([]interface{}, len(included))
+		for j, c := range included {
+			rowValues[j] = fieldByIndex(row, c.Index).Interface()
+		}
+		values = append(values, rowValues)
+	}
+	return names, values, nil
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex, returning a nil value rather than panicking for a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Zero(v.Type().Elem().FieldByIndex(index[i:]).Type)
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+func without(columns, exclude []string) []string {
+	excluded := map[string]bool{}
+	for _, c := range exclude {
+		excluded[c] = true
+	}
+	var remaining []string
+	for _, c := range columns {
+		if !excluded[c] {
+			remaining = append(remaining, c)
+		}
+	}
+	return remaining
+}
+
+// quoteIdentifier quotes each part of a possibly schema qualified identifier.
+func quoteIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+	}
+	return strings.Join(parts, ".")
+}
+
+func quoteIdentifiers(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdentifier(name)
+	}
+	return strings.Join(quoted, ",")
+}
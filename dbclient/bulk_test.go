@@ -0,0 +1,112 @@
+package dbclient
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type bulkAudit struct {
+	CreatedBy string `db:"created_by"`
+}
+
+type bulkListing struct {
+	ID      int    `db:"listing_id"`
+	Address string `db:"address"`
+	Price   *int   `db:"price"`
+	Notes   string `db:"-"`
+	Ignored string
+	secret  string `db:"secret"`
+	*bulkAudit
+}
+
+func TestColumns(t *testing.T) {
+	columns, err := Columns(reflect.TypeOf(&bulkListing{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, c := range columns {
+		names = append(names, c.Name)
+	}
+	want := []string{"listing_id", "address", "price", "created_by"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Columns() = %v, want %v", names, want)
+	}
+}
+
+func TestBulkInsert_Statements(t *testing.T) {
+	rows := []bulkListing{
+		{ID: 1, Address: "1 Queen Street", bulkAudit: &bulkAudit{CreatedBy: "import"}},
+		{ID: 2, Address: "2 Queen Street"},
+	}
+	tests := []struct {
+		name     string
+		insert   BulkInsert
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "inserts the tagged columns",
+			insert:   BulkInsert{Table: "public.listing", Exclude: []string{"price"}},
+			wantSQL:  `INSERT INTO "public"."listing" ("listing_id","address","created_by") VALUES ($1,$2,$3),($4,$5,$6)`,
+			wantArgs: []interface{}{1, "1 Queen Street", "import", 2, "2 Queen Street", ""},
+		},
+		{
+			name:    "upserts the non-conflict columns",
+			insert:  BulkInsert{Table: "listing", Exclude: []string{"price", "created_by"}, ConflictColumns: []string{"listing_id"}, Returning: []string{"listing_id"}},
+			wantSQL: `INSERT INTO "listing" ("listing_id","address") VALUES ($1,$2),($3,$4) ON CONFLICT ("listing_id") DO UPDATE SET "address" = EXCLUDED."address" RETURNING "listing_id"`,
+		},
+		{
+			name:    "does nothing on conflict",
+			insert:  BulkInsert{Table: "listing", Exclude: []string{"price", "created_by", "address"}, DoNothing: true},
+			wantSQL: `INSERT INTO "listing" ("listing_id") VALUES ($1),($2) ON CONFLICT DO NOTHING`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := tt.insert.Statements(rows)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(statements) != 1 {
+				t.Fatalf("Statements() returned %d statements, want 1", len(statements))
+			}
+			if statements[0].SQL != tt.wantSQL {
+				t.Errorf("Statements() SQL = %v, want %v", statements[0].SQL, tt.wantSQL)
+			}
+			if tt.wantArgs != nil && !reflect.DeepEqual(statements[0].Args, tt.wantArgs) {
+				t.Errorf("Statements() Args = %v, want %v", statements[0].Args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBulkInsert_Statements_batches(t *testing.T) {
+	rows := make([]bulkListing, 40000)
+	statements, err := BulkInsert{Table: "listing", Exclude: []string{"price", "created_by"}}.Statements(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two columns are included, so 32767 rows fit in a statement.
+	if len(statements) != 2 {
+		t.Fatalf("Statements() returned %d statements, want 2", len(statements))
+	}
+	if got := len(statements[0].Args); got != 65534 {
+		t.Errorf("first statement has %d args, want 65534", got)
+	}
+	if !strings.HasSuffix(statements[1].SQL, "($14465,$14466)") {
+		t.Errorf("second statement doesn't restart placeholders: %s", statements[1].SQL[len(statements[1].SQL)-40:])
+	}
+}
+
+func TestPlaceholders_batchesIncludedFields(t *testing.T) {
+	rows := make([]interface{}, 40000)
+	for i := range rows {
+		rows[i] = struct{ Foo, Bar, Baz string }{}
+	}
+	placeholders := Placeholders(rows, "Baz")
+	if len(placeholders) != 2 || len(placeholders[0].Args) != 65534 {
+		t.Errorf("Placeholders() returned %d batches, want 2 of up to 32767 rows", len(placeholders))
+	}
+}
@@ -31,7 +31,11 @@ func Placeholders(rawArgs []interface{}, toExclude ...string) []Placeholder {
 	}
 
 	var placeholders []Placeholder
-	fields := reflect.ValueOf(rawArgs[0]).NumField()
+	// Only the included fields are placeholders, so count those rather than every field of the struct.
+	fields := len(extractArgs(rawArgs[:1], keys)[0])
+	if fields == 0 {
+		return nil
+	}
 	batchSize := SQL_MAX_PLACEHOLDERS / fields
 	for i := 0; i < len(rawArgs); i += batchSize {
 		to := i + batchSize
@@ -0,0 +1,53 @@
+package dbclient
+
+import (
+	"context"
+	"strings"
+
+	bulk "github.com/HomesNZ/go-common/dbclient"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+)
+
+// CopyFromer copies rows into a table, e.g. a *pgxpool.Pool, *pgx.Conn or pgx.Tx.
+type CopyFromer interface {
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+// CopyFrom inserts rows, a slice of structs or struct pointers, into table using the COPY protocol, which is much
+// faster than INSERT for large numbers of rows. The columns are the db tags of the structs, less the excluded columns,
+// as for bulk.BulkInsert. COPY can't upsert, so use ExecBulk for conflicts.
+func CopyFrom(ctx context.Context, db CopyFromer, table string, rows interface{}, exclude ...string) (int64, error) {
+	columns, values, err := bulk.BulkRows(rows, exclude...)
+	if err != nil {
+		return 0, err
+	}
+	if len(values) == 0 {
+		return 0, nil
+	}
+	n, err := db.CopyFrom(ctx, pgx.Identifier(strings.Split(table, ".")), columns, pgx.CopyFromRows(values))
+	if err != nil {
+		return n, errors.Wrapf(err, "copy into %s", table)
+	}
+	return n, nil
+}
+
+// ExecBulk runs the statements built by insert for rows, on the transaction carried by ctx or db, and returns the
+// number of rows affected. Run it with WithTx if all of the statements should succeed or fail together.
+func ExecBulk(ctx context.Context, db Querier, insert bulk.BulkInsert, rows interface{}) (int64, error) {
+	statements, err := insert.Statements(rows)
+	if err != nil {
+		return 0, err
+	}
+
+	var affected int64
+	q := FromContext(ctx, db)
+	for _, s := range statements {
+		tag, err := q.Exec(ctx, s.SQL, s.Args...)
+		if err != nil {
+			return affected, errors.Wrapf(err, "bulk insert into %s", insert.Table)
+		}
+		affected += tag.RowsAffected()
+	}
+	return affected, nil
+}
@@ -0,0 +1,70 @@
+package dbclient
+
+import (
+	"context"
+
+	bulk "github.com/HomesNZ/go-common/dbclient"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type fakeCopyFromer struct {
+	table   pgx.Identifier
+	columns []string
+	rows    [][]interface{}
+}
+
+func (f *fakeCopyFromer) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	f.table, f.columns = tableName, columnNames
+	for rowSrc.Next() {
+		values, err := rowSrc.Values()
+		if err != nil {
+			return 0, err
+		}
+		f.rows = append(f.rows, values)
+	}
+	return int64(len(f.rows)), nil
+}
+
+type fakeExecer struct {
+	Querier
+	sql []string
+}
+
+func (f *fakeExecer) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	f.sql = append(f.sql, sql)
+	return pgconn.CommandTag("INSERT 0 2"), nil
+}
+
+type bulkListing struct {
+	ID      int    `db:"listing_id"`
+	Address string `db:"address"`
+}
+
+var _ = Describe("Bulk", func() {
+	listings := []bulkListing{{1, "1 Queen Street"}, {2, "2 Queen Street"}}
+
+	Describe("CopyFrom", func() {
+		It("copies the tagged columns", func() {
+			db := &fakeCopyFromer{}
+			n, err := CopyFrom(context.Background(), db, "public.listing", listings, "address")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(n).To(Equal(int64(2)))
+			Expect(db.table).To(Equal(pgx.Identifier{"public", "listing"}))
+			Expect(db.columns).To(Equal([]string{"listing_id"}))
+			Expect(db.rows).To(Equal([][]interface{}{{1}, {2}}))
+		})
+	})
+
+	Describe("ExecBulk", func() {
+		It("runs the statements", func() {
+			db := &fakeExecer{}
+			n, err := ExecBulk(context.Background(), db, bulk.BulkInsert{Table: "listing", ConflictColumns: []string{"listing_id"}}, listings)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(n).To(Equal(int64(2)))
+			Expect(db.sql).To(ConsistOf(ContainSubstring(`ON CONFLICT ("listing_id") DO UPDATE SET "address" = EXCLUDED."address"`)))
+		})
+	})
+})
@@ -0,0 +1,272 @@
+package dbclient
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/HomesNZ/go-common/dbclient/v4/config"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// Cluster routes queries between a primary and read replicas. Exec, Query, QueryRow and read/write transactions go
+// to the primary, and reads which can tolerate replication lag go through Reader.
+type Cluster struct {
+	next       uint64 // - is first so it's 64 bit aligned for atomic access
+	primary    *pgxpool.Pool
+	replicas   []*replica
+	selection  string
+	stickiness time.Duration
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+type replica struct {
+	pool    *pgxpool.Pool
+	healthy int32
+}
+
+func (r *replica) isHealthy() bool {
+	return atomic.LoadInt32(&r.healthy) == 1
+}
+
+func (r *replica) setHealthy(healthy bool) {
+	var v int32
+	if healthy {
+		v = 1
+	}
+	atomic.StoreInt32(&r.healthy, v)
+}
+
+// NewCluster connects to the primary at cfg.Host and a replica at each of cfg.ReplicaHosts. Replicas are health
+// checked every cfg.HealthCheckPeriod, and reads fall back to the primary while none are healthy.
+func NewCluster(ctx context.Context, cfg *config.Config) (*Cluster, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, errors.Wrap(err, "DB")
+	}
+	primaryConfig, err := connectionConfig(ctx, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "DB")
+	}
+
+	replicaConfigs := make([]*pgxpool.Config, 0, len(cfg.ReplicaHosts))
+	for _, host := range cfg.ReplicaHosts {
+		replicaCfg, err := cfg.Replica(host)
+		if err != nil {
+			return nil, errors.Wrap(err, "DB")
+		}
+		replicaConfig, err := connectionConfig(ctx, replicaCfg)
+		if err != nil {
+			return nil, errors.Wrap(err, "DB")
+		}
+		// An unavailable replica shouldn't stop the service starting, as reads fall back to the primary.
+		replicaConfig.LazyConnect = true
+		replicaConfigs = append(replicaConfigs, replicaConfig)
+	}
+
+	return newCluster(ctx, cfg, primaryConfig, replicaConfigs)
+}
+
+// NewClusterFromEnv returns a Cluster configured with the DB_* environment variables, with replicas at
+// DB_REPLICA_HOSTS.
+func NewClusterFromEnv(ctx context.Context) (*Cluster, error) {
+	return NewCluster(ctx, config.NewFromEnv())
+}
+
+func newCluster(ctx context.Context, cfg *config.Config, primaryConfig *pgxpool.Config, replicaConfigs []*pgxpool.Config) (*Cluster, error) {
+	primary, err := pgxpool.ConnectConfig(ctx, primaryConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cluster{
+		primary:    primary,
+		selection:  cfg.ReplicaSelection,
+		stickiness: cfg.ReplicaStickiness,
+		done:       make(chan struct{}),
+	}
+	for _, replicaConfig := range replicaConfigs {
+		pool, err := pgxpool.ConnectConfig(ctx, replicaConfig)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		c.replicas = append(c.replicas, &replica{pool: pool})
+	}
+
+	if len(c.replicas) > 0 {
+		c.checkHealth(ctx)
+		period := cfg.HealthCheckPeriod
+		if period <= 0 {
+			period = 30 * time.Second
+		}
+		go c.healthCheck(period)
+	}
+	return c, nil
+}
+
+// healthCheck pings the replicas every period until the cluster is closed.
+func (c *Cluster) healthCheck(period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), period)
+			c.checkHealth(ctx)
+			cancel()
+		}
+	}
+}
+
+func (c *Cluster) checkHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, r := range c.replicas {
+		wg.Add(1)
+		go func(r *replica) {
+			defer wg.Done()
+			r.setHealthy(r.pool.Ping(ctx) == nil)
+		}(r)
+	}
+	wg.Wait()
+}
+
+// Primary returns the primary pool.
+func (c *Cluster) Primary() *pgxpool.Pool {
+	return c.primary
+}
+
+// Reader returns a pool to read from: a healthy replica, or the primary if there are none, ctx was returned by
+// WithPrimary, or there was a write in the ctx session within the stickiness period. If ctx carries a transaction,
+// the transaction is returned.
+func (c *Cluster) Reader(ctx context.Context) Querier {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	if c.usePrimary(ctx) {
+		return c.primary
+	}
+	if r := c.replica(); r != nil {
+		return r.pool
+	}
+	return c.primary
+}
+
+// Writer returns the primary, or the transaction carried by ctx, and records a write in the ctx session.
+func (c *Cluster) Writer(ctx context.Context) Querier {
+	c.recordWrite(ctx)
+	return FromContext(ctx, c.primary)
+}
+
+// replica returns a healthy replica, or nil if there aren't any.
+func (c *Cluster) replica() *replica {
+	n := len(c.replicas)
+	if n == 0 {
+		return nil
+	}
+
+	if c.selection == config.LeastConnections {
+		var least *replica
+		var leastConns int32
+		for _, r := range c.replicas {
+			if !r.isHealthy() {
+				continue
+			}
+			if conns := r.pool.Stat().AcquiredConns(); least == nil || conns < leastConns {
+				least, leastConns = r, conns
+			}
+		}
+		return least
+	}
+
+	start := atomic.AddUint64(&c.next, 1)
+	for i := 0; i < n; i++ {
+		if r := c.replicas[(start+uint64(i))%uint64(n)]; r.isHealthy() {
+			return r
+		}
+	}
+	return nil
+}
+
+// Exec runs sql on the primary.
+func (c *Cluster) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	return c.Writer(ctx).Exec(ctx, sql, arguments...)
+}
+
+// Query runs sql on the primary, as it may write. Use Reader(ctx).Query to read from a replica.
+func (c *Cluster) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return c.Writer(ctx).Query(ctx, sql, args...)
+}
+
+// QueryRow runs sql on the primary, as it may write. Use Reader(ctx).QueryRow to read from a replica.
+func (c *Cluster) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return c.Writer(ctx).QueryRow(ctx, sql, args...)
+}
+
+// BeginTx begins a read only transaction on a replica, chosen as for Reader, and other transactions on the primary.
+// Cluster can be passed to WithTx.
+func (c *Cluster) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	if txOptions.AccessMode == pgx.ReadOnly && !c.usePrimary(ctx) {
+		if r := c.replica(); r != nil {
+			return r.pool.BeginTx(ctx, txOptions)
+		}
+	}
+	c.recordWrite(ctx)
+	return c.primary.BeginTx(ctx, txOptions)
+}
+
+// Close closes the primary and replica pools.
+func (c *Cluster) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.primary.Close()
+		for _, r := range c.replicas {
+			r.pool.Close()
+		}
+	})
+}
+
+type primaryKey struct{}
+
+// WithPrimary returns a copy of ctx whose reads go to the primary.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+type sessionKey struct{}
+
+// session records the last write of a request, so its subsequent reads see it.
+type session struct {
+	lastWrite int64
+}
+
+// NewSession returns a copy of ctx which gives read-your-writes consistency: after a write through a Cluster with the
+// returned context, reads with it go to the primary for the config's ReplicaStickiness. Typically called once per
+// request by middleware.
+func NewSession(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sessionKey{}, &session{})
+}
+
+func (c *Cluster) recordWrite(ctx context.Context) {
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		atomic.StoreInt64(&s.lastWrite, time.Now().UnixNano())
+	}
+}
+
+func (c *Cluster) usePrimary(ctx context.Context) bool {
+	if primary, _ := ctx.Value(primaryKey{}).(bool); primary {
+		return true
+	}
+	s, ok := ctx.Value(sessionKey{}).(*session)
+	if !ok {
+		return false
+	}
+	lastWrite := atomic.LoadInt64(&s.lastWrite)
+	return lastWrite != 0 && time.Since(time.Unix(0, lastWrite)) < c.stickiness
+}
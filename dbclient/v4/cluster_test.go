@@ -0,0 +1,101 @@
+package dbclient
+
+import (
+	"context"
+	"time"
+
+	"github.com/HomesNZ/go-common/dbclient/v4/config"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// lazyPool returns a pool which doesn't connect until it's used, to an address which refuses connections.
+func lazyPool() *pgxpool.Pool {
+	cfg, err := pgxpool.ParseConfig("host=127.0.0.1 port=1 user=postgres dbname=test connect_timeout=1")
+	Expect(err).NotTo(HaveOccurred())
+	cfg.LazyConnect = true
+	pool, err := pgxpool.ConnectConfig(context.Background(), cfg)
+	Expect(err).NotTo(HaveOccurred())
+	return pool
+}
+
+var _ = Describe("Cluster", func() {
+	var (
+		cluster  *Cluster
+		replicas []*replica
+		ctx      = context.Background()
+	)
+
+	BeforeEach(func() {
+		replicas = []*replica{{pool: lazyPool(), healthy: 1}, {pool: lazyPool(), healthy: 1}}
+		cluster = &Cluster{
+			primary:    lazyPool(),
+			replicas:   replicas,
+			selection:  config.RoundRobin,
+			stickiness: time.Minute,
+			done:       make(chan struct{}),
+		}
+	})
+
+	AfterEach(func() {
+		cluster.Close()
+	})
+
+	Describe("#Reader", func() {
+		It("chooses replicas in turn", func() {
+			first := cluster.Reader(ctx)
+			second := cluster.Reader(ctx)
+			Expect([]Querier{first, second}).To(ConsistOf(replicas[0].pool, replicas[1].pool))
+			Expect(cluster.Reader(ctx)).To(Equal(first))
+		})
+
+		It("skips unhealthy replicas", func() {
+			replicas[0].setHealthy(false)
+			Expect(cluster.Reader(ctx)).To(Equal(replicas[1].pool))
+			Expect(cluster.Reader(ctx)).To(Equal(replicas[1].pool))
+		})
+
+		It("chooses the replica with the fewest connections", func() {
+			cluster.selection = config.LeastConnections
+			replicas[0].setHealthy(false)
+			Expect(cluster.Reader(ctx)).To(Equal(replicas[1].pool))
+		})
+
+		It("falls back to the primary when no replicas are healthy", func() {
+			cluster.checkHealth(ctx)
+			Expect(replicas[0].isHealthy()).To(BeFalse())
+			Expect(cluster.Reader(ctx)).To(Equal(cluster.Primary()))
+		})
+
+		It("reads from the primary with WithPrimary", func() {
+			Expect(cluster.Reader(WithPrimary(ctx))).To(Equal(cluster.Primary()))
+		})
+
+		It("reads from the primary after a write in the session", func() {
+			ctx := NewSession(ctx)
+			Expect(cluster.Reader(ctx)).NotTo(Equal(cluster.Primary()))
+
+			Expect(cluster.Writer(ctx)).To(Equal(cluster.Primary()))
+			Expect(cluster.Reader(ctx)).To(Equal(cluster.Primary()))
+
+			cluster.stickiness = 0
+			Expect(cluster.Reader(ctx)).NotTo(Equal(cluster.Primary()))
+		})
+
+		It("returns the transaction in the context", func() {
+			tx := &fakeTx{}
+			Expect(cluster.Reader(NewContext(ctx, tx))).To(Equal(tx))
+		})
+	})
+
+	Describe("#BeginTx", func() {
+		It("doesn't record a write for read only transactions", func() {
+			ctx := NewSession(ctx)
+			// The replicas refuse connections, so only the routing is tested.
+			cluster.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+			Expect(cluster.usePrimary(ctx)).To(BeFalse())
+		})
+	})
+})
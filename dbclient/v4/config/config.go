@@ -1,43 +1,88 @@
 package config
 
 import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/HomesNZ/go-common/env"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
 )
 
 type Config struct {
-	ServiceName       string
-	Host              string
-	User              string
-	Name              string
-	Password          string
-	MaxConns          int // max number of connections in the pool. will define
-	Port              int
-	SearchPath        string
-	HealthCheckPeriod time.Duration // seconds - how often to check health of the connection
-	MaxConnIdleTime   time.Duration // minutes - how long connection can be idle before it'll be closed
-	PingBeforeUse     bool          // it'll be used to check connection before use, if true and connection is not alive, it'll be reconnected
+	DSN                   string // full keyword/value or URL connection string, overriding Host, Port, User, Name, Password, SearchPath and the SSL config
+	ServiceName           string
+	Host                  string
+	User                  string
+	Name                  string
+	Password              string
+	MaxConns              int // max number of connections in the pool. will define
+	Port                  int
+	SearchPath            string
+	HealthCheckPeriod     time.Duration // seconds - how often to check health of the connection
+	MaxConnIdleTime       time.Duration // minutes - how long connection can be idle before it'll be closed
+	PingBeforeUse         bool          // it'll be used to check connection before use, if true and connection is not alive, it'll be reconnected
+	ReplicaHosts          []string      // host or host:port of each read replica, the Port is used if it's not set
+	ReplicaSelection      string        // how a replica is chosen for each read, round-robin or least-connections
+	ReplicaStickiness     time.Duration // seconds - how long reads go to the primary after a write in the same session
+	Tracing               bool          // creates an OpenTelemetry span for each query
+	SlowQueryThreshold    time.Duration // milliseconds - queries taking longer are logged, 0 disables slow query logging
+	SSLMode               string        // disable, allow, prefer, require, verify-ca or verify-full, libpq defaults to prefer
+	SSLRootCert           string        // path of the CA bundle used to verify the server certificate with verify-ca or verify-full
+	StatementTimeout      time.Duration // milliseconds - queries running longer are cancelled by the server, 0 disables the timeout
+	LockTimeout           time.Duration // milliseconds - how long a query waits for a lock before it is cancelled, 0 disables the timeout
+	MinConns              int           // min number of connections kept open in the pool
+	MaxConnLifetime       time.Duration // minutes - how long a connection is used before it'll be closed
+	DisableSimpleProtocol bool          // prepares statements instead of sending queries with the simple protocol, which isn't compatible with PgBouncer
+	IAMAuth               bool          // authenticates with an RDS IAM token generated for each new connection, instead of the Password
+	Region                string        // AWS region of the RDS instance, used for IAM authentication
 }
 
+// SSL modes supported by SSLMode.
+var sslModes = []interface{}{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+const (
+	// RoundRobin chooses each replica in turn.
+	RoundRobin = "round-robin"
+	// LeastConnections chooses the replica with the fewest connections in use.
+	LeastConnections = "least-connections"
+)
+
 func NewFromEnv() *Config {
 	healthCheckPeriod := time.Duration(env.GetInt("DB_HEALTH_CHECK_PERIOD", 30)) * time.Second
 	maxConnIdleTime := time.Duration(env.GetInt("DB_MAX_CONN_IDLE_TIME", 5)) * time.Minute
 	pingBeforeUse := env.GetBool("DB_PING_BEFORE_USE", true)
 
 	cfg := &Config{
-		ServiceName:       env.GetString("SERVICE_NAME", ""),
-		Host:              env.GetString("DB_HOST", "localhost"),
-		User:              env.GetString("DB_USER", "postgres"),
-		Name:              env.GetString("DB_NAME", ""),
-		Password:          env.GetString("DB_PASSWORD", ""),
-		MaxConns:          env.GetInt("DB_MAX_CONNECT", 1),
-		Port:              env.GetInt("DB_PORT", 5432),
-		SearchPath:        env.GetString("DB_SEARCH_PATH", ""),
-		HealthCheckPeriod: healthCheckPeriod,
-		MaxConnIdleTime:   maxConnIdleTime,
-		PingBeforeUse:     pingBeforeUse,
+		DSN:                   env.GetString("DB_DSN", ""),
+		ServiceName:           env.GetString("SERVICE_NAME", ""),
+		Host:                  env.GetString("DB_HOST", "localhost"),
+		User:                  env.GetString("DB_USER", "postgres"),
+		Name:                  env.GetString("DB_NAME", ""),
+		Password:              env.GetString("DB_PASSWORD", ""),
+		MaxConns:              env.GetInt("DB_MAX_CONNECT", 1),
+		Port:                  env.GetInt("DB_PORT", 5432),
+		SearchPath:            env.GetString("DB_SEARCH_PATH", ""),
+		HealthCheckPeriod:     healthCheckPeriod,
+		MaxConnIdleTime:       maxConnIdleTime,
+		PingBeforeUse:         pingBeforeUse,
+		ReplicaHosts:          splitHosts(env.GetString("DB_REPLICA_HOSTS", "")),
+		ReplicaSelection:      env.GetString("DB_REPLICA_SELECTION", RoundRobin),
+		ReplicaStickiness:     time.Duration(env.GetInt("DB_REPLICA_STICKINESS", 5)) * time.Second,
+		Tracing:               env.GetBool("DB_TRACING", false),
+		SlowQueryThreshold:    time.Duration(env.GetInt("DB_SLOW_QUERY_THRESHOLD", 0)) * time.Millisecond,
+		SSLMode:               env.GetString("DB_SSL_MODE", ""),
+		SSLRootCert:           env.GetString("DB_SSL_ROOT_CERT", ""),
+		StatementTimeout:      time.Duration(env.GetInt("DB_STATEMENT_TIMEOUT", 0)) * time.Millisecond,
+		LockTimeout:           time.Duration(env.GetInt("DB_LOCK_TIMEOUT", 0)) * time.Millisecond,
+		MinConns:              env.GetInt("DB_MIN_CONNS", 0),
+		MaxConnLifetime:       time.Duration(env.GetInt("DB_MAX_CONN_LIFETIME", 60)) * time.Minute,
+		DisableSimpleProtocol: env.GetBool("DB_DISABLE_SIMPLE_PROTOCOL", false),
+		IAMAuth:               env.GetBool("DB_IAM_AUTH", false),
+		Region:                env.GetString("AWS_REGION", ""),
 	}
 
 	return cfg
@@ -46,6 +91,71 @@ func NewFromEnv() *Config {
 func (c *Config) Validate() error {
 	return validation.ValidateStruct(c,
 		validation.Field(&c.ServiceName, validation.Required, validation.Required.Error("SERVICE_NAME was not specified in env")),
-		validation.Field(&c.Name, validation.Required, validation.Required.Error("DB_NAME was not specified in env")),
+		validation.Field(&c.Name, validation.When(c.DSN == "", validation.Required.Error("DB_NAME was not specified in env"))),
+		validation.Field(&c.ReplicaSelection, validation.In(RoundRobin, LeastConnections).Error("DB_REPLICA_SELECTION must be round-robin or least-connections")),
+		validation.Field(&c.SSLMode, validation.In(sslModes...).Error("DB_SSL_MODE must be disable, allow, prefer, require, verify-ca or verify-full")),
+		validation.Field(&c.MinConns, validation.When(c.MaxConns > 0, validation.Max(c.MaxConns).Error("DB_MIN_CONNS must not be more than DB_MAX_CONNECT"))),
+		validation.Field(&c.Region, validation.When(c.IAMAuth, validation.Required.Error("AWS_REGION is required for DB_IAM_AUTH"))),
 	)
 }
+
+// Replica returns a copy of the config for the replica at host, which may include a port. If the config has a DSN,
+// the DSN's host is replaced, and its port too if host includes one.
+func (c Config) Replica(host string) (*Config, error) {
+	replica := c
+	replica.Host = host
+	hasPort := false
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		port, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid port in replica host %s", host)
+		}
+		replica.Host = h
+		replica.Port = port
+		hasPort = true
+	}
+	if c.DSN != "" {
+		dsn, err := replicaDSN(c.DSN, replica.Host, replica.Port, hasPort)
+		if err != nil {
+			return nil, errors.Wrapf(err, "replica host %s", host)
+		}
+		replica.DSN = dsn
+	}
+	replica.ReplicaHosts = nil
+	return &replica, nil
+}
+
+// replicaDSN returns dsn connecting to host instead, and port if hasPort is true.
+func replicaDSN(dsn, host string, port int, hasPort bool) (string, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", errors.Wrap(err, "invalid DSN")
+		}
+		if hasPort {
+			u.Host = net.JoinHostPort(host, strconv.Itoa(port))
+		} else if p := u.Port(); p != "" && !strings.Contains(u.Host, ",") {
+			u.Host = net.JoinHostPort(host, p)
+		} else {
+			u.Host = host
+		}
+		return u.String(), nil
+	}
+
+	// Later keywords override earlier ones in a keyword/value connection string.
+	dsn += " host=" + host
+	if hasPort {
+		dsn += " port=" + strconv.Itoa(port)
+	}
+	return dsn, nil
+}
+
+func splitHosts(hosts string) []string {
+	var split []string
+	for _, host := range strings.Split(hosts, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			split = append(split, host)
+		}
+	}
+	return split
+}
@@ -3,66 +3,144 @@ package dbclient
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsConfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/rds/auth"
 	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
 
 	"github.com/HomesNZ/go-common/dbclient/v4/config"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/pkg/errors"
 )
 
+// redactedPassword replaces the password in logged connection strings.
+const redactedPassword = "****"
+
+// dsnPassword matches the password of a keyword/value connection string.
+var dsnPassword = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
 func New(ctx context.Context, cfg *config.Config) (*pgxpool.Pool, error) {
 	if err := cfg.Validate(); err != nil {
 		return nil, errors.Wrap(err, "DB")
 	}
-	config, err := connectionConfig(cfg)
+	config, err := connectionConfig(ctx, cfg)
 	if err != nil {
 		return nil, errors.Wrap(err, "DB")
 	}
+	logrus.WithField("connection", SafeConnectionString(cfg)).Info("DB connecting")
 	return pgxpool.ConnectConfig(ctx, config)
 }
 
 func NewFromEnv(ctx context.Context) (*pgxpool.Pool, error) {
-	cfg := config.NewFromEnv()
-	if err := cfg.Validate(); err != nil {
-		return nil, errors.Wrap(err, "DB")
+	return New(ctx, config.NewFromEnv())
+}
+
+// ConnectionString returns cfg.DSN if it's set, otherwise a keyword/value connection string built from cfg.
+func ConnectionString(cfg *config.Config) string {
+	if cfg.DSN != "" {
+		return cfg.DSN
 	}
-	config, err := connectionConfig(cfg)
-	if err != nil {
-		return nil, errors.Wrap(err, "DB")
+
+	params := [][2]string{
+		{"host", cfg.Host},
+		{"user", cfg.User},
+		{"dbname", cfg.Name},
+		{"password", cfg.Password},
+		{"port", strconv.Itoa(int(uint16(cfg.Port)))},
+		{"pool_max_conns", strconv.Itoa(cfg.MaxConns)},
+		{"search_path", cfg.SearchPath},
+		{"application_name", cfg.ServiceName},
+		{"sslmode", cfg.SSLMode},
+		{"sslrootcert", cfg.SSLRootCert},
 	}
-	return pgxpool.ConnectConfig(ctx, config)
+	var connStr []string
+	for _, p := range params {
+		if p[1] != "" {
+			connStr = append(connStr, fmt.Sprintf("%s=%s", p[0], quoteConnValue(p[1])))
+		}
+	}
+	return strings.Join(connStr, " ")
 }
 
-// connectionConfig returns the database connection config and error
-func connectionConfig(cfg *config.Config) (*pgxpool.Config, error) {
-	connStr := fmt.Sprintf("host=%s user=%s dbname=%s password=%s port=%d pool_max_conns=%d",
-		cfg.Host,
-		cfg.User,
-		cfg.Name,
-		cfg.Password,
-		uint16(cfg.Port),
-		cfg.MaxConns,
-	)
-
-	if len(cfg.SearchPath) > 0 || len(cfg.ServiceName) > 0 {
-		if len(cfg.SearchPath) > 0 {
-			connStr = fmt.Sprintf("%s search_path=%s", connStr, cfg.SearchPath)
+// SafeConnectionString is the connection string with the password replaced with `****` so it can be logged without
+// revealing the password.
+func SafeConnectionString(cfg *config.Config) string {
+	if cfg.DSN == "" {
+		redacted := *cfg
+		if redacted.Password != "" {
+			redacted.Password = redactedPassword
 		}
-		if len(cfg.ServiceName) > 0 {
-			connStr = fmt.Sprintf("%s application_name=%s", connStr, cfg.ServiceName)
+		return ConnectionString(&redacted)
+	}
+
+	if u, err := url.Parse(cfg.DSN); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redactedPassword)
+		}
+		query := u.Query()
+		if query.Get("password") != "" {
+			query.Set("password", redactedPassword)
+			u.RawQuery = query.Encode()
 		}
+		return u.String()
 	}
+	return dsnPassword.ReplaceAllString(cfg.DSN, "${1}"+redactedPassword)
+}
 
-	config, err := pgxpool.ParseConfig(connStr)
+// quoteConnValue quotes a keyword/value connection string value, so that values with spaces or quotes are parsed
+// correctly.
+func quoteConnValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
+// connectionConfig returns the database connection config and error
+func connectionConfig(ctx context.Context, cfg *config.Config) (*pgxpool.Config, error) {
+	config, err := pgxpool.ParseConfig(ConnectionString(cfg))
 	if err != nil {
 		return nil, errors.Wrap(err, "db failed to parse config")
 	}
-	config.ConnConfig.PreferSimpleProtocol = true
+	config.ConnConfig.PreferSimpleProtocol = !cfg.DisableSimpleProtocol
 
+	if cfg.DSN != "" && cfg.MaxConns > 0 {
+		config.MaxConns = int32(cfg.MaxConns)
+	}
+	if cfg.MinConns > 0 {
+		config.MinConns = int32(cfg.MinConns)
+	}
+	if cfg.MaxConnLifetime > 0 {
+		config.MaxConnLifetime = cfg.MaxConnLifetime
+	}
 	config.HealthCheckPeriod = cfg.HealthCheckPeriod
 	config.MaxConnIdleTime = cfg.MaxConnIdleTime
 
+	if cfg.StatementTimeout > 0 {
+		config.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
+	if cfg.LockTimeout > 0 {
+		config.ConnConfig.RuntimeParams["lock_timeout"] = strconv.FormatInt(cfg.LockTimeout.Milliseconds(), 10)
+	}
+
+	if cfg.IAMAuth {
+		awsCfg, err := awsConfig.LoadDefaultConfig(ctx, awsConfig.WithRegion(cfg.Region))
+		if err != nil {
+			return nil, errors.Wrap(err, "db failed to load AWS config")
+		}
+		config.BeforeConnect = iamAuth(cfg.Region, awsCfg.Credentials)
+	}
+
+	if cfg.Tracing || cfg.SlowQueryThreshold > 0 {
+		config.ConnConfig.Logger = NewQueryLogger(cfg.Tracing, cfg.SlowQueryThreshold)
+		config.ConnConfig.LogLevel = pgx.LogLevelInfo
+	}
+
 	if cfg.PingBeforeUse {
 		// BeforeAcquire is called before a connection is acquired from the pool.
 		// If it returns false, the connection is discarded and a new connection is acquired.
@@ -73,3 +151,17 @@ func connectionConfig(cfg *config.Config) (*pgxpool.Config, error) {
 
 	return config, nil
 }
+
+// iamAuth returns a BeforeConnect func which sets the password of each new connection to an RDS IAM token. Tokens
+// are valid for 15 minutes, so one is generated for each connection rather than once for the pool.
+func iamAuth(region string, credentials aws.CredentialsProvider) func(context.Context, *pgx.ConnConfig) error {
+	return func(ctx context.Context, cc *pgx.ConnConfig) error {
+		endpoint := fmt.Sprintf("%s:%d", cc.Host, cc.Port)
+		token, err := auth.BuildAuthToken(ctx, endpoint, region, cc.User, credentials)
+		if err != nil {
+			return errors.Wrap(err, "db failed to build IAM auth token")
+		}
+		cc.Password = token
+		return nil
+	}
+}
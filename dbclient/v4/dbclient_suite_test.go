@@ -0,0 +1,13 @@
+package dbclient
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestDBClient(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "DBClient")
+}
@@ -0,0 +1,221 @@
+package dbclient
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/label"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/HomesNZ/go-common/dbclient/v4"
+
+var (
+	stringLiterals = regexp.MustCompile(`'(?:[^']|'')*'`)
+	// numericLiterals matches numbers which aren't placeholders or part of an identifier.
+	numericLiterals = regexp.MustCompile(`(^|[^\w$.])-?\d+(?:\.\d+)?\b`)
+	whitespace      = regexp.MustCompile(`\s+`)
+)
+
+// QueryLogger is a pgx.Logger which instruments queries. pgx logs each query once it has finished, with its duration,
+// so spans are created retrospectively with the start and end times of the query.
+type QueryLogger struct {
+	tracer             trace.Tracer
+	slowQueryThreshold time.Duration
+	log                logrus.FieldLogger
+}
+
+// NewQueryLogger returns a QueryLogger which creates an OpenTelemetry span for each query if tracing is true, and
+// logs queries taking longer than slowQueryThreshold if it's greater than zero. connectionConfig sets it on the pool
+// from the DB_TRACING and DB_SLOW_QUERY_THRESHOLD config.
+func NewQueryLogger(tracing bool, slowQueryThreshold time.Duration) *QueryLogger {
+	l := &QueryLogger{
+		slowQueryThreshold: slowQueryThreshold,
+		log:                logrus.WithField("package", "dbclient"),
+	}
+	if tracing {
+		l.tracer = otel.Tracer(instrumentationName)
+	}
+	return l
+}
+
+// Log implements pgx.Logger.
+func (l *QueryLogger) Log(ctx context.Context, level pgx.LogLevel, msg string, data map[string]interface{}) {
+	duration, ok := data["time"].(time.Duration)
+	if !ok {
+		// Only queries are timed. Connection messages aren't instrumented.
+		return
+	}
+	sql, _ := data["sql"].(string)
+	statement := SanitizeSQL(sql)
+	err, _ := data["err"].(error)
+
+	if l.tracer != nil {
+		l.span(ctx, msg, sql, statement, duration, data, err)
+	}
+
+	if l.slowQueryThreshold > 0 && duration >= l.slowQueryThreshold {
+		fields := logrus.Fields{
+			"operation": msg,
+			"sql":       statement,
+			"duration":  duration.String(),
+		}
+		if args, ok := data["args"].([]interface{}); ok {
+			fields["args"] = redactArgs(args)
+		}
+		if table, ok := data["tableName"]; ok {
+			fields["table"] = fmt.Sprint(table)
+		}
+		l.log.WithFields(fields).Warn("slow query")
+	}
+}
+
+func (l *QueryLogger) span(ctx context.Context, msg, sql, statement string, duration time.Duration, data map[string]interface{}, err error) {
+	end := time.Now()
+	attributes := []label.KeyValue{
+		semconv.DBSystemPostgres,
+		semconv.DBOperationKey.String(msg),
+	}
+	if statement != "" {
+		attributes = append(attributes, semconv.DBStatementKey.String(statement))
+	}
+	if sql != "" && !strings.ContainsAny(strings.TrimSpace(sql), " \t\n") {
+		// A single word is the name of a prepared statement.
+		attributes = append(attributes, label.String("db.statement_name", sql))
+	}
+	if table, ok := data["tableName"]; ok {
+		attributes = append(attributes, label.String("db.sql.table", fmt.Sprint(table)))
+	}
+	if rows, ok := rowsAffected(data); ok {
+		attributes = append(attributes, label.Int64("db.rows_affected", rows))
+	}
+
+	_, span := l.tracer.Start(ctx, spanName(msg, sql),
+		trace.WithTimestamp(end.Add(-duration)),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributes...),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End(trace.WithTimestamp(end))
+}
+
+// spanName returns the SQL command, e.g. SELECT, or the pgx operation if it can't be determined.
+func spanName(msg, sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) > 1 {
+		return strings.ToUpper(fields[0])
+	}
+	return msg
+}
+
+func rowsAffected(data map[string]interface{}) (int64, bool) {
+	switch v := data["rowCount"].(type) {
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	}
+	if tag, ok := data["commandTag"].(pgconn.CommandTag); ok {
+		return tag.RowsAffected(), true
+	}
+	return 0, false
+}
+
+// SanitizeSQL replaces string and numeric literals in sql with ?, and collapses whitespace, so that it can be
+// recorded without leaking data which was inlined rather than passed as an argument.
+func SanitizeSQL(sql string) string {
+	sanitized := stringLiterals.ReplaceAllString(sql, "?")
+	sanitized = numericLiterals.ReplaceAllString(sanitized, "${1}?")
+	return strings.TrimSpace(whitespace.ReplaceAllString(sanitized, " "))
+}
+
+// redactArgs returns the type of each argument in place of its value.
+func redactArgs(args []interface{}) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == nil {
+			redacted[i] = "<nil>"
+			continue
+		}
+		redacted[i] = fmt.Sprintf("<%T>", arg)
+	}
+	return redacted
+}
+
+// RegisterPoolMetrics records the statistics of pool with the global OpenTelemetry meter provider whenever metrics are
+// collected. name distinguishes pools, e.g. primary and replica, with the db.pool label.
+func RegisterPoolMetrics(pool *pgxpool.Pool, name string) error {
+	meter := otel.Meter(instrumentationName)
+	labels := []label.KeyValue{semconv.DBSystemPostgres, label.String("db.pool", name)}
+
+	var acquired, idle, total, max, acquireCount, emptyAcquireCount metric.Int64ValueObserver
+	var acquireDuration metric.Float64ValueObserver
+	batch := meter.NewBatchObserver(func(ctx context.Context, result metric.BatchObserverResult) {
+		stat := pool.Stat()
+		result.Observe(labels,
+			acquired.Observation(int64(stat.AcquiredConns())),
+			idle.Observation(int64(stat.IdleConns())),
+			total.Observation(int64(stat.TotalConns())),
+			max.Observation(int64(stat.MaxConns())),
+			acquireCount.Observation(stat.AcquireCount()),
+			emptyAcquireCount.Observation(stat.EmptyAcquireCount()),
+			acquireDuration.Observation(stat.AcquireDuration().Seconds()),
+		)
+	})
+
+	var err error
+	observers := []struct {
+		observer *metric.Int64ValueObserver
+		name     string
+		desc     string
+	}{
+		{&acquired, "db.pool.acquired_conns", "Connections in use"},
+		{&idle, "db.pool.idle_conns", "Idle connections"},
+		{&total, "db.pool.total_conns", "Open connections"},
+		{&max, "db.pool.max_conns", "Maximum connections"},
+		{&acquireCount, "db.pool.acquire_count", "Connections acquired since the pool was created"},
+		{&emptyAcquireCount, "db.pool.empty_acquire_count", "Acquires which waited for a connection since the pool was created"},
+	}
+	for _, o := range observers {
+		if *o.observer, err = batch.NewInt64ValueObserver(o.name, metric.WithDescription(o.desc)); err != nil {
+			return errors.Wrap(err, o.name)
+		}
+	}
+	acquireDuration, err = batch.NewFloat64ValueObserver("db.pool.acquire_duration",
+		metric.WithDescription("Time spent acquiring connections since the pool was created"),
+		metric.WithUnit("s"),
+	)
+	return errors.Wrap(err, "db.pool.acquire_duration")
+}
+
+// Pinger checks a database connection, e.g. a *pgxpool.Pool or *pgx.Conn.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// HealthCheck returns a check for health.Handler which pings db, failing if it doesn't respond within timeout.
+func HealthCheck(db Pinger, timeout time.Duration) func() error {
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := db.Ping(ctx); err != nil {
+			return errors.Wrap(err, "DB")
+		}
+		return nil
+	}
+}
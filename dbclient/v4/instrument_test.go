@@ -0,0 +1,122 @@
+package dbclient
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/label"
+	"go.opentelemetry.io/otel/oteltest"
+)
+
+type fakePinger struct {
+	err error
+}
+
+func (p fakePinger) Ping(ctx context.Context) error {
+	return p.err
+}
+
+var _ = Describe("Instrumentation", func() {
+	Describe("SanitizeSQL", func() {
+		It("replaces literals", func() {
+			Expect(SanitizeSQL("SELECT *\n  FROM listing_2 WHERE address = 'O''Connell St' AND price > 500000.50 AND agent_id = $1 LIMIT -1")).
+				To(Equal("SELECT * FROM listing_2 WHERE address = ? AND price > ? AND agent_id = $1 LIMIT ?"))
+		})
+	})
+
+	Describe("QueryLogger", func() {
+		var (
+			recorder *oteltest.StandardSpanRecorder
+			hook     *test.Hook
+		)
+
+		BeforeEach(func() {
+			recorder = new(oteltest.StandardSpanRecorder)
+			otel.SetTracerProvider(oteltest.NewTracerProvider(oteltest.WithSpanRecorder(recorder)))
+			hook = test.NewGlobal()
+		})
+
+		AfterEach(func() {
+			logrus.StandardLogger().ReplaceHooks(logrus.LevelHooks{})
+		})
+
+		It("creates a span for each query", func() {
+			l := NewQueryLogger(true, 0)
+			l.Log(context.Background(), pgx.LogLevelInfo, "Exec", map[string]interface{}{
+				"sql":        "UPDATE listing SET price = 1 WHERE listing_id = $1",
+				"args":       []interface{}{1},
+				"time":       50 * time.Millisecond,
+				"commandTag": pgconn.CommandTag("UPDATE 3"),
+			})
+
+			spans := recorder.Completed()
+			Expect(spans).To(HaveLen(1))
+			Expect(spans[0].Name()).To(Equal("UPDATE"))
+			Expect(spans[0].Attributes()).To(HaveKeyWithValue(label.Key("db.statement"), label.StringValue("UPDATE listing SET price = ? WHERE listing_id = $1")))
+			Expect(spans[0].Attributes()).To(HaveKeyWithValue(label.Key("db.rows_affected"), label.Int64Value(3)))
+			end, _ := spans[0].EndTime()
+			Expect(end.Sub(spans[0].StartTime())).To(Equal(50 * time.Millisecond))
+			Expect(hook.Entries).To(BeEmpty())
+		})
+
+		It("records errors", func() {
+			l := NewQueryLogger(true, 0)
+			l.Log(context.Background(), pgx.LogLevelError, "Query", map[string]interface{}{
+				"sql":  "get_listing",
+				"err":  errors.New("failed"),
+				"time": time.Millisecond,
+			})
+
+			spans := recorder.Completed()
+			Expect(spans).To(HaveLen(1))
+			Expect(spans[0].StatusCode()).To(Equal(codes.Error))
+			Expect(spans[0].Attributes()).To(HaveKeyWithValue(label.Key("db.statement_name"), label.StringValue("get_listing")))
+		})
+
+		It("logs slow queries with the args redacted", func() {
+			l := NewQueryLogger(false, 100*time.Millisecond)
+			l.Log(context.Background(), pgx.LogLevelInfo, "Query", map[string]interface{}{
+				"sql":  "SELECT * FROM agent WHERE email = $1",
+				"args": []interface{}{"someone@example.com"},
+				"time": time.Second,
+			})
+			l.Log(context.Background(), pgx.LogLevelInfo, "Query", map[string]interface{}{
+				"sql":  "SELECT 1",
+				"time": time.Millisecond,
+			})
+
+			Expect(recorder.Completed()).To(BeEmpty())
+			Expect(hook.Entries).To(HaveLen(1))
+			Expect(hook.LastEntry().Data).To(HaveKeyWithValue("args", []string{"<string>"}))
+		})
+
+		It("ignores connection messages", func() {
+			l := NewQueryLogger(true, time.Nanosecond)
+			l.Log(context.Background(), pgx.LogLevelInfo, "Dialing PostgreSQL server", map[string]interface{}{"host": "localhost"})
+			Expect(recorder.Completed()).To(BeEmpty())
+			Expect(hook.Entries).To(BeEmpty())
+		})
+	})
+
+	Describe("HealthCheck", func() {
+		It("returns the ping error", func() {
+			Expect(HealthCheck(fakePinger{}, time.Second)()).To(Succeed())
+			Expect(HealthCheck(fakePinger{err: errors.New("refused")}, time.Second)()).To(MatchError("DB: refused"))
+		})
+	})
+
+	Describe("RegisterPoolMetrics", func() {
+		It("registers the observers", func() {
+			Expect(RegisterPoolMetrics(lazyPool(), "primary")).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,207 @@
+package dbclient
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultListenerBuffer is the capacity of the Notifications channel.
+	DefaultListenerBuffer = 100
+	// DefaultListenerMinBackoff is the delay before the first reconnect, which doubles on each failed reconnect.
+	DefaultListenerMinBackoff = 100 * time.Millisecond
+	// DefaultListenerMaxBackoff is the longest delay between reconnects.
+	DefaultListenerMaxBackoff = 30 * time.Second
+)
+
+// Notification is a Postgres notification, or a gap.
+type Notification struct {
+	Channel string
+	Payload string
+	PID     uint32 // - is the process ID of the sending session
+	// Gap is true for a notification sent after the listener reconnects, without a Channel or Payload. Notifications
+	// sent while it was disconnected are lost, so callers should resync any state they derive from notifications.
+	Gap bool
+}
+
+// notificationConn is the part of *pgx.Conn a Listener uses.
+type notificationConn interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	WaitForNotification(ctx context.Context) (*pgconn.Notification, error)
+	Close(ctx context.Context) error
+}
+
+// Listener LISTENs to channels on a dedicated connection and delivers their notifications.
+//
+//	listener, err := dbclient.NewListener(pool, []string{"listing_updated"})
+//	if err != nil {
+//		return err
+//	}
+//	go listener.Listen(ctx)
+//	for n := range listener.Notifications() {
+//		if n.Gap {
+//			resync()
+//			continue
+//		}
+//		handle(n.Payload)
+//	}
+type Listener struct {
+	connect       func(ctx context.Context) (notificationConn, error)
+	channels      []string
+	notifications chan Notification
+	bufferSize    int
+	minBackoff    time.Duration
+	maxBackoff    time.Duration
+	log           logrus.FieldLogger
+}
+
+// ListenerOption configures a Listener.
+type ListenerOption func(*Listener)
+
+// WithListenerBuffer sets the capacity of the Notifications channel. Listen blocks while it is full, so that
+// notifications aren't dropped. Defaults to DefaultListenerBuffer.
+func WithListenerBuffer(size int) ListenerOption {
+	return func(l *Listener) {
+		l.bufferSize = size
+	}
+}
+
+// WithReconnectBackoff sets the delay before the first reconnect and the longest delay between reconnects. min must be
+// positive and max at least min.
+func WithReconnectBackoff(min, max time.Duration) ListenerOption {
+	return func(l *Listener) {
+		l.minBackoff = min
+		l.maxBackoff = max
+	}
+}
+
+// NewListener returns a Listener for channels, which takes a connection out of pool while it is listening. It returns an
+// error if the options are invalid.
+func NewListener(pool *pgxpool.Pool, channels []string, options ...ListenerOption) (*Listener, error) {
+	return newListener(func(ctx context.Context) (notificationConn, error) {
+		conn, err := pool.Acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		// The connection's session is LISTENing, so it mustn't be returned to the pool for other queries.
+		return conn.Hijack(), nil
+	}, channels, options...)
+}
+
+func newListener(connect func(ctx context.Context) (notificationConn, error), channels []string, options ...ListenerOption) (*Listener, error) {
+	l := &Listener{
+		connect:    connect,
+		channels:   channels,
+		bufferSize: DefaultListenerBuffer,
+		minBackoff: DefaultListenerMinBackoff,
+		maxBackoff: DefaultListenerMaxBackoff,
+		log:        logrus.WithField("package", "dbclient"),
+	}
+	for _, opt := range options {
+		opt(l)
+	}
+	if l.bufferSize < 0 {
+		return nil, errors.Errorf("listener buffer size %d is negative", l.bufferSize)
+	}
+	if l.minBackoff <= 0 {
+		return nil, errors.Errorf("listener min backoff %s must be positive", l.minBackoff)
+	}
+	if l.maxBackoff < l.minBackoff {
+		return nil, errors.Errorf("listener max backoff %s is less than the min backoff %s", l.maxBackoff, l.minBackoff)
+	}
+	l.notifications = make(chan Notification, l.bufferSize)
+	return l, nil
+}
+
+// Notifications returns the channel notifications are delivered on. It is closed when Listen returns.
+func (l *Listener) Notifications() <-chan Notification {
+	return l.notifications
+}
+
+// Listen listens until ctx is done, reconnecting with backoff when the connection is lost, and returns ctx.Err().
+// A Listener can only Listen once.
+func (l *Listener) Listen(ctx context.Context) error {
+	defer close(l.notifications)
+
+	backoff := l.minBackoff
+	connected := false
+	for {
+		err := l.listen(ctx, connected, func() {
+			connected = true
+			backoff = l.minBackoff
+		})
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		l.log.WithError(err).WithField("channels", strings.Join(l.channels, ",")).Warn("DB listener disconnected")
+
+		// Full jitter stops listeners reconnecting in lockstep after a failover.
+		delay := time.Duration(rand.Int63n(int64(backoff)) + 1)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		if backoff *= 2; backoff > l.maxBackoff {
+			backoff = l.maxBackoff
+		}
+	}
+}
+
+// listen connects, LISTENs and delivers notifications until the connection fails. If reconnected is true, a gap is
+// delivered once listening. listening is called once the channels are listened to.
+func (l *Listener) listen(ctx context.Context, reconnected bool, listening func()) error {
+	conn, err := l.connect(ctx)
+	if err != nil {
+		return errors.Wrap(err, "connect")
+	}
+	defer conn.Close(context.Background())
+
+	for _, channel := range l.channels {
+		if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+			return errors.Wrapf(err, "listen %s", channel)
+		}
+	}
+	listening()
+
+	if reconnected {
+		if err := l.deliver(ctx, Notification{Gap: true}); err != nil {
+			return err
+		}
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return errors.Wrap(err, "wait for notification")
+		}
+		err = l.deliver(ctx, Notification{Channel: n.Channel, Payload: n.Payload, PID: n.PID})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (l *Listener) deliver(ctx context.Context, n Notification) error {
+	select {
+	case l.notifications <- n:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Notify sends a notification with payload on channel, using the transaction carried by ctx, if any, so that it's
+// only delivered if the transaction commits.
+func Notify(ctx context.Context, db Querier, channel, payload string) error {
+	_, err := FromContext(ctx, db).Exec(ctx, "SELECT pg_notify($1, $2)", channel, payload)
+	return errors.Wrapf(err, "notify %s", channel)
+}
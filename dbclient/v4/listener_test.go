@@ -0,0 +1,102 @@
+package dbclient
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgconn"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeNotificationConn delivers its notifications, then fails as if the connection was lost.
+type fakeNotificationConn struct {
+	sql           []string
+	notifications []*pgconn.Notification
+	closed        bool
+}
+
+func (c *fakeNotificationConn) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	c.sql = append(c.sql, sql)
+	return nil, nil
+}
+
+func (c *fakeNotificationConn) WaitForNotification(ctx context.Context) (*pgconn.Notification, error) {
+	if len(c.notifications) == 0 {
+		return nil, errors.New("connection lost")
+	}
+	n := c.notifications[0]
+	c.notifications = c.notifications[1:]
+	return n, nil
+}
+
+func (c *fakeNotificationConn) Close(ctx context.Context) error {
+	c.closed = true
+	return nil
+}
+
+var _ = Describe("Listener", func() {
+	It("delivers notifications, and a gap after reconnecting", func() {
+		conns := []*fakeNotificationConn{
+			{notifications: []*pgconn.Notification{{Channel: "listing_updated", Payload: "1", PID: 42}}},
+			{notifications: []*pgconn.Notification{{Channel: "agent_updated", Payload: "2"}}},
+		}
+		connects := 0
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		l, err := newListener(func(ctx context.Context) (notificationConn, error) {
+			if connects == 1 {
+				connects++
+				return nil, errors.New("connection refused")
+			}
+			if connects == 3 {
+				cancel()
+				return nil, ctx.Err()
+			}
+			conn := conns[connects/2]
+			connects++
+			return conn, nil
+		}, []string{"listing_updated", "agent_updated"}, WithReconnectBackoff(time.Millisecond, time.Millisecond))
+		Expect(err).NotTo(HaveOccurred())
+
+		errs := make(chan error)
+		go func() { errs <- l.Listen(ctx) }()
+
+		var received []Notification
+		for n := range l.Notifications() {
+			received = append(received, n)
+		}
+		Expect(<-errs).To(Equal(context.Canceled))
+		Expect(received).To(Equal([]Notification{
+			{Channel: "listing_updated", Payload: "1", PID: 42},
+			{Gap: true},
+			{Channel: "agent_updated", Payload: "2"},
+		}))
+		Expect(conns[0].sql).To(Equal([]string{`LISTEN "listing_updated"`, `LISTEN "agent_updated"`}))
+		Expect(conns[0].closed).To(BeTrue())
+		Expect(conns[1].closed).To(BeTrue())
+	})
+})
+
+var _ = Describe("Notify", func() {
+	It("calls pg_notify", func() {
+		db := &fakeExecer{}
+		Expect(Notify(context.Background(), db, "listing_updated", "1")).To(Succeed())
+		Expect(db.sql).To(Equal([]string{"SELECT pg_notify($1, $2)"}))
+	})
+
+	It("rejects invalid options", func() {
+		connect := func(ctx context.Context) (notificationConn, error) { return nil, errors.New("not called") }
+		for _, opt := range []ListenerOption{
+			WithListenerBuffer(-1),
+			WithReconnectBackoff(0, time.Second),
+			WithReconnectBackoff(-time.Second, time.Second),
+			WithReconnectBackoff(time.Second, time.Millisecond),
+		} {
+			_, err := newListener(connect, []string{"listing_updated"}, opt)
+			Expect(err).To(HaveOccurred())
+		}
+	})
+})
@@ -0,0 +1,164 @@
+package dbclient
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"sync"
+	"time"
+
+	bulk "github.com/HomesNZ/go-common/dbclient"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+)
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+
+	// columnCache holds the columns of each struct type, by db tag.
+	columnCache sync.Map
+)
+
+// Select runs sql and scans each row into a T. If T is a struct, each column is scanned into the field with the
+// matching `db:"column"` tag, including fields of embedded structs, and a column without a field returns an error.
+// Otherwise, including for types which implement sql.Scanner such as null.String, dirty.String and db.PGArray, the
+// query must return a single column. The query runs on the transaction carried by ctx, if any.
+func Select[T any](ctx context.Context, q Querier, sql string, args ...interface{}) ([]T, error) {
+	rows, err := FromContext(ctx, q).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []T
+	scan, err := newRowScanner[T](rows)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var result T
+		if err := scan(&result); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// Get runs sql and scans the first row into a T as Select does. It returns pgx.ErrNoRows if there are no rows.
+func Get[T any](ctx context.Context, q Querier, sql string, args ...interface{}) (T, error) {
+	var result T
+	rows, err := FromContext(ctx, q).Query(ctx, sql, args...)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+
+	scan, err := newRowScanner[T](rows)
+	if err != nil {
+		return result, err
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return result, err
+		}
+		return result, pgx.ErrNoRows
+	}
+	if err := scan(&result); err != nil {
+		return result, err
+	}
+	return result, rows.Err()
+}
+
+// newRowScanner returns a func which scans the current row of rows into a T.
+func newRowScanner[T any](rows pgx.Rows) (func(*T) error, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	fields := rows.FieldDescriptions()
+
+	if !isStruct(t) {
+		if len(fields) != 1 {
+			return nil, errors.Errorf("dbclient: query returned %d columns, scanning into %s needs 1", len(fields), t)
+		}
+		return func(dest *T) error {
+			return rows.Scan(dest)
+		}, nil
+	}
+
+	columns, err := structColumns(t)
+	if err != nil {
+		return nil, err
+	}
+	indexes := make([][]int, len(fields))
+	for i, f := range fields {
+		index, ok := columns[string(f.Name)]
+		if !ok {
+			return nil, errors.Errorf("dbclient: column %q has no field tagged `db:\"%s\"` in %s", f.Name, f.Name, t)
+		}
+		indexes[i] = index
+	}
+
+	dests := make([]interface{}, len(fields))
+	return func(dest *T) error {
+		v := reflect.ValueOf(dest).Elem()
+		for i, index := range indexes {
+			dests[i] = fieldByIndex(v, index).Addr().Interface()
+		}
+		return rows.Scan(dests...)
+	}, nil
+}
+
+// isStruct reports whether t is a struct which is scanned field by field, rather than as a single column.
+func isStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != timeType && !reflect.PtrTo(t).Implements(scannerType)
+}
+
+// structColumns returns the field index of each column of t. Columns of structs embedded through unexported pointers
+// are left out, as a nil pointer to an unexported struct can't be allocated when scanning.
+func structColumns(t reflect.Type) (map[string][]int, error) {
+	if columns, ok := columnCache.Load(t); ok {
+		return columns.(map[string][]int), nil
+	}
+	columns, err := bulk.Columns(t)
+	if err != nil {
+		return nil, err
+	}
+	indexes := make(map[string][]int, len(columns))
+	for _, c := range columns {
+		if !settable(t, c.Index) {
+			continue
+		}
+		indexes[c.Name] = c.Index
+	}
+	columnCache.Store(t, indexes)
+	return indexes, nil
+}
+
+// settable reports whether the field of t at index can be set when its embedded struct pointers are nil.
+func settable(t reflect.Type, index []int) bool {
+	for _, x := range index[:len(index)-1] {
+		f := t.Field(x)
+		t = f.Type
+		if t.Kind() == reflect.Ptr {
+			if f.PkgPath != "" {
+				return false
+			}
+			t = t.Elem()
+		}
+	}
+	return true
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex, allocating nil embedded struct pointers.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
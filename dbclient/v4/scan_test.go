@@ -0,0 +1,146 @@
+package dbclient
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+
+	"github.com/jackc/pgproto3/v2"
+	"github.com/jackc/pgx/v4"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeRows returns fixed rows, scanning into sql.Scanners or by assignment.
+type fakeRows struct {
+	pgx.Rows
+	columns []string
+	values  [][]interface{}
+	row     int
+}
+
+func (r *fakeRows) FieldDescriptions() []pgproto3.FieldDescription {
+	fields := make([]pgproto3.FieldDescription, len(r.columns))
+	for i, c := range r.columns {
+		fields[i].Name = []byte(c)
+	}
+	return fields
+}
+
+func (r *fakeRows) Next() bool {
+	r.row++
+	return r.row <= len(r.values)
+}
+
+func (r *fakeRows) Scan(dest ...interface{}) error {
+	for i, d := range dest {
+		value := r.values[r.row-1][i]
+		if scanner, ok := d.(sql.Scanner); ok {
+			if err := scanner.Scan(value); err != nil {
+				return err
+			}
+			continue
+		}
+		if value == nil {
+			return fmt.Errorf("can't scan NULL into %T", d)
+		}
+		reflect.ValueOf(d).Elem().Set(reflect.ValueOf(value))
+	}
+	return nil
+}
+
+func (r *fakeRows) Err() error { return nil }
+func (r *fakeRows) Close()     {}
+
+type fakeQuerier struct {
+	Querier
+	rows *fakeRows
+}
+
+func (q *fakeQuerier) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return q.rows, nil
+}
+
+type Audit struct {
+	CreatedBy sql.NullString `db:"created_by"`
+}
+
+type scanListing struct {
+	ID      int64  `db:"listing_id"`
+	Address string `db:"address"`
+	Ignored string `db:"-"`
+	*Audit
+}
+
+type scanAudit struct {
+	UpdatedBy string `db:"updated_by"`
+}
+
+type scanUnexportedListing struct {
+	ID int64 `db:"listing_id"`
+	*scanAudit
+}
+
+var _ = Describe("Scan", func() {
+	ctx := context.Background()
+
+	Describe("Select", func() {
+		It("maps columns to fields by db tag", func() {
+			q := &fakeQuerier{rows: &fakeRows{
+				columns: []string{"address", "listing_id", "created_by"},
+				values:  [][]interface{}{{"1 Queen Street", int64(1), "import"}, {"2 Queen Street", int64(2), nil}},
+			}}
+
+			listings, err := Select[scanListing](ctx, q, "SELECT ...")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(listings).To(HaveLen(2))
+			Expect(listings[0].ID).To(Equal(int64(1)))
+			Expect(listings[0].Address).To(Equal("1 Queen Street"))
+			Expect(listings[0].CreatedBy).To(Equal(sql.NullString{String: "import", Valid: true}))
+			Expect(listings[1].CreatedBy.Valid).To(BeFalse())
+		})
+
+		It("returns an error for an unmapped column", func() {
+			q := &fakeQuerier{rows: &fakeRows{columns: []string{"listing_id", "price"}}}
+
+			_, err := Select[scanListing](ctx, q, "SELECT ...")
+			Expect(err).To(MatchError(ContainSubstring(`column "price" has no field`)))
+		})
+
+		It("doesn't map columns of structs embedded through unexported pointers", func() {
+			q := &fakeQuerier{rows: &fakeRows{columns: []string{"listing_id", "updated_by"}}}
+
+			_, err := Select[scanUnexportedListing](ctx, q, "SELECT ...")
+			Expect(err).To(MatchError(ContainSubstring(`column "updated_by" has no field`)))
+		})
+
+		It("scans single columns", func() {
+			q := &fakeQuerier{rows: &fakeRows{
+				columns: []string{"address"},
+				values:  [][]interface{}{{"1 Queen Street"}, {nil}},
+			}}
+
+			addresses, err := Select[sql.NullString](ctx, q, "SELECT ...")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(addresses).To(Equal([]sql.NullString{{String: "1 Queen Street", Valid: true}, {}}))
+		})
+	})
+
+	Describe("Get", func() {
+		It("returns the first row", func() {
+			q := &fakeQuerier{rows: &fakeRows{columns: []string{"listing_id"}, values: [][]interface{}{{int64(1)}}}}
+
+			listing, err := Get[scanListing](ctx, q, "SELECT ...")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(listing.ID).To(Equal(int64(1)))
+		})
+
+		It("returns pgx.ErrNoRows when there are no rows", func() {
+			q := &fakeQuerier{rows: &fakeRows{columns: []string{"listing_id"}}}
+
+			_, err := Get[scanListing](ctx, q, "SELECT ...")
+			Expect(err).To(Equal(pgx.ErrNoRows))
+		})
+	})
+})
@@ -0,0 +1,159 @@
+package dbclient
+
+import (
+	"context"
+	stderrors "errors"
+	"math/rand"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultTxMaxAttempts is how many times WithTx runs a transaction that fails with a serialization failure or
+	// deadlock.
+	DefaultTxMaxAttempts = 3
+	// DefaultTxMinBackoff is the delay before the first retry, which doubles on each retry.
+	DefaultTxMinBackoff = 10 * time.Millisecond
+	// DefaultTxMaxBackoff is the longest delay between retries.
+	DefaultTxMaxBackoff = time.Second
+
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+)
+
+// TxOptions configures a transaction run by WithTx. The zero value is a read/write transaction with the database's
+// default isolation level and the default retries.
+type TxOptions struct {
+	IsoLevel    pgx.TxIsoLevel // - e.g. pgx.Serializable or pgx.RepeatableRead
+	ReadOnly    bool
+	MaxAttempts int           // - is the number of attempts including the first, 1 disables retries
+	MinBackoff  time.Duration // - is the delay before the first retry
+	MaxBackoff  time.Duration // - is the longest delay between retries
+}
+
+// TxBeginner begins transactions, e.g. a *pgxpool.Pool or *pgx.Conn.
+type TxBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// Querier runs queries, e.g. a *pgxpool.Pool, *pgx.Conn or pgx.Tx.
+type Querier interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+type txKey struct{}
+
+// NewContext returns a copy of ctx carrying tx, so that functions called with it join the transaction.
+func NewContext(ctx context.Context, tx pgx.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext returns the transaction carried by ctx, if any.
+func TxFromContext(ctx context.Context) (pgx.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(pgx.Tx)
+	return tx, ok
+}
+
+// FromContext returns the transaction carried by ctx, or db if there isn't one. Repository functions use it to join
+// an outer transaction:
+//
+//	func (r repo) Save(ctx context.Context, l Listing) error {
+//		_, err := dbclient.FromContext(ctx, r.pool).Exec(ctx, "...", l.ID)
+//		return err
+//	}
+func FromContext(ctx context.Context, db Querier) Querier {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return db
+}
+
+// WithTx runs fn in a transaction, committing if it returns nil and rolling back if it returns an error or panics.
+// A transaction which fails with a serialization failure or deadlock (SQLSTATE 40001 or 40P01) is retried with
+// exponential backoff, so fn must be safe to run more than once.
+//
+// If ctx already carries a transaction, fn runs in a savepoint of it instead, and opts are ignored. Failures roll back
+// to the savepoint and are returned without retrying, so that the outermost WithTx retries the whole transaction.
+// Use NewContext(ctx, tx) within fn to pass the transaction to functions which take a context.
+func WithTx(ctx context.Context, db TxBeginner, opts TxOptions, fn func(pgx.Tx) error) error {
+	if outer, ok := TxFromContext(ctx); ok {
+		return run(ctx, func() (pgx.Tx, error) { return outer.Begin(ctx) }, fn)
+	}
+
+	txOptions := pgx.TxOptions{IsoLevel: opts.IsoLevel, AccessMode: pgx.ReadWrite}
+	if opts.ReadOnly {
+		txOptions.AccessMode = pgx.ReadOnly
+	}
+	attempts := opts.MaxAttempts
+	if attempts <= 0 {
+		attempts = DefaultTxMaxAttempts
+	}
+	backoff := opts.MinBackoff
+	if backoff <= 0 {
+		backoff = DefaultTxMinBackoff
+	}
+	maxBackoff := opts.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultTxMaxBackoff
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := run(ctx, func() (pgx.Tx, error) { return db.BeginTx(ctx, txOptions) }, fn)
+		if err == nil || attempt >= attempts || !IsRetryable(err) {
+			return err
+		}
+
+		// Full jitter spreads out transactions which conflicted with each other.
+		delay := time.Duration(rand.Int63n(int64(backoff)) + 1)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// run begins a transaction and runs fn in it.
+func run(ctx context.Context, begin func() (pgx.Tx, error), fn func(pgx.Tx) error) (err error) {
+	tx, err := begin()
+	if err != nil {
+		return errors.Wrap(err, "begin transaction")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			// The rollback error is lost, as the panic is more important.
+			_ = tx.Rollback(ctx)
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !stderrors.Is(rollbackErr, pgx.ErrTxClosed) {
+			return errors.Wrapf(err, "rollback failed: %v", rollbackErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, "commit transaction")
+	}
+	return nil
+}
+
+// IsRetryable reports whether err is a serialization failure or deadlock, after which the transaction can be retried.
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !stderrors.As(errors.Cause(err), &pgErr) {
+		return false
+	}
+	return pgErr.Code == sqlStateSerializationFailure || pgErr.Code == sqlStateDeadlockDetected
+}
@@ -0,0 +1,162 @@
+package dbclient
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeTx records how a transaction ends. Savepoints are fakeTxs with a parent.
+type fakeTx struct {
+	pgx.Tx
+	parent     *fakeTx
+	committed  bool
+	rolledBack bool
+	commitErr  error
+}
+
+func (t *fakeTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	return &fakeTx{parent: t}, nil
+}
+
+func (t *fakeTx) Commit(ctx context.Context) error {
+	t.committed = true
+	return t.commitErr
+}
+
+func (t *fakeTx) Rollback(ctx context.Context) error {
+	if t.committed {
+		return pgx.ErrTxClosed
+	}
+	t.rolledBack = true
+	return nil
+}
+
+type fakeBeginner struct {
+	options []pgx.TxOptions
+	txs     []*fakeTx
+}
+
+func (b *fakeBeginner) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	tx := &fakeTx{}
+	b.options = append(b.options, txOptions)
+	b.txs = append(b.txs, tx)
+	return tx, nil
+}
+
+var _ = Describe("WithTx", func() {
+	var (
+		db   *fakeBeginner
+		ctx  = context.Background()
+		opts = TxOptions{MinBackoff: time.Millisecond}
+	)
+
+	BeforeEach(func() {
+		db = &fakeBeginner{}
+	})
+
+	It("commits when fn succeeds", func() {
+		err := WithTx(ctx, db, TxOptions{IsoLevel: pgx.Serializable, ReadOnly: true}, func(tx pgx.Tx) error {
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(db.options).To(Equal([]pgx.TxOptions{{IsoLevel: pgx.Serializable, AccessMode: pgx.ReadOnly}}))
+		Expect(db.txs[0].committed).To(BeTrue())
+	})
+
+	It("rolls back when fn fails", func() {
+		failed := errors.New("failed")
+		err := WithTx(ctx, db, opts, func(tx pgx.Tx) error {
+			return failed
+		})
+		Expect(err).To(Equal(failed))
+		Expect(db.txs).To(HaveLen(1))
+		Expect(db.txs[0].rolledBack).To(BeTrue())
+	})
+
+	It("rolls back and re-panics when fn panics", func() {
+		Expect(func() {
+			WithTx(ctx, db, opts, func(tx pgx.Tx) error {
+				panic("boom")
+			})
+		}).To(Panic())
+		Expect(db.txs[0].rolledBack).To(BeTrue())
+	})
+
+	It("retries serialization failures and deadlocks", func() {
+		codes := []string{"40001", "40P01"}
+		err := WithTx(ctx, db, opts, func(tx pgx.Tx) error {
+			if len(codes) == 0 {
+				return nil
+			}
+			code := codes[0]
+			codes = codes[1:]
+			return &pgconn.PgError{Code: code}
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(db.txs).To(HaveLen(3))
+		Expect(db.txs[2].committed).To(BeTrue())
+	})
+
+	It("retries failed commits", func() {
+		err := WithTx(ctx, db, opts, func(tx pgx.Tx) error {
+			if len(db.txs) == 1 {
+				tx.(*fakeTx).commitErr = &pgconn.PgError{Code: "40001"}
+			}
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(db.txs).To(HaveLen(2))
+	})
+
+	It("gives up after MaxAttempts", func() {
+		err := WithTx(ctx, db, TxOptions{MaxAttempts: 2, MinBackoff: time.Millisecond}, func(tx pgx.Tx) error {
+			return &pgconn.PgError{Code: "40001"}
+		})
+		Expect(IsRetryable(err)).To(BeTrue())
+		Expect(db.txs).To(HaveLen(2))
+	})
+
+	It("doesn't retry other errors", func() {
+		err := WithTx(ctx, db, opts, func(tx pgx.Tx) error {
+			return &pgconn.PgError{Code: "23505"}
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(db.txs).To(HaveLen(1))
+	})
+
+	Context("with a transaction in the context", func() {
+		It("runs fn in a savepoint", func() {
+			err := WithTx(ctx, db, opts, func(tx pgx.Tx) error {
+				ctx := NewContext(ctx, tx)
+				Expect(FromContext(ctx, nil)).To(Equal(tx))
+
+				return WithTx(ctx, db, opts, func(savepoint pgx.Tx) error {
+					Expect(savepoint.(*fakeTx).parent).To(Equal(tx))
+					return nil
+				})
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(db.txs).To(HaveLen(1))
+		})
+
+		It("rolls back the savepoint without retrying", func() {
+			var savepoint *fakeTx
+			err := WithTx(ctx, db, TxOptions{MaxAttempts: 1}, func(tx pgx.Tx) error {
+				return WithTx(NewContext(ctx, tx), db, opts, func(tx pgx.Tx) error {
+					savepoint = tx.(*fakeTx)
+					return &pgconn.PgError{Code: "40001"}
+				})
+			})
+			Expect(IsRetryable(err)).To(BeTrue())
+			Expect(savepoint.rolledBack).To(BeTrue())
+			Expect(db.txs).To(HaveLen(1))
+			Expect(db.txs[0].rolledBack).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,70 @@
+package envelope
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/HomesNZ/go-common/trace"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// Envelope is the shared wrapper for every event published to SNS. The Type field uses the same json key as sqs.Event,
+// so routers that only look at the event type keep working with enveloped events.
+type Envelope[T any] struct {
+	ID            string      `json:"id"`             // ID uniquely identifies the event.
+	Type          string      `json:"type"`           // Type is the event type, used for topic names and routing.
+	SchemaVersion int         `json:"schema_version"` // SchemaVersion is the version of the Payload schema, starting at 1.
+	Producer      string      `json:"producer"`       // Producer is the name of the service that published the event.
+	OccurredAt    time.Time   `json:"occurred_at"`    // OccurredAt is when the event was created by the producer.
+	Trace         trace.Trace `json:"trace"`          // Trace links the event to the request or message that caused it.
+	Payload       T           `json:"payload"`
+}
+
+// Payload can be implemented by payload types so the event type and schema version don't need to be repeated at every
+// call site.
+type Payload interface {
+	EventType() string
+	SchemaVersion() int
+}
+
+// Raw is an envelope with an undecoded payload, used when upcasting.
+type Raw = Envelope[json.RawMessage]
+
+// New wraps payload in an envelope. A new ID is generated and the trace from ctx is linked, so the causation ID of the
+// event is the event ID of the trace in ctx. If ctx has no trace a new one is started.
+func New[T any](ctx context.Context, eventType string, version int, producer string, payload T) Envelope[T] {
+	return Envelope[T]{
+		ID:            uuid.NewString(),
+		Type:          eventType,
+		SchemaVersion: version,
+		Producer:      producer,
+		OccurredAt:    time.Now().UTC(),
+		Trace:         trace.LinkFromTrace(trace.FromCtx(ctx)),
+		Payload:       payload,
+	}
+}
+
+// From wraps a payload which implements Payload in an envelope.
+func From[T Payload](ctx context.Context, producer string, payload T) Envelope[T] {
+	return New(ctx, payload.EventType(), payload.SchemaVersion(), producer, payload)
+}
+
+// Unmarshal decodes an enveloped event. Messages which were published without an envelope (schema version 0) are
+// rejected, as their payload would silently decode as a zero value.
+func Unmarshal[T any](data []byte) (Envelope[T], error) {
+	env := Envelope[T]{}
+	if err := json.Unmarshal(data, &env); err != nil {
+		return env, errors.Wrap(err, "unmarshal envelope")
+	}
+	if env.SchemaVersion == 0 {
+		return env, errors.New("message is not an envelope: " + env.Type)
+	}
+	return env, nil
+}
+
+// Ctx returns ctx with the envelope trace set, so anything published while handling the event is linked to it.
+func (e Envelope[T]) Ctx(ctx context.Context) context.Context {
+	return trace.SetToCtx(ctx, e.Trace)
+}
@@ -0,0 +1,138 @@
+package envelope
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/HomesNZ/go-common/trace"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestEnvelope(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Envelope Suite")
+}
+
+type listingCreatedV1 struct {
+	Address string `json:"address"`
+}
+
+type listingCreated struct {
+	Street string `json:"street"`
+	Suburb string `json:"suburb"`
+}
+
+func (listingCreated) EventType() string  { return "listing_created" }
+func (listingCreated) SchemaVersion() int { return 2 }
+
+var _ = Describe("Envelope", func() {
+	Context(".New", func() {
+		It("should fill in the envelope", func() {
+			ctx := trace.SetToCtx(context.Background(), trace.New())
+			parent := trace.FromCtx(ctx)
+
+			env := New(ctx, "listing_created", 1, "listing-service", listingCreatedV1{Address: "1 Queen Street"})
+			Expect(env.ID).NotTo(Equal(""))
+			Expect(env.Type).To(Equal("listing_created"))
+			Expect(env.SchemaVersion).To(Equal(1))
+			Expect(env.Producer).To(Equal("listing-service"))
+			Expect(env.OccurredAt.IsZero()).To(Equal(false))
+			Expect(env.Trace.CausationID).To(Equal(parent.EventID))
+			Expect(env.Trace.CorrelationID).To(Equal(parent.CorrelationID))
+		})
+		It("should start a new trace", func() {
+			env := New(context.Background(), "listing_created", 1, "listing-service", listingCreatedV1{})
+			Expect(env.Trace.IsEmpty()).To(Equal(false))
+		})
+	})
+	Context(".From", func() {
+		It("should use the payload type and version", func() {
+			env := From(context.Background(), "listing-service", listingCreated{Street: "1 Queen Street"})
+			Expect(env.Type).To(Equal("listing_created"))
+			Expect(env.SchemaVersion).To(Equal(2))
+		})
+	})
+	Context(".Unmarshal", func() {
+		It("should decode an envelope", func() {
+			env := From(context.Background(), "listing-service", listingCreated{Street: "1 Queen Street"})
+			b, err := json.Marshal(env)
+			Expect(err).NotTo(HaveOccurred())
+
+			decoded, err := Unmarshal[listingCreated](b)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decoded.ID).To(Equal(env.ID))
+			Expect(decoded.Payload.Street).To(Equal("1 Queen Street"))
+		})
+		It("should reject a message without an envelope", func() {
+			_, err := Unmarshal[listingCreated]([]byte(`{"type":"listing_created","created":"2020-01-01T00:00:00Z"}`))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("Upcasters", func() {
+	upcasters := &Upcasters{}
+	upcasters.Register("listing_created", 1, func(payload json.RawMessage) (json.RawMessage, error) {
+		v1 := listingCreatedV1{}
+		if err := json.Unmarshal(payload, &v1); err != nil {
+			return nil, err
+		}
+		return json.Marshal(listingCreated{Street: v1.Address})
+	})
+
+	It("should migrate an old version to the current struct", func() {
+		env := New(context.Background(), "listing_created", 1, "listing-service", listingCreatedV1{Address: "1 Queen Street"})
+		b, err := json.Marshal(env)
+		Expect(err).NotTo(HaveOccurred())
+
+		b, err = upcasters.Upcast(b)
+		Expect(err).NotTo(HaveOccurred())
+
+		decoded, err := Unmarshal[listingCreated](b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decoded.SchemaVersion).To(Equal(2))
+		Expect(decoded.Payload.Street).To(Equal("1 Queen Street"))
+		Expect(decoded.ID).To(Equal(env.ID))
+	})
+	It("should leave the current version unchanged", func() {
+		env := From(context.Background(), "listing-service", listingCreated{Street: "1 Queen Street"})
+		b, err := json.Marshal(env)
+		Expect(err).NotTo(HaveOccurred())
+
+		upcasted, err := upcasters.Upcast(b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(upcasted).To(Equal(b))
+	})
+	It("should leave messages without an envelope unchanged", func() {
+		b := []byte(`{"type":"listing_created","created":"2020-01-01T00:00:00Z"}`)
+		upcasted, err := upcasters.Upcast(b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(upcasted).To(Equal(b))
+	})
+	It("should leave legacy events which don't decode as an envelope unchanged", func() {
+		for _, b := range [][]byte{
+			[]byte(`{"type":"listing_created","id":123}`),
+			[]byte(`{"type":"listing_created","id":123,"schema_version":1}`),
+			[]byte(`{"type":"listing_created","schema_version":1,"trace":"abc"}`),
+			[]byte(`{"type":"listing_created","schema_version":"1"}`),
+			[]byte(`[1,2,3]`),
+		} {
+			upcasted, err := upcasters.Upcast(b)
+			Expect(err).NotTo(HaveOccurred(), string(b))
+			Expect(upcasted).To(Equal(b))
+		}
+	})
+	It("should leave events without registered upcasters unchanged", func() {
+		b := []byte(`{"type":"listing_deleted","id":"1","schema_version":1,"trace":{"event_id":1}}`)
+		upcasted, err := upcasters.Upcast(b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(upcasted).To(Equal(b))
+	})
+	It("should panic when an upcaster is registered twice", func() {
+		upcaster := func(payload json.RawMessage) (json.RawMessage, error) { return payload, nil }
+		Expect(func() { upcasters.Register("listing_created", 1, upcaster) }).To(Panic())
+		Expect(func() { upcasters.Register("listing_created", 2, upcaster) }).NotTo(Panic())
+	})
+})
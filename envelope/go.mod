@@ -0,0 +1,24 @@
+module github.com/HomesNZ/go-common/envelope
+
+go 1.21.5
+
+require (
+	github.com/HomesNZ/go-common/trace v0.0.0-00010101000000-000000000000
+	github.com/google/uuid v1.4.0
+	github.com/onsi/ginkgo v1.16.5
+	github.com/onsi/gomega v1.30.0
+	github.com/pkg/errors v0.9.1
+)
+
+require (
+	github.com/fsnotify/fsnotify v1.4.9 // indirect
+	github.com/google/go-cmp v0.6.0 // indirect
+	github.com/nxadm/tail v1.4.8 // indirect
+	golang.org/x/net v0.17.0 // indirect
+	golang.org/x/sys v0.13.0 // indirect
+	golang.org/x/text v0.13.0 // indirect
+	gopkg.in/tomb.v1 v1.0.0-20141024135613-dd632973f1e7 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/HomesNZ/go-common/trace => ../trace
@@ -0,0 +1,92 @@
+package envelope
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Upcaster migrates a payload from one schema version to the next. It receives the payload at fromVersion and must
+// return the payload at fromVersion+1.
+type Upcaster func(payload json.RawMessage) (json.RawMessage, error)
+
+// Upcasters is a registry of upcasters keyed by event type and the schema version they migrate from. The zero value
+// is ready to use.
+type Upcasters struct {
+	mu        sync.RWMutex
+	upcasters map[string]map[int]Upcaster
+}
+
+// Register adds an upcaster which migrates eventType payloads from fromVersion to fromVersion+1. It panics if an
+// upcaster is already registered for eventType and fromVersion, as only one of them could ever be applied.
+func (u *Upcasters) Register(eventType string, fromVersion int, upcaster Upcaster) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.upcasters == nil {
+		u.upcasters = map[string]map[int]Upcaster{}
+	}
+	if u.upcasters[eventType] == nil {
+		u.upcasters[eventType] = map[int]Upcaster{}
+	}
+	if _, ok := u.upcasters[eventType][fromVersion]; ok {
+		panic(fmt.Sprintf("envelope: upcaster for %s version %d registered twice", eventType, fromVersion))
+	}
+	u.upcasters[eventType][fromVersion] = upcaster
+}
+
+// Upcast applies the registered upcasters to an encoded envelope until no upcaster is registered for its schema
+// version, and returns the re-encoded envelope. Messages which are not envelopes, can't be decoded as one, or have no
+// upcasters registered for their type, are returned unchanged, so legacy events pass through to their handlers.
+func (u *Upcasters) Upcast(data []byte) ([]byte, error) {
+	header := struct {
+		Type          string `json:"type"`
+		SchemaVersion int    `json:"schema_version"`
+	}{}
+	if err := json.Unmarshal(data, &header); err != nil || header.SchemaVersion == 0 || !u.has(header.Type) {
+		return data, nil
+	}
+	env := Raw{}
+	if err := json.Unmarshal(data, &env); err != nil {
+		return data, nil
+	}
+
+	upcasted, err := u.UpcastEnvelope(env)
+	if err != nil {
+		return nil, err
+	}
+	if upcasted.SchemaVersion == env.SchemaVersion {
+		return data, nil
+	}
+	return json.Marshal(upcasted)
+}
+
+// UpcastEnvelope applies the registered upcasters to a decoded envelope.
+func (u *Upcasters) UpcastEnvelope(env Raw) (Raw, error) {
+	for {
+		upcaster, ok := u.get(env.Type, env.SchemaVersion)
+		if !ok {
+			return env, nil
+		}
+		payload, err := upcaster(env.Payload)
+		if err != nil {
+			return env, errors.Wrapf(err, "upcast %s from version %d", env.Type, env.SchemaVersion)
+		}
+		env.Payload = payload
+		env.SchemaVersion++
+	}
+}
+
+func (u *Upcasters) has(eventType string) bool {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return len(u.upcasters[eventType]) > 0
+}
+
+func (u *Upcasters) get(eventType string, version int) (Upcaster, bool) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	upcaster, ok := u.upcasters[eventType][version]
+	return upcaster, ok
+}
@@ -0,0 +1,234 @@
+// Package imaging validates uploaded photos and stores resized renditions of them through the s3 package, using pure
+// Go codecs only.
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"time"
+
+	// Register the decoders of the accepted upload types.
+	_ "image/gif"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+
+	"github.com/HomesNZ/go-common/s3"
+	"github.com/pkg/errors"
+	xdraw "golang.org/x/image/draw"
+)
+
+// Format is the encoding of a rendition.
+type Format string
+
+const (
+	JPEG Format = "jpeg"
+	// WebP renditions are encoded losslessly, the Quality of the rendition is ignored.
+	WebP Format = "webp"
+)
+
+var (
+	// ErrUnsupportedType is returned for uploads which aren't an accepted image type.
+	ErrUnsupportedType = errors.New("imaging: unsupported image type")
+	// ErrTooSmall is returned for images smaller than the minimum dimensions.
+	ErrTooSmall = errors.New("imaging: image is too small")
+	// ErrTooLarge is returned for images larger than the maximum dimensions.
+	ErrTooLarge = errors.New("imaging: image is too large")
+)
+
+// Rendition describes a resized version of the uploaded image.
+type Rendition struct {
+	Name      string // - is used in the key and manifest, e.g. "thumbnail"
+	MaxWidth  int    // - is the maximum width, images are never enlarged
+	MaxHeight int    // - is the maximum height, images are never enlarged
+	Crop      bool   // - fills MaxWidth x MaxHeight exactly, cropping the center of the image, instead of fitting inside it
+	Format    Format
+	Quality   int // - is the JPEG quality, 1-100
+}
+
+// DefaultRenditions are used when no renditions are configured.
+var DefaultRenditions = []Rendition{
+	{Name: "thumbnail", MaxWidth: 200, MaxHeight: 200, Crop: true, Format: JPEG, Quality: 80},
+	{Name: "medium", MaxWidth: 800, MaxHeight: 800, Format: JPEG, Quality: 85},
+	{Name: "large", MaxWidth: 1600, MaxHeight: 1600, Format: JPEG, Quality: 85},
+}
+
+// Manifest lists the renditions stored for an image.
+type Manifest struct {
+	ID         string                  `json:"id"`     // ID is derived from the content of the upload.
+	Width      int                     `json:"width"`  // Width of the upload, after it was auto-oriented.
+	Height     int                     `json:"height"` // Height of the upload, after it was auto-oriented.
+	Renditions map[string]StoredObject `json:"renditions"`
+}
+
+// StoredObject is a rendition which was stored.
+type StoredObject struct {
+	Key         string `json:"key"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Size        int    `json:"size"`
+}
+
+// Processor processes uploaded images.
+type Processor struct {
+	storage      s3.Service
+	renditions   []Rendition
+	keyPrefix    string
+	allowedTypes map[string]bool
+	minWidth     int
+	minHeight    int
+	maxWidth     int
+	maxHeight    int
+	expiry       time.Time
+}
+
+// Process validates an uploaded image, removes its metadata (including EXIF and GPS), rotates it upright according
+// to its EXIF orientation and stores each rendition under {prefix}/{id}/{rendition}.{format}. The ID is a hash of the
+// upload, so processing the same upload again overwrites the same keys.
+func (p *Processor) Process(ctx context.Context, b []byte) (*Manifest, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(b))
+	if err != nil || !p.allowedTypes[format] {
+		return nil, ErrUnsupportedType
+	}
+	orientation := exifOrientation(b)
+	width, height := cfg.Width, cfg.Height
+	if orientation >= 5 {
+		width, height = height, width
+	}
+	if width < p.minWidth || height < p.minHeight {
+		return nil, errors.Wrapf(ErrTooSmall, "%dx%d", width, height)
+	}
+	if width > p.maxWidth || height > p.maxHeight {
+		return nil, errors.Wrapf(ErrTooLarge, "%dx%d", width, height)
+	}
+
+	// Decoding and re-encoding drops every metadata segment of the original.
+	img, _, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		return nil, errors.Wrap(err, "decode image")
+	}
+	img = orient(img, orientation)
+
+	sum := sha256.Sum256(b)
+	manifest := &Manifest{
+		ID:         hex.EncodeToString(sum[:16]),
+		Width:      width,
+		Height:     height,
+		Renditions: map[string]StoredObject{},
+	}
+	for _, rendition := range p.renditions {
+		stored, err := p.store(ctx, manifest.ID, img, rendition)
+		if err != nil {
+			return nil, errors.Wrapf(err, "store rendition %s", rendition.Name)
+		}
+		manifest.Renditions[rendition.Name] = stored
+	}
+	return manifest, nil
+}
+
+func (p *Processor) store(ctx context.Context, id string, img image.Image, rendition Rendition) (StoredObject, error) {
+	resized := resize(img, rendition)
+
+	buf := &bytes.Buffer{}
+	var contentType string
+	switch rendition.Format {
+	case WebP:
+		contentType = "image/webp"
+		if err := encodeWebP(buf, resized); err != nil {
+			return StoredObject{}, err
+		}
+	default:
+		contentType = "image/jpeg"
+		quality := rendition.Quality
+		if quality <= 0 {
+			quality = jpeg.DefaultQuality
+		}
+		if err := jpeg.Encode(buf, flatten(resized), &jpeg.Options{Quality: quality}); err != nil {
+			return StoredObject{}, err
+		}
+	}
+
+	key := fmt.Sprintf("%s/%s/%s.%s", p.keyPrefix, id, rendition.Name, extension(rendition.Format))
+	url, err := p.storage.Upload(ctx, key, buf.Bytes(), p.expiry, contentType)
+	if err != nil {
+		return StoredObject{}, err
+	}
+
+	bounds := resized.Bounds()
+	return StoredObject{
+		Key:         key,
+		URL:         url,
+		ContentType: contentType,
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+		Size:        buf.Len(),
+	}, nil
+}
+
+func extension(format Format) string {
+	if format == WebP {
+		return "webp"
+	}
+	return "jpg"
+}
+
+// resize scales img to fit inside, or with Crop to fill, the rendition's dimensions. Images are never enlarged.
+func resize(img image.Image, rendition Rendition) image.Image {
+	src := img.Bounds()
+	w, h := src.Dx(), src.Dy()
+	maxW, maxH := rendition.MaxWidth, rendition.MaxHeight
+	if maxW <= 0 {
+		maxW = w
+	}
+	if maxH <= 0 {
+		maxH = h
+	}
+
+	if rendition.Crop {
+		// Crop the center of the image to the aspect ratio of the rendition, then scale it down.
+		cw, ch := w, maxInt(1, w*maxH/maxW)
+		if ch > h {
+			cw, ch = maxInt(1, h*maxW/maxH), h
+		}
+		x, y := src.Min.X+(w-cw)/2, src.Min.Y+(h-ch)/2
+		src = image.Rect(x, y, x+cw, y+ch)
+		w, h = cw, ch
+		if w > maxW {
+			w, h = maxW, maxH
+		}
+	} else if w > maxW || h > maxH {
+		if w*maxH > h*maxW {
+			w, h = maxW, maxInt(1, h*maxW/w)
+		} else {
+			w, h = maxInt(1, w*maxH/h), maxH
+		}
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, src, xdraw.Src, nil)
+	return dst
+}
+
+// flatten draws img on a white background, as JPEG has no transparency.
+func flatten(img image.Image) image.Image {
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
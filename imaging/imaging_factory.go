@@ -0,0 +1,76 @@
+package imaging
+
+import (
+	"time"
+
+	"github.com/HomesNZ/go-common/s3"
+)
+
+// Option configures a Processor.
+type Option func(*Processor)
+
+// WithRenditions sets the renditions generated for each image, replacing DefaultRenditions.
+func WithRenditions(renditions ...Rendition) Option {
+	return func(p *Processor) {
+		p.renditions = renditions
+	}
+}
+
+// WithKeyPrefix sets the prefix of the keys renditions are stored under. Defaults to "images".
+func WithKeyPrefix(prefix string) Option {
+	return func(p *Processor) {
+		p.keyPrefix = prefix
+	}
+}
+
+// WithAllowedTypes sets the accepted upload types, out of "jpeg", "png", "gif" and "webp". Defaults to all of them.
+func WithAllowedTypes(types ...string) Option {
+	return func(p *Processor) {
+		p.allowedTypes = map[string]bool{}
+		for _, t := range types {
+			p.allowedTypes[t] = true
+		}
+	}
+}
+
+// WithMinDimensions rejects images smaller than width x height, after they're auto-oriented.
+func WithMinDimensions(width, height int) Option {
+	return func(p *Processor) {
+		p.minWidth = width
+		p.minHeight = height
+	}
+}
+
+// WithMaxDimensions rejects images larger than width x height, after they're auto-oriented. Defaults to 10000 x
+// 10000, which also limits the memory used to decode an image.
+func WithMaxDimensions(width, height int) Option {
+	return func(p *Processor) {
+		p.maxWidth = width
+		p.maxHeight = height
+	}
+}
+
+// WithExpiry sets the date and time stored renditions are no longer cacheable.
+func WithExpiry(expiry time.Time) Option {
+	return func(p *Processor) {
+		p.expiry = expiry
+	}
+}
+
+// New returns a Processor which stores renditions with store.
+func New(store s3.Service, options ...Option) *Processor {
+	p := &Processor{
+		storage:      store,
+		renditions:   DefaultRenditions,
+		keyPrefix:    "images",
+		allowedTypes: map[string]bool{"jpeg": true, "png": true, "gif": true, "webp": true},
+		minWidth:     1,
+		minHeight:    1,
+		maxWidth:     10000,
+		maxHeight:    10000,
+	}
+	for _, opt := range options {
+		opt(p)
+	}
+	return p
+}
@@ -0,0 +1,158 @@
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+	"time"
+
+	"github.com/HomesNZ/go-common/s3"
+	mock_s3 "github.com/HomesNZ/go-common/s3/mock"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/image/webp"
+)
+
+func TestImaging(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Imaging")
+}
+
+// photo returns a width x height JPEG which is red on the left half and blue on the right, with an EXIF orientation
+// if orientation isn't 0.
+func photo(width, height, orientation int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= width/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	buf := &bytes.Buffer{}
+	Expect(jpeg.Encode(buf, img, nil)).To(Succeed())
+	if orientation == 0 {
+		return buf.Bytes()
+	}
+
+	// A big endian TIFF header with a single IFD entry holding the orientation.
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 1, 0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, byte(orientation), 0, 0, 0, 0, 0, 0}
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	b := buf.Bytes()
+	return append(append(append([]byte{}, b[:2]...), app1...), b[2:]...)
+}
+
+var _ = Describe("Processor", func() {
+	var (
+		ctrl    *gomock.Controller
+		store   *mock_s3.MockService
+		uploads map[string][]byte
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		store = mock_s3.NewMockService(ctrl)
+		uploads = map[string][]byte{}
+		store.EXPECT().Upload(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+			func(ctx context.Context, key string, b []byte, expiry time.Time, contentType string, opts ...s3.TransferOption) (string, error) {
+				uploads[key] = b
+				return "https://cdn.example.com/" + key, nil
+			})
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("stores the renditions", func() {
+		p := New(store, WithRenditions(
+			Rendition{Name: "thumbnail", MaxWidth: 50, MaxHeight: 50, Crop: true, Format: JPEG},
+			Rendition{Name: "large", MaxWidth: 200, MaxHeight: 200, Format: WebP},
+		))
+		manifest, err := p.Process(context.Background(), photo(400, 200, 0))
+		Expect(err).NotTo(HaveOccurred())
+
+		thumbnail := manifest.Renditions["thumbnail"]
+		Expect(thumbnail.Key).To(Equal("images/" + manifest.ID + "/thumbnail.jpg"))
+		Expect(thumbnail.URL).To(Equal("https://cdn.example.com/" + thumbnail.Key))
+		Expect([]int{thumbnail.Width, thumbnail.Height}).To(Equal([]int{50, 50}))
+
+		large := manifest.Renditions["large"]
+		Expect([]int{large.Width, large.Height}).To(Equal([]int{200, 100}))
+		img, err := webp.Decode(bytes.NewReader(uploads[large.Key]))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(img.Bounds().Dx()).To(Equal(200))
+	})
+
+	It("uses a deterministic ID", func() {
+		p := New(store)
+		first, err := p.Process(context.Background(), photo(100, 100, 0))
+		Expect(err).NotTo(HaveOccurred())
+		second, err := p.Process(context.Background(), photo(100, 100, 0))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(first.ID).To(Equal(second.ID))
+	})
+
+	It("auto-orients and strips EXIF", func() {
+		b := photo(400, 200, 6)
+		Expect(exifOrientation(b)).To(Equal(6))
+
+		p := New(store, WithRenditions(Rendition{Name: "large", MaxWidth: 1000, MaxHeight: 1000, Format: JPEG, Quality: 100}))
+		manifest, err := p.Process(context.Background(), b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect([]int{manifest.Width, manifest.Height}).To(Equal([]int{200, 400}))
+
+		stored := uploads[manifest.Renditions["large"].Key]
+		Expect(exifOrientation(stored)).To(Equal(1))
+		Expect(bytes.Contains(stored, []byte("Exif"))).To(BeFalse())
+
+		// Rotated clockwise, the red left half of the photo is now the top half.
+		img, err := jpeg.Decode(bytes.NewReader(stored))
+		Expect(err).NotTo(HaveOccurred())
+		r, _, b2, _ := img.At(100, 50).RGBA()
+		Expect(r > b2).To(BeTrue())
+		r, _, b2, _ = img.At(100, 350).RGBA()
+		Expect(b2 > r).To(BeTrue())
+	})
+
+	It("rejects images outside the dimensions", func() {
+		p := New(store, WithMinDimensions(300, 300))
+		_, err := p.Process(context.Background(), photo(400, 200, 0))
+		Expect(err).To(MatchError(ContainSubstring(ErrTooSmall.Error())))
+
+		p = New(store, WithMaxDimensions(300, 300))
+		_, err = p.Process(context.Background(), photo(400, 200, 0))
+		Expect(err).To(MatchError(ContainSubstring(ErrTooLarge.Error())))
+	})
+
+	It("rejects unsupported types", func() {
+		_, err := New(store).Process(context.Background(), []byte("%PDF-1.4"))
+		Expect(err).To(Equal(ErrUnsupportedType))
+
+		_, err = New(store, WithAllowedTypes("png")).Process(context.Background(), photo(10, 10, 0))
+		Expect(err).To(Equal(ErrUnsupportedType))
+	})
+})
+
+var _ = Describe("resize", func() {
+	It("crops images to at least a pixel", func() {
+		wide := resize(image.NewNRGBA(image.Rect(0, 0, 400, 1)), Rendition{MaxWidth: 1, MaxHeight: 1000, Crop: true})
+		Expect(wide.Bounds().Dx()).To(BeNumerically(">=", 1))
+		Expect(wide.Bounds().Dy()).To(Equal(1))
+
+		tall := resize(image.NewNRGBA(image.Rect(0, 0, 1, 400)), Rendition{MaxWidth: 1000, MaxHeight: 1, Crop: true})
+		Expect(tall.Bounds().Dx()).To(Equal(1))
+		Expect(tall.Bounds().Dy()).To(BeNumerically(">=", 1))
+	})
+})
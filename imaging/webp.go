@@ -0,0 +1,310 @@
+package imaging
+
+import (
+	"encoding/binary"
+	"image"
+	"image/draw"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// WebP lossless (VP8L) bitstream constants, see RFC 9649.
+const (
+	vp8lSignature        = 0x2f
+	vp8lMaxDimension     = 1 << 14
+	vp8lSubtractGreen    = 2
+	vp8lNumLengthCodes   = 24
+	vp8lNumDistanceCodes = 40
+	vp8lMaxCodeLength    = 15
+	vp8lMaxCodeLenCode   = 7
+)
+
+// vp8lCodeLengthOrder is the order the lengths of the code length code are written in.
+var vp8lCodeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// encodeWebP writes img as a lossless WebP. Only the subtract green transform is used, with no backward references
+// or color cache, which keeps the encoder simple at the cost of larger files than libwebp.
+func encodeWebP(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 1 || height < 1 || width > vp8lMaxDimension || height > vp8lMaxDimension {
+		return errors.Errorf("webp: can't encode a %dx%d image", width, height)
+	}
+	nrgba, ok := img.(*image.NRGBA)
+	if !ok || nrgba.Rect.Min != (image.Point{}) || nrgba.Stride != 4*width {
+		nrgba = image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(nrgba, nrgba.Rect, img, bounds.Min, draw.Src)
+	}
+
+	// Subtracting green from red and blue makes them cheaper to code, as the channels of photos are correlated.
+	pix := make([]byte, len(nrgba.Pix))
+	copy(pix, nrgba.Pix)
+	alphaUsed := false
+	var green, red, blue, alpha [256]int
+	for i := 0; i < len(pix); i += 4 {
+		pix[i] -= pix[i+1]
+		pix[i+2] -= pix[i+1]
+		red[pix[i]]++
+		green[pix[i+1]]++
+		blue[pix[i+2]]++
+		alpha[pix[i+3]]++
+		alphaUsed = alphaUsed || pix[i+3] != 0xff
+	}
+
+	bw := &bitWriter{}
+	bw.write(vp8lSignature, 8)
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	bw.write(boolBit(alphaUsed), 1)
+	bw.write(0, 3) // version
+	bw.write(1, 1) // a transform follows
+	bw.write(vp8lSubtractGreen, 2)
+	bw.write(0, 1) // no more transforms
+	bw.write(0, 1) // no color cache
+	bw.write(0, 1) // a single group of prefix codes
+
+	greenCode := writePrefixCode(bw, append(green[:], make([]int, vp8lNumLengthCodes)...))
+	redCode := writePrefixCode(bw, red[:])
+	blueCode := writePrefixCode(bw, blue[:])
+	alphaCode := writePrefixCode(bw, alpha[:])
+	writePrefixCode(bw, make([]int, vp8lNumDistanceCodes))
+
+	for i := 0; i < len(pix); i += 4 {
+		greenCode.write(bw, int(pix[i+1]))
+		redCode.write(bw, int(pix[i]))
+		blueCode.write(bw, int(pix[i+2]))
+		alphaCode.write(bw, int(pix[i+3]))
+	}
+	data := bw.bytes()
+
+	padded := len(data) + len(data)&1
+	header := make([]byte, 20)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(12+padded))
+	copy(header[8:], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(header[16:], uint32(len(data)))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if padded > len(data) {
+		data = append(data, 0)
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// bitWriter packs values least significant bit first, as VP8L is read.
+type bitWriter struct {
+	buf  []byte
+	bits uint64
+	n    uint
+}
+
+func (w *bitWriter) write(v uint32, n uint) {
+	w.bits |= uint64(v) << w.n
+	w.n += n
+	for w.n >= 8 {
+		w.buf = append(w.buf, byte(w.bits))
+		w.bits >>= 8
+		w.n -= 8
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	if w.n > 0 {
+		w.buf = append(w.buf, byte(w.bits))
+		w.bits, w.n = 0, 0
+	}
+	return w.buf
+}
+
+// prefixCode is a canonical Huffman code, with the codes bit reversed so they can be written least significant bit
+// first.
+type prefixCode struct {
+	codes   []uint32
+	lengths []uint8
+}
+
+func (c prefixCode) write(w *bitWriter, symbol int) {
+	w.write(c.codes[symbol], uint(c.lengths[symbol]))
+}
+
+// writePrefixCode writes the prefix code for symbols with the frequencies freqs, and returns it.
+func writePrefixCode(w *bitWriter, freqs []int) prefixCode {
+	var symbols []int
+	for symbol, freq := range freqs {
+		if freq > 0 {
+			symbols = append(symbols, symbol)
+		}
+	}
+	if len(symbols) == 0 {
+		symbols = []int{0}
+	}
+
+	if len(symbols) <= 2 && symbols[len(symbols)-1] < 256 {
+		// A simple code, which reads a single symbol with no bits, or each of two symbols with one bit.
+		code := prefixCode{codes: make([]uint32, len(freqs)), lengths: make([]uint8, len(freqs))}
+		w.write(1, 1)
+		w.write(uint32(len(symbols)-1), 1)
+		if symbols[0] < 2 {
+			w.write(0, 1)
+			w.write(uint32(symbols[0]), 1)
+		} else {
+			w.write(1, 1)
+			w.write(uint32(symbols[0]), 8)
+		}
+		if len(symbols) == 2 {
+			w.write(uint32(symbols[1]), 8)
+			code.codes[symbols[1]] = 1
+			code.lengths[symbols[0]], code.lengths[symbols[1]] = 1, 1
+		}
+		return code
+	}
+
+	lengths := codeLengths(freqs, vp8lMaxCodeLength)
+	var lengthFreqs [19]int
+	for _, l := range lengths {
+		lengthFreqs[l]++
+	}
+	lengthLengths := codeLengths(lengthFreqs[:], vp8lMaxCodeLenCode)
+	lengthCode := canonicalCode(lengthLengths)
+	if countUsed(lengthLengths) == 1 {
+		// A code with a single symbol is read with no bits.
+		lengthCode = prefixCode{codes: make([]uint32, len(lengthLengths)), lengths: make([]uint8, len(lengthLengths))}
+	}
+
+	numCodes := 4
+	for i, symbol := range vp8lCodeLengthOrder {
+		if lengthLengths[symbol] > 0 && i+1 > numCodes {
+			numCodes = i + 1
+		}
+	}
+	w.write(0, 1)
+	w.write(uint32(numCodes-4), 4)
+	for _, symbol := range vp8lCodeLengthOrder[:numCodes] {
+		w.write(uint32(lengthLengths[symbol]), 3)
+	}
+	w.write(0, 1) // code lengths for every symbol follow
+	for _, l := range lengths {
+		lengthCode.write(w, int(l))
+	}
+	return canonicalCode(lengths)
+}
+
+// codeLengths returns the Huffman code lengths for freqs, no longer than limit. Rare symbols are made more frequent
+// until the code fits in limit.
+func codeLengths(freqs []int, limit int) []uint8 {
+	for floor := 1; ; floor *= 2 {
+		lengths := huffmanLengths(freqs, floor)
+		longest := uint8(0)
+		for _, l := range lengths {
+			longest = maxUint8(longest, l)
+		}
+		if int(longest) <= limit {
+			return lengths
+		}
+	}
+}
+
+// huffmanLengths returns the Huffman code lengths for freqs, counting each used symbol at least floor times.
+func huffmanLengths(freqs []int, floor int) []uint8 {
+	type tree struct {
+		weight  int
+		symbols []int
+	}
+	lengths := make([]uint8, len(freqs))
+	var trees []tree
+	for symbol, freq := range freqs {
+		if freq > 0 {
+			if freq < floor {
+				freq = floor
+			}
+			trees = append(trees, tree{weight: freq, symbols: []int{symbol}})
+		}
+	}
+	if len(trees) == 1 {
+		lengths[trees[0].symbols[0]] = 1
+		return lengths
+	}
+	for len(trees) > 1 {
+		// Merge the two lightest trees, which adds a bit to the codes of their symbols.
+		a, b := 0, 1
+		if trees[b].weight < trees[a].weight {
+			a, b = b, a
+		}
+		for i := 2; i < len(trees); i++ {
+			if trees[i].weight < trees[a].weight {
+				a, b = i, a
+			} else if trees[i].weight < trees[b].weight {
+				b = i
+			}
+		}
+		merged := tree{weight: trees[a].weight + trees[b].weight, symbols: append(trees[a].symbols, trees[b].symbols...)}
+		for _, symbol := range merged.symbols {
+			lengths[symbol]++
+		}
+		if a > b {
+			a, b = b, a
+		}
+		trees[a] = merged
+		trees = append(trees[:b], trees[b+1:]...)
+	}
+	return lengths
+}
+
+// canonicalCode assigns codes to lengths in order of length, then symbol, as the decoder does.
+func canonicalCode(lengths []uint8) prefixCode {
+	var count [vp8lMaxCodeLength + 1]uint32
+	for _, l := range lengths {
+		count[l]++
+	}
+	count[0] = 0
+	var next [vp8lMaxCodeLength + 1]uint32
+	code := uint32(0)
+	for l := 1; l <= vp8lMaxCodeLength; l++ {
+		code = (code + count[l-1]) << 1
+		next[l] = code
+	}
+	c := prefixCode{codes: make([]uint32, len(lengths)), lengths: lengths}
+	for symbol, l := range lengths {
+		if l > 0 {
+			c.codes[symbol] = reverseBits(next[l], uint(l))
+			next[l]++
+		}
+	}
+	return c
+}
+
+func reverseBits(v uint32, n uint) uint32 {
+	r := uint32(0)
+	for i := uint(0); i < n; i++ {
+		r = r<<1 | v&1
+		v >>= 1
+	}
+	return r
+}
+
+func countUsed(lengths []uint8) int {
+	n := 0
+	for _, l := range lengths {
+		if l > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func boolBit(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func maxUint8(a, b uint8) uint8 {
+	if a > b {
+		return a
+	}
+	return b
+}
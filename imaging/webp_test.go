@@ -0,0 +1,79 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/image/webp"
+)
+
+// roundTrip encodes img as WebP and decodes it again.
+func roundTrip(img image.Image) *image.NRGBA {
+	buf := &bytes.Buffer{}
+	Expect(encodeWebP(buf, img)).To(Succeed())
+	decoded, err := webp.Decode(buf)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(decoded).To(BeAssignableToTypeOf(&image.NRGBA{}))
+	return decoded.(*image.NRGBA)
+}
+
+var _ = Describe("encodeWebP", func() {
+	It("encodes photos losslessly", func() {
+		random := rand.New(rand.NewSource(1))
+		img := image.NewNRGBA(image.Rect(0, 0, 37, 23))
+		for i := range img.Pix {
+			img.Pix[i] = byte(random.Intn(256))
+		}
+		Expect(roundTrip(img).Pix).To(Equal(img.Pix))
+	})
+
+	It("encodes images with a few colors", func() {
+		img := image.NewNRGBA(image.Rect(0, 0, 9, 5))
+		for y := 0; y < 5; y++ {
+			for x := 0; x < 9; x++ {
+				c := color.NRGBA{R: 200, G: 10, B: 30, A: 255}
+				if x%3 == 0 {
+					c = color.NRGBA{R: 1, G: 2, B: 3, A: 128}
+				}
+				img.SetNRGBA(x, y, c)
+			}
+		}
+		Expect(roundTrip(img).Pix).To(Equal(img.Pix))
+
+		single := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+		single.SetNRGBA(0, 0, color.NRGBA{R: 255, A: 255})
+		Expect(roundTrip(single).Pix).To(Equal(single.Pix))
+	})
+
+	It("encodes images which aren't NRGBA", func() {
+		img := image.NewRGBA(image.Rect(10, 10, 14, 12))
+		for i := range img.Pix {
+			img.Pix[i] = 0xff
+		}
+		decoded := roundTrip(img)
+		Expect(decoded.Bounds()).To(Equal(image.Rect(0, 0, 4, 2)))
+		Expect(decoded.Pix).To(Equal(img.Pix))
+	})
+
+	It("rejects images which are too large", func() {
+		Expect(encodeWebP(&bytes.Buffer{}, image.NewNRGBA(image.Rect(0, 0, 1<<14+1, 1)))).NotTo(Succeed())
+	})
+	It("limits the code lengths", func() {
+		// Fibonacci frequencies give the deepest Huffman trees.
+		freqs := []int{1, 1}
+		for len(freqs) < 30 {
+			freqs = append(freqs, freqs[len(freqs)-1]+freqs[len(freqs)-2])
+		}
+		lengths := codeLengths(freqs, vp8lMaxCodeLength)
+		kraft := 0
+		for _, l := range lengths {
+			Expect(l).To(BeNumerically("<=", vp8lMaxCodeLength))
+			kraft += 1 << (vp8lMaxCodeLength - l)
+		}
+		Expect(kraft).To(Equal(1 << vp8lMaxCodeLength))
+	})
+})
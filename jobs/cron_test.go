@@ -0,0 +1,55 @@
+package jobs
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseCron", func() {
+	// Wednesday.
+	from := time.Date(2020, 1, 1, 10, 7, 30, 0, time.UTC)
+
+	next := func(spec string, t time.Time) time.Time {
+		s, err := ParseCron(spec)
+		Expect(err).NotTo(HaveOccurred())
+		return s.Next(t)
+	}
+
+	It("runs every minute", func() {
+		Expect(next("* * * * *", from)).To(Equal(time.Date(2020, 1, 1, 10, 8, 0, 0, time.UTC)))
+	})
+
+	It("supports steps, ranges and lists", func() {
+		Expect(next("*/15 * * * *", from)).To(Equal(time.Date(2020, 1, 1, 10, 15, 0, 0, time.UTC)))
+		Expect(next("0 9-17/4 * * *", from)).To(Equal(time.Date(2020, 1, 1, 13, 0, 0, 0, time.UTC)))
+		Expect(next("5,10 8 * * *", from)).To(Equal(time.Date(2020, 1, 2, 8, 5, 0, 0, time.UTC)))
+	})
+
+	It("supports named months and days of the week", func() {
+		Expect(next("0 0 * * sat-sun", from)).To(Equal(time.Date(2020, 1, 4, 0, 0, 0, 0, time.UTC)))
+		Expect(next("0 0 1 mar *", from)).To(Equal(time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)))
+		Expect(next("0 0 * * 7", from)).To(Equal(time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC)))
+	})
+
+	It("matches either day field when both are restricted", func() {
+		Expect(next("0 0 15 * fri", from)).To(Equal(time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC)))
+	})
+
+	It("supports descriptors", func() {
+		Expect(next("@daily", from)).To(Equal(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)))
+		Expect(next("@every 1h", from)).To(Equal(time.Date(2020, 1, 1, 11, 0, 0, 0, time.UTC)))
+	})
+
+	It("returns the zero time for a date that doesn't exist", func() {
+		Expect(next("0 0 30 2 *", from)).To(BeZero())
+	})
+
+	It("rejects invalid specs", func() {
+		for _, spec := range []string{"* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "@every 1ms"} {
+			_, err := ParseCron(spec)
+			Expect(err).To(HaveOccurred(), spec)
+		}
+	})
+})
@@ -0,0 +1,117 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	dbclient "github.com/HomesNZ/go-common/dbclient/v4"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+)
+
+// DefaultMaxAttempts is the number of times a job is attempted before it's dead, unless WithMaxAttempts is used.
+const DefaultMaxAttempts = 25
+
+// ErrDuplicate is returned by Enqueue when a job with the same unique key is already available or running.
+var ErrDuplicate = errors.New("duplicate job")
+
+const enqueueSQL = `INSERT INTO jobs (queue, kind, payload, priority, unique_key, max_attempts, run_at)
+VALUES ($1, $2, $3, $4, $5, $6, COALESCE($7, now()))
+ON CONFLICT (unique_key) WHERE unique_key IS NOT NULL AND state IN ('available', 'running') DO NOTHING
+RETURNING id`
+
+const retrySQL = `UPDATE jobs SET state = 'available', attempts = 0, run_at = now(), last_error = NULL, updated_at = now()
+WHERE id = $1 AND state = 'dead'`
+
+// EnqueueOption configures a job being enqueued.
+type EnqueueOption func(*enqueueOptions)
+
+type enqueueOptions struct {
+	queue       string
+	priority    int
+	uniqueKey   *string
+	maxAttempts int
+	runAt       *time.Time
+}
+
+// WithQueue enqueues the job on queue, which is handled by workers created with WithWorkerQueue. Defaults to
+// DefaultQueue.
+func WithQueue(queue string) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.queue = queue
+	}
+}
+
+// WithPriority sets the priority of the job. Available jobs with a higher priority are claimed first. Defaults to 0.
+func WithPriority(priority int) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.priority = priority
+	}
+}
+
+// WithUniqueKey stops the job being enqueued while another job with the same key is available or running, in which
+// case Enqueue returns ErrDuplicate.
+func WithUniqueKey(key string) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.uniqueKey = &key
+	}
+}
+
+// WithMaxAttempts sets the number of times the job is attempted before it's dead. Defaults to DefaultMaxAttempts.
+func WithMaxAttempts(n int) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.maxAttempts = n
+	}
+}
+
+// WithRunAt stops the job being claimed before runAt. Defaults to now.
+func WithRunAt(runAt time.Time) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.runAt = &runAt
+	}
+}
+
+// WithDelay stops the job being claimed until d has passed.
+func WithDelay(d time.Duration) EnqueueOption {
+	return WithRunAt(time.Now().Add(d))
+}
+
+// Enqueue adds a job of kind with payload encoded as JSON, and returns its ID. It uses the transaction carried by ctx,
+// if any, so that the job is only enqueued if the transaction commits.
+func Enqueue(ctx context.Context, db dbclient.Querier, kind string, payload interface{}, opts ...EnqueueOption) (int64, error) {
+	o := enqueueOptions{
+		queue:       DefaultQueue,
+		maxAttempts: DefaultMaxAttempts,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if payload == nil {
+		payload = struct{}{}
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return 0, errors.Wrapf(err, "marshal %s payload", kind)
+	}
+
+	// The payload is sent as a string, as the simple protocol (see dbclient's DisableSimpleProtocol) sends []byte as
+	// bytea, which isn't valid jsonb.
+	var id int64
+	err = dbclient.FromContext(ctx, db).
+		QueryRow(ctx, enqueueSQL, o.queue, kind, string(b), o.priority, o.uniqueKey, o.maxAttempts, o.runAt).
+		Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrDuplicate
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "enqueue %s", kind)
+	}
+	return id, nil
+}
+
+// Retry makes a dead job available again, with its attempts reset. It does nothing if the job isn't dead.
+func Retry(ctx context.Context, db dbclient.Querier, id int64) error {
+	_, err := dbclient.FromContext(ctx, db).Exec(ctx, retrySQL, id)
+	return errors.Wrapf(err, "retry job %d", id)
+}
@@ -0,0 +1,56 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	dbclient "github.com/HomesNZ/go-common/dbclient/v4"
+	"github.com/jackc/pgx/v4"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Enqueue", func() {
+	ctx := context.Background()
+
+	It("inserts the job with its options", func() {
+		runAt := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		db := &recordingRowDB{id: 7}
+		id, err := Enqueue(ctx, db, "send_email", map[string]string{"to": "a@b.c"},
+			WithQueue("emails"), WithPriority(10), WithUniqueKey("welcome:1"), WithMaxAttempts(3), WithRunAt(runAt))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(id).To(Equal(int64(7)))
+
+		key := "welcome:1"
+		Expect(db.args).To(Equal([]interface{}{"emails", "send_email", `{"to":"a@b.c"}`, 10, &key, 3, &runAt}))
+	})
+
+	It("returns ErrDuplicate when the unique key conflicts", func() {
+		_, err := Enqueue(ctx, &recordingRowDB{}, "send_email", nil, WithUniqueKey("welcome:1"))
+		Expect(err).To(Equal(ErrDuplicate))
+	})
+})
+
+// recordingRowDB records the args of QueryRow, and returns a row scanning id, or no rows if id is 0.
+type recordingRowDB struct {
+	dbclient.Querier
+	id   int64
+	args []interface{}
+}
+
+func (db *recordingRowDB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	db.args = args
+	return fakeRow{id: db.id}
+}
+
+type fakeRow struct {
+	id int64
+}
+
+func (r fakeRow) Scan(dest ...interface{}) error {
+	if r.id == 0 {
+		return pgx.ErrNoRows
+	}
+	*dest[0].(*int64) = r.id
+	return nil
+}
@@ -0,0 +1,67 @@
+package jobs
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Migrations holds the migration which creates the jobs and job_schedules tables, named the way the migrate package
+// expects. Copy it into a service's migrations directory, renumbered to follow its latest migration.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+// State is the state of a job. Jobs are deleted once they're handled successfully.
+type State string
+
+const (
+	// StateAvailable is a job waiting for its run time, or to be claimed by a worker.
+	StateAvailable = State("available")
+	// StateRunning is a job claimed by a worker.
+	StateRunning = State("running")
+	// StateDead is a job which failed on each of its attempts. It stays in the table until it's retried with Retry or
+	// deleted.
+	StateDead = State("dead")
+)
+
+// DefaultQueue is the queue jobs are enqueued on and claimed from unless another is configured.
+const DefaultQueue = "default"
+
+// Job is a job claimed by a worker.
+type Job struct {
+	ID          int64
+	Queue       string
+	Kind        string
+	Payload     json.RawMessage
+	Priority    int
+	UniqueKey   string
+	Attempt     int // - is the attempt being made, starting at 1
+	MaxAttempts int
+	RunAt       time.Time
+	CreatedAt   time.Time
+
+	lockedAt time.Time
+}
+
+// Unmarshal decodes the job's JSON payload into v.
+func (j Job) Unmarshal(v interface{}) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// Handler handles a batch of jobs claimed by a Worker. If it returns nil the jobs are done and deleted, if it returns
+// FailedJobs only those jobs are retried, and if it returns any other error all of the jobs are retried.
+type Handler func(ctx context.Context, jobs []Job) error
+
+// Notifier is called with errors which occur while jobs are claimed, handled and completed.
+type Notifier func(err error, rawData ...interface{})
+
+// FailedJobs can be returned by a Handler when only some of the jobs failed. It maps the ID of each failed job to its
+// error, which is stored as the job's last_error; the other jobs are done and deleted.
+type FailedJobs map[int64]error
+
+func (f FailedJobs) Error() string {
+	return fmt.Sprintf("%d jobs failed", len(f))
+}
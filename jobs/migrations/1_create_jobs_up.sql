@@ -0,0 +1,25 @@
+CREATE TABLE jobs (
+  id           bigserial PRIMARY KEY,
+  queue        text        NOT NULL DEFAULT 'default',
+  kind         text        NOT NULL,
+  payload      jsonb       NOT NULL DEFAULT '{}',
+  priority     integer     NOT NULL DEFAULT 0,
+  unique_key   text,
+  state        text        NOT NULL DEFAULT 'available' CHECK (state IN ('available', 'running', 'dead')),
+  attempts     integer     NOT NULL DEFAULT 0,
+  max_attempts integer     NOT NULL DEFAULT 25,
+  run_at       timestamptz NOT NULL DEFAULT now(),
+  last_error   text,
+  locked_at    timestamptz,
+  created_at   timestamptz NOT NULL DEFAULT now(),
+  updated_at   timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX jobs_claim_idx ON jobs (queue, priority DESC, run_at) WHERE state = 'available';
+CREATE INDEX jobs_running_idx ON jobs (locked_at) WHERE state = 'running';
+CREATE UNIQUE INDEX jobs_unique_key_idx ON jobs (unique_key) WHERE unique_key IS NOT NULL AND state IN ('available', 'running');
+
+CREATE TABLE job_schedules (
+  name        text PRIMARY KEY,
+  next_run_at timestamptz NOT NULL
+);
@@ -0,0 +1,205 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	dbclient "github.com/HomesNZ/go-common/dbclient/v4"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultCheckInterval is how often a Scheduler checks for recurring jobs which are due.
+const DefaultCheckInterval = 15 * time.Second
+
+// registerScheduleSQL stores a schedule's next run time, moving it earlier if the schedule has changed to run sooner.
+const registerScheduleSQL = `INSERT INTO job_schedules (name, next_run_at) VALUES ($1, $2)
+ON CONFLICT (name) DO UPDATE SET next_run_at = LEAST(job_schedules.next_run_at, EXCLUDED.next_run_at)`
+
+// dueScheduleSQL locks a due schedule, skipping it if another scheduler has it locked.
+const dueScheduleSQL = `SELECT next_run_at FROM job_schedules WHERE name = $1 AND next_run_at <= now()
+FOR UPDATE SKIP LOCKED`
+
+const updateScheduleSQL = `UPDATE job_schedules SET next_run_at = $2 WHERE name = $1`
+
+// DB runs queries and begins transactions, e.g. a *pgxpool.Pool.
+type DB interface {
+	dbclient.Querier
+	dbclient.TxBeginner
+}
+
+// SchedulerOption configures a Scheduler.
+type SchedulerOption func(*Scheduler)
+
+// WithCheckInterval sets how often the scheduler checks for recurring jobs which are due. It must be positive. Defaults
+// to DefaultCheckInterval.
+func WithCheckInterval(d time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.interval = d
+	}
+}
+
+// WithLocation sets the location cron expressions are evaluated in. Defaults to UTC.
+func WithLocation(loc *time.Location) SchedulerOption {
+	return func(s *Scheduler) {
+		s.location = loc
+	}
+}
+
+// WithSchedulerLogger sets the logger for the scheduler.
+func WithSchedulerLogger(log logrus.FieldLogger) SchedulerOption {
+	return func(s *Scheduler) {
+		s.log = log
+	}
+}
+
+type entry struct {
+	name     string
+	schedule Schedule
+	kind     string
+	payload  interface{}
+	opts     []EnqueueOption
+}
+
+// Scheduler enqueues recurring jobs. The next run time of each recurring job is stored in the job_schedules table, so
+// any number of instances can run a Scheduler and each run is only enqueued once. Runs missed while no scheduler was
+// running are skipped, apart from the latest.
+//
+//	scheduler := jobs.NewScheduler(pool)
+//	scheduler.MustRegister("expire-listings", "0 3 * * *", "expire_listings", nil)
+//	scheduler.Start(ctx)
+//	defer scheduler.Stop()
+type Scheduler struct {
+	db       DB
+	interval time.Duration
+	location *time.Location
+	log      logrus.FieldLogger
+	entries  []entry
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScheduler returns a Scheduler which enqueues jobs using db.
+func NewScheduler(db DB, options ...SchedulerOption) *Scheduler {
+	s := &Scheduler{
+		db:       db,
+		interval: DefaultCheckInterval,
+		location: time.UTC,
+		log:      logrus.WithField("package", "jobs"),
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+	return s
+}
+
+// Register adds a recurring job named name, which enqueues a job of kind with payload on the cron schedule spec (see
+// ParseCron). Names must be unique, and stable between deploys. It must be called before Start.
+func (s *Scheduler) Register(name, spec, kind string, payload interface{}, opts ...EnqueueOption) error {
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return errors.Wrapf(err, "register %s", name)
+	}
+	if schedule.Next(time.Now().In(s.location)).IsZero() {
+		return errors.Errorf("register %s: cron spec %q never runs", name, spec)
+	}
+	s.RegisterSchedule(name, schedule, kind, payload, opts...)
+	return nil
+}
+
+// MustRegister is like Register but panics if spec is invalid.
+func (s *Scheduler) MustRegister(name, spec, kind string, payload interface{}, opts ...EnqueueOption) {
+	if err := s.Register(name, spec, kind, payload, opts...); err != nil {
+		panic(err)
+	}
+}
+
+// RegisterSchedule is like Register, with a Schedule instead of a cron expression.
+func (s *Scheduler) RegisterSchedule(name string, schedule Schedule, kind string, payload interface{}, opts ...EnqueueOption) {
+	s.entries = append(s.entries, entry{
+		name:     name,
+		schedule: schedule,
+		kind:     kind,
+		payload:  payload,
+		opts:     opts,
+	})
+}
+
+// Start stores the registered schedules, then checks for due jobs in the background until ctx is done or Stop is
+// called.
+func (s *Scheduler) Start(ctx context.Context) error {
+	if s.interval <= 0 {
+		return errors.Errorf("check interval must be positive, got %s", s.interval)
+	}
+	now := time.Now().In(s.location)
+	for _, e := range s.entries {
+		if _, err := s.db.Exec(ctx, registerScheduleSQL, e.name, e.schedule.Next(now)); err != nil {
+			return errors.Wrapf(err, "register schedule %s", e.name)
+		}
+	}
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			s.Check(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops checking for due jobs.
+func (s *Scheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+}
+
+// Check enqueues the recurring jobs which are due. Start calls it periodically.
+func (s *Scheduler) Check(ctx context.Context) {
+	for _, e := range s.entries {
+		if err := s.check(ctx, e); err != nil && ctx.Err() == nil {
+			s.log.WithError(err).WithField("schedule", e.name).Error("failed to enqueue recurring job")
+		}
+	}
+}
+
+func (s *Scheduler) check(ctx context.Context, e entry) error {
+	return dbclient.WithTx(ctx, s.db, dbclient.TxOptions{}, func(tx pgx.Tx) error {
+		var due time.Time
+		err := tx.QueryRow(ctx, dueScheduleSQL, e.name).Scan(&due)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "lock schedule")
+		}
+
+		// The job is enqueued in the same transaction as the schedule is updated, so each run is enqueued once.
+		opts := append([]EnqueueOption{WithRunAt(due)}, e.opts...)
+		_, err = Enqueue(ctx, tx, e.kind, e.payload, opts...)
+		if err != nil && !errors.Is(err, ErrDuplicate) {
+			return err
+		}
+
+		next := e.schedule.Next(due.In(s.location))
+		if now := time.Now().In(s.location); !next.After(now) {
+			next = e.schedule.Next(now)
+		}
+		_, err = tx.Exec(ctx, updateScheduleSQL, e.name, next)
+		return errors.Wrap(err, "update schedule")
+	})
+}
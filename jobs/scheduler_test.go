@@ -0,0 +1,101 @@
+package jobs
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// valuesRow scans its values by assignment, or returns pgx.ErrNoRows if it has none.
+type valuesRow []interface{}
+
+func (r valuesRow) Scan(dest ...interface{}) error {
+	if len(r) == 0 {
+		return pgx.ErrNoRows
+	}
+	for i, d := range dest {
+		reflect.ValueOf(d).Elem().Set(reflect.ValueOf(r[i]))
+	}
+	return nil
+}
+
+// schedulerTx returns rows for each QueryRow in turn, and records its Execs.
+type schedulerTx struct {
+	pgx.Tx
+	rows      []valuesRow
+	execs     []fakeQuery
+	committed bool
+}
+
+func (tx *schedulerTx) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	row := tx.rows[0]
+	tx.rows = tx.rows[1:]
+	return row
+}
+
+func (tx *schedulerTx) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	tx.execs = append(tx.execs, fakeQuery{sql, args})
+	return pgconn.CommandTag("UPDATE 1"), nil
+}
+
+func (tx *schedulerTx) Commit(ctx context.Context) error {
+	tx.committed = true
+	return nil
+}
+
+func (tx *schedulerTx) Rollback(ctx context.Context) error {
+	return nil
+}
+
+type schedulerDB struct {
+	fakeDB
+	tx *schedulerTx
+}
+
+func (db *schedulerDB) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	return db.tx, nil
+}
+
+var _ = Describe("Scheduler", func() {
+	ctx := context.Background()
+
+	It("rejects invalid and impossible specs", func() {
+		s := NewScheduler(&schedulerDB{})
+		Expect(s.Register("bad", "* * *", "kind", nil)).NotTo(Succeed())
+		Expect(s.Register("never", "0 0 31 2 *", "kind", nil)).NotTo(Succeed())
+	})
+
+	It("rejects a check interval which isn't positive", func() {
+		s := NewScheduler(&schedulerDB{}, WithCheckInterval(0))
+		Expect(s.Start(ctx)).NotTo(Succeed())
+	})
+
+	It("enqueues a due job and schedules the next run", func() {
+		due := time.Now().Add(-time.Minute).Truncate(time.Minute)
+		db := &schedulerDB{tx: &schedulerTx{rows: []valuesRow{{due}, {int64(1)}}}}
+		s := NewScheduler(db)
+		s.RegisterSchedule("sync", Every(time.Hour), "sync_listings", nil)
+
+		s.Check(ctx)
+		Expect(db.tx.rows).To(BeEmpty())
+		Expect(db.tx.committed).To(BeTrue())
+		Expect(db.tx.execs).To(HaveLen(1))
+		Expect(db.tx.execs[0].sql).To(Equal(updateScheduleSQL))
+		Expect(db.tx.execs[0].args[0]).To(Equal("sync"))
+		Expect(db.tx.execs[0].args[1]).To(Equal(Every(time.Hour).Next(time.Now().UTC())))
+	})
+
+	It("does nothing when the job isn't due", func() {
+		db := &schedulerDB{tx: &schedulerTx{rows: []valuesRow{{}}}}
+		s := NewScheduler(db)
+		s.RegisterSchedule("sync", Every(time.Hour), "sync_listings", nil)
+
+		s.Check(ctx)
+		Expect(db.tx.execs).To(BeEmpty())
+	})
+})
@@ -0,0 +1,357 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	dbclient "github.com/HomesNZ/go-common/dbclient/v4"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultBatchSize is the most jobs a worker claims and passes to its handler at once.
+	DefaultBatchSize = 10
+	// DefaultPollInterval is how long a worker waits before claiming again when there are no available jobs.
+	DefaultPollInterval = time.Second
+	// DefaultMinBackoff is the delay before a failed job's first retry, which doubles on each failed attempt.
+	DefaultMinBackoff = time.Second
+	// DefaultMaxBackoff is the longest delay between retries of a failed job.
+	DefaultMaxBackoff = time.Hour
+	// DefaultLockTimeout is how long a job can be running before it's assumed its worker died, and it's retried.
+	DefaultLockTimeout = 30 * time.Minute
+)
+
+// claimSQL claims the available jobs due to run, skipping the ones being claimed by other workers.
+const claimSQL = `UPDATE jobs SET state = 'running', attempts = attempts + 1, locked_at = now(), updated_at = now()
+WHERE id IN (
+  SELECT id FROM jobs
+  WHERE queue = $1 AND state = 'available' AND run_at <= now()
+  ORDER BY priority DESC, run_at, id
+  LIMIT $2
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, queue, kind, payload, priority, unique_key, attempts, max_attempts, run_at, created_at, locked_at`
+
+// completeSQL deletes jobs which are still locked by the claim at $2. A job which was rescued after the lock timeout
+// and claimed again belongs to the other claim, and is left alone.
+const completeSQL = `DELETE FROM jobs WHERE id = ANY($1) AND state = 'running' AND locked_at = $2`
+
+// failSQL makes failed jobs available after a backoff of $3 milliseconds doubled for each attempt, up to $4
+// milliseconds, or dead if they've been attempted max_attempts times.
+const failSQL = `UPDATE jobs SET
+  state = CASE WHEN attempts >= max_attempts THEN 'dead' ELSE 'available' END,
+  run_at = now() + LEAST($3::float8 * power(2, attempts - 1), $4::float8) * interval '1 millisecond',
+  last_error = failed.message, locked_at = NULL, updated_at = now()
+FROM unnest($1::bigint[], $2::text[]) AS failed(job_id, message)
+WHERE jobs.id = failed.job_id AND jobs.state = 'running' AND jobs.locked_at = $5`
+
+// rescueSQL fails jobs which have been running for longer than $1 milliseconds.
+const rescueSQL = `UPDATE jobs SET
+  state = CASE WHEN attempts >= max_attempts THEN 'dead' ELSE 'available' END,
+  last_error = 'lock timed out', locked_at = NULL, updated_at = now()
+WHERE state = 'running' AND locked_at < now() - $1::float8 * interval '1 millisecond'`
+
+// WorkerOption configures a Worker.
+type WorkerOption func(*Worker)
+
+// WithWorkerQueue sets the queue the worker claims jobs from. Defaults to DefaultQueue.
+func WithWorkerQueue(queue string) WorkerOption {
+	return func(w *Worker) {
+		w.queue = queue
+	}
+}
+
+// WithBatchSize sets the most jobs claimed and passed to the handler at once. Defaults to DefaultBatchSize.
+func WithBatchSize(n int) WorkerOption {
+	return func(w *Worker) {
+		w.batchSize = n
+	}
+}
+
+// WithConcurrency sets how many batches are handled in parallel. Defaults to 1.
+func WithConcurrency(n int) WorkerOption {
+	return func(w *Worker) {
+		w.concurrency = n
+	}
+}
+
+// WithPollInterval sets how long the worker waits before claiming again when there are no available jobs. Defaults
+// to DefaultPollInterval.
+func WithPollInterval(d time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.pollInterval = d
+	}
+}
+
+// WithBackoff sets the delay before a failed job's first retry and the longest delay between retries. Defaults to
+// DefaultMinBackoff and DefaultMaxBackoff.
+func WithBackoff(min, max time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.minBackoff = min
+		w.maxBackoff = max
+	}
+}
+
+// WithLockTimeout sets how long a job can be running before it's assumed its worker died, and it's retried. It must
+// be positive, and longer than the handler takes to handle a batch. Defaults to DefaultLockTimeout.
+func WithLockTimeout(d time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.lockTimeout = d
+	}
+}
+
+// WithLogger sets the logger for the worker.
+func WithLogger(log logrus.FieldLogger) WorkerOption {
+	return func(w *Worker) {
+		w.log = log
+	}
+}
+
+// WithNotifier sets a func which is called with the errors the worker logs, e.g. to report them to bugsnag.
+func WithNotifier(notifier Notifier) WorkerOption {
+	return func(w *Worker) {
+		w.notifier = notifier
+	}
+}
+
+// Worker claims jobs from a queue and passes them to a Handler.
+//
+//	worker, err := jobs.NewWorker(pool, func(ctx context.Context, batch []jobs.Job) error {
+//		failed := jobs.FailedJobs{}
+//		for _, job := range batch {
+//			if err := handle(ctx, job); err != nil {
+//				failed[job.ID] = err
+//			}
+//		}
+//		if len(failed) > 0 {
+//			return failed
+//		}
+//		return nil
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	worker.Start(ctx)
+//	defer worker.Stop()
+type Worker struct {
+	db           dbclient.Querier
+	handler      Handler
+	queue        string
+	batchSize    int
+	concurrency  int
+	pollInterval time.Duration
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	lockTimeout  time.Duration
+	log          logrus.FieldLogger
+	notifier     Notifier
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWorker returns a Worker which claims jobs using db, e.g. a *pgxpool.Pool, and passes them to handler. It returns
+// an error if an option is out of range.
+func NewWorker(db dbclient.Querier, handler Handler, options ...WorkerOption) (*Worker, error) {
+	w := &Worker{
+		db:           db,
+		handler:      handler,
+		queue:        DefaultQueue,
+		batchSize:    DefaultBatchSize,
+		concurrency:  1,
+		pollInterval: DefaultPollInterval,
+		minBackoff:   DefaultMinBackoff,
+		maxBackoff:   DefaultMaxBackoff,
+		lockTimeout:  DefaultLockTimeout,
+		log:          logrus.WithField("package", "jobs"),
+	}
+	for _, opt := range options {
+		opt(w)
+	}
+	if err := w.validate(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Worker) validate() error {
+	switch {
+	case w.batchSize < 1:
+		return errors.Errorf("batch size must be at least 1, got %d", w.batchSize)
+	case w.concurrency < 1:
+		return errors.Errorf("concurrency must be at least 1, got %d", w.concurrency)
+	case w.pollInterval <= 0:
+		return errors.Errorf("poll interval must be positive, got %s", w.pollInterval)
+	case w.lockTimeout <= 0:
+		return errors.Errorf("lock timeout must be positive, got %s", w.lockTimeout)
+	case w.minBackoff <= 0 || w.maxBackoff < w.minBackoff:
+		return errors.Errorf("backoff must be positive with max at least min, got %s and %s", w.minBackoff, w.maxBackoff)
+	}
+	return nil
+}
+
+// Start starts claiming and handling jobs in the background until ctx is done or Stop is called.
+func (w *Worker) Start(ctx context.Context) {
+	ctx, w.cancel = context.WithCancel(ctx)
+	w.log.WithField("queue", w.queue).Info("starting jobs worker")
+
+	w.wg.Add(w.concurrency + 1)
+	for i := 0; i < w.concurrency; i++ {
+		go func() {
+			defer w.wg.Done()
+			w.work(ctx)
+		}()
+	}
+	go func() {
+		defer w.wg.Done()
+		w.rescue(ctx)
+	}()
+}
+
+// Stop stops claiming jobs, and waits for the batches being handled to finish.
+func (w *Worker) Stop() {
+	if w.cancel == nil {
+		return
+	}
+	w.cancel()
+	w.wg.Wait()
+	w.log.WithField("queue", w.queue).Info("stopped jobs worker")
+}
+
+func (w *Worker) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := w.Work(ctx)
+		if err != nil && ctx.Err() == nil {
+			w.error(err, "failed to work jobs")
+		}
+		if n > 0 && err == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(w.pollInterval):
+		}
+	}
+}
+
+// Work claims a batch of jobs and handles it, returning the number of jobs claimed. Start calls it in a loop, it's
+// exported so tests and one-off commands can work a queue synchronously.
+func (w *Worker) Work(ctx context.Context) (int, error) {
+	jobs, err := w.claim(ctx)
+	if err != nil || len(jobs) == 0 {
+		return 0, err
+	}
+
+	failed := map[int64]error{}
+	if err := w.handle(ctx, jobs); err != nil {
+		var failedJobs FailedJobs
+		if errors.As(err, &failedJobs) {
+			for id, jobErr := range failedJobs {
+				if jobErr == nil {
+					jobErr = errors.New("job failed")
+				}
+				failed[id] = jobErr
+			}
+		} else {
+			for _, job := range jobs {
+				failed[job.ID] = err
+			}
+			w.error(err, "failed to handle jobs")
+		}
+	}
+
+	done := make([]int64, 0, len(jobs))
+	retry := make([]int64, 0, len(failed))
+	lastErrors := make([]string, 0, len(failed))
+	for _, job := range jobs {
+		if err, ok := failed[job.ID]; ok {
+			retry = append(retry, job.ID)
+			lastErrors = append(lastErrors, err.Error())
+		} else {
+			done = append(done, job.ID)
+		}
+	}
+	// The batch was claimed, so it's completed even if ctx is done; otherwise it would wait for the lock timeout.
+	// Each job in the batch was locked by the same statement, so they share locked_at.
+	completeCtx := context.Background()
+	lockedAt := jobs[0].lockedAt
+	if len(done) > 0 {
+		if _, err := w.db.Exec(completeCtx, completeSQL, done, lockedAt); err != nil {
+			return len(jobs), errors.Wrap(err, "complete jobs")
+		}
+	}
+	if len(retry) > 0 {
+		_, err := w.db.Exec(completeCtx, failSQL, retry, lastErrors, float64(w.minBackoff.Milliseconds()),
+			float64(w.maxBackoff.Milliseconds()), lockedAt)
+		if err != nil {
+			return len(jobs), errors.Wrap(err, "fail jobs")
+		}
+	}
+	return len(jobs), nil
+}
+
+// handle calls the handler, recovering a panic as an error so that the jobs are retried.
+func (w *Worker) handle(ctx context.Context, jobs []Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("panic: %v", r)
+		}
+	}()
+	return w.handler(ctx, jobs)
+}
+
+func (w *Worker) claim(ctx context.Context) ([]Job, error) {
+	rows, err := w.db.Query(ctx, claimSQL, w.queue, w.batchSize)
+	if err != nil {
+		return nil, errors.Wrap(err, "claim jobs")
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		var job Job
+		var uniqueKey *string
+		err := rows.Scan(&job.ID, &job.Queue, &job.Kind, &job.Payload, &job.Priority, &uniqueKey, &job.Attempt,
+			&job.MaxAttempts, &job.RunAt, &job.CreatedAt, &job.lockedAt)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan job")
+		}
+		if uniqueKey != nil {
+			job.UniqueKey = *uniqueKey
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, errors.Wrap(rows.Err(), "claim jobs")
+}
+
+// rescue periodically retries jobs which have been running for longer than the lock timeout.
+func (w *Worker) rescue(ctx context.Context) {
+	ticker := time.NewTicker(w.lockTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			tag, err := w.db.Exec(ctx, rescueSQL, float64(w.lockTimeout.Milliseconds()))
+			if err != nil {
+				if ctx.Err() == nil {
+					w.error(err, "failed to rescue jobs")
+				}
+				continue
+			}
+			if tag.RowsAffected() > 0 {
+				w.log.WithField("jobs", tag.RowsAffected()).Warn("rescued jobs which timed out")
+			}
+		}
+	}
+}
+
+func (w *Worker) error(err error, msg string) {
+	w.log.WithError(err).WithField("queue", w.queue).Error(msg)
+	if w.notifier != nil {
+		w.notifier(errors.Wrap(err, msg))
+	}
+}
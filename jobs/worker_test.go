@@ -0,0 +1,198 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+
+	dbclient "github.com/HomesNZ/go-common/dbclient/v4"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeRows returns fixed rows, scanning by assignment. A nil value scans as the zero value.
+type fakeRows struct {
+	pgx.Rows
+	values [][]interface{}
+	row    int
+}
+
+func (r *fakeRows) Next() bool {
+	r.row++
+	return r.row <= len(r.values)
+}
+
+func (r *fakeRows) Scan(dest ...interface{}) error {
+	for i, d := range dest {
+		v := reflect.ValueOf(d).Elem()
+		if value := r.values[r.row-1][i]; value != nil {
+			v.Set(reflect.ValueOf(value))
+		} else {
+			v.Set(reflect.Zero(v.Type()))
+		}
+	}
+	return nil
+}
+
+func (r *fakeRows) Err() error { return nil }
+func (r *fakeRows) Close()     {}
+
+type fakeQuery struct {
+	sql  string
+	args []interface{}
+}
+
+// fakeDB records the queries it runs, and returns rows for each Query in turn.
+type fakeDB struct {
+	dbclient.Querier
+	execs   []fakeQuery
+	queries []fakeQuery
+	rows    []*fakeRows
+}
+
+func (db *fakeDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	db.execs = append(db.execs, fakeQuery{sql, args})
+	return pgconn.CommandTag("UPDATE 1"), nil
+}
+
+func (db *fakeDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	db.queries = append(db.queries, fakeQuery{sql, args})
+	if len(db.rows) == 0 {
+		return &fakeRows{}, nil
+	}
+	rows := db.rows[0]
+	db.rows = db.rows[1:]
+	return rows, nil
+}
+
+var lockedAt = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func jobRow(id int64) []interface{} {
+	now := time.Now()
+	return []interface{}{id, DefaultQueue, "send_email", []byte(`{"to":"a@b.c"}`), 0, nil, 1, 25, now, now, lockedAt}
+}
+
+func newWorker(db *fakeDB, handler Handler, options ...WorkerOption) *Worker {
+	w, err := NewWorker(db, handler, options...)
+	Expect(err).NotTo(HaveOccurred())
+	return w
+}
+
+var _ = Describe("Worker", func() {
+	var (
+		db  *fakeDB
+		ctx = context.Background()
+	)
+
+	BeforeEach(func() {
+		db = &fakeDB{rows: []*fakeRows{{values: [][]interface{}{jobRow(1), jobRow(2)}}}}
+	})
+
+	It("claims a batch from its queue", func() {
+		var handled []Job
+		w := newWorker(db, func(ctx context.Context, jobs []Job) error {
+			handled = jobs
+			return nil
+		}, WithWorkerQueue("emails"), WithBatchSize(5))
+
+		n, err := w.Work(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(2))
+		Expect(db.queries[0].sql).To(ContainSubstring("FOR UPDATE SKIP LOCKED"))
+		Expect(db.queries[0].args).To(Equal([]interface{}{"emails", 5}))
+
+		Expect(handled).To(HaveLen(2))
+		Expect(handled[0].Kind).To(Equal("send_email"))
+		Expect(handled[0].Attempt).To(Equal(1))
+		var payload struct{ To string }
+		Expect(handled[0].Unmarshal(&payload)).To(Succeed())
+		Expect(payload.To).To(Equal("a@b.c"))
+	})
+
+	It("deletes the jobs when the handler succeeds", func() {
+		w := newWorker(db, func(ctx context.Context, jobs []Job) error { return nil })
+		_, err := w.Work(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(db.execs).To(HaveLen(1))
+		Expect(db.execs[0]).To(Equal(fakeQuery{completeSQL, []interface{}{[]int64{1, 2}, lockedAt}}))
+	})
+
+	It("retries all of the jobs with backoff when the handler fails", func() {
+		w := newWorker(db, func(ctx context.Context, jobs []Job) error {
+			return errors.New("smtp down")
+		}, WithBackoff(time.Second, time.Minute))
+		_, err := w.Work(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(db.execs).To(HaveLen(1))
+		Expect(db.execs[0]).To(Equal(fakeQuery{failSQL, []interface{}{[]int64{1, 2}, []string{"smtp down", "smtp down"}, 1000.0, 60000.0, lockedAt}}))
+	})
+
+	It("only retries FailedJobs, with their own errors", func() {
+		w := newWorker(db, func(ctx context.Context, jobs []Job) error {
+			return FailedJobs{2: errors.New("mailbox full")}
+		})
+		_, err := w.Work(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(db.execs).To(HaveLen(2))
+		Expect(db.execs[0].args[0]).To(Equal([]int64{1}))
+		Expect(db.execs[1].sql).To(Equal(failSQL))
+		Expect(db.execs[1].args[0]).To(Equal([]int64{2}))
+		Expect(db.execs[1].args[1]).To(Equal([]string{"mailbox full"}))
+	})
+
+	It("stores each failed job's error", func() {
+		w := newWorker(db, func(ctx context.Context, jobs []Job) error {
+			return FailedJobs{1: errors.New("bounced"), 2: nil}
+		})
+		_, err := w.Work(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(db.execs).To(HaveLen(1))
+		Expect(db.execs[0]).To(Equal(fakeQuery{failSQL, []interface{}{[]int64{1, 2}, []string{"bounced", "job failed"}, float64(DefaultMinBackoff.Milliseconds()), float64(DefaultMaxBackoff.Milliseconds()), lockedAt}}))
+	})
+
+	It("retries the jobs when the handler panics", func() {
+		var notified error
+		w := newWorker(db, func(ctx context.Context, jobs []Job) error {
+			panic("boom")
+		}, WithNotifier(func(err error, rawData ...interface{}) {
+			notified = err
+		}))
+		_, err := w.Work(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(notified).To(MatchError("failed to handle jobs: panic: boom"))
+		Expect(db.execs[0].sql).To(Equal(failSQL))
+	})
+
+	It("does nothing when there are no jobs", func() {
+		db.rows = nil
+		w := newWorker(db, func(ctx context.Context, jobs []Job) error {
+			Fail("handler called")
+			return nil
+		})
+		n, err := w.Work(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(BeZero())
+		Expect(db.execs).To(BeEmpty())
+	})
+})
+
+var _ = Describe("NewWorker", func() {
+	handler := func(ctx context.Context, jobs []Job) error { return nil }
+
+	It("rejects options out of range", func() {
+		for _, opt := range []WorkerOption{
+			WithBatchSize(0),
+			WithConcurrency(0),
+			WithPollInterval(0),
+			WithLockTimeout(0),
+			WithBackoff(0, time.Second),
+			WithBackoff(time.Minute, time.Second),
+		} {
+			_, err := NewWorker(&fakeDB{}, handler, opt)
+			Expect(err).To(HaveOccurred())
+		}
+	})
+})
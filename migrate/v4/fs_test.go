@@ -0,0 +1,121 @@
+package migrate
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fsMigrator(fsys fstest.MapFS, options ...Option) *migrator {
+	m := &migrator{
+		migrations: make(map[uint64]*Migration),
+		logger:     log.New(ioutil.Discard, "", 0),
+		fsys:       fsys,
+	}
+	for _, opt := range options {
+		opt(m)
+	}
+	return m
+}
+
+func TestFetchMigrationsFromFS(t *testing.T) {
+	m := fsMigrator(fstest.MapFS{
+		"001_create_listings_up.sql":   {Data: []byte("CREATE TABLE listings ();")},
+		"001_create_listings_down.sql": {Data: []byte("DROP TABLE listings;")},
+		"002_add-index_up.sql":         {Data: []byte("CREATE INDEX ...;")},
+		"002_add-index_down.sql":       {Data: []byte("DROP INDEX ...;")},
+		"README.md":                    {Data: []byte("ignored")},
+		"003_backup_up.sql.orig":       {Data: []byte("ignored")},
+	})
+	assert.NoError(t, m.fetchMigrations())
+
+	migrations := m.Migrations(-1)
+	assert.Len(t, migrations, 2)
+	assert.Equal(t, &Migration{
+		Id:       1,
+		Name:     "create_listings",
+		Status:   Inactive,
+		UpPath:   "001_create_listings_up.sql",
+		DownPath: "001_create_listings_down.sql",
+	}, migrations[0])
+	assert.Equal(t, "add-index", migrations[1].Name)
+}
+
+func TestFetchMigrationsFromDir(t *testing.T) {
+	m := fsMigrator(nil)
+	m.fsys = os.DirFS("test_migrations")
+	assert.NoError(t, m.fetchMigrations())
+	assert.Len(t, m.Migrations(-1), 3)
+	assert.Equal(t, "1_test_up.sql", m.Migrations(-1)[0].UpPath)
+}
+
+func TestFetchMigrationsInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		options []Option
+		want    error
+	}{
+		{
+			name: "duplicate up migrations",
+			fsys: fstest.MapFS{
+				"1_a_up.sql":   {},
+				"1_a_down.sql": {},
+				"01_a_up.sql":  {},
+			},
+			want: DuplicateMigration,
+		},
+		{
+			name: "two migrations with the same id",
+			fsys: fstest.MapFS{
+				"1_a_up.sql":   {},
+				"1_a_down.sql": {},
+				"1_b_up.sql":   {},
+				"1_b_down.sql": {},
+			},
+			want: DuplicateMigration,
+		},
+		{
+			name: "missing down migration",
+			fsys: fstest.MapFS{
+				"1_a_up.sql": {},
+			},
+			want: InvalidMigrationPair,
+		},
+		{
+			name: "gap between ids",
+			fsys: fstest.MapFS{
+				"1_a_up.sql":   {},
+				"1_a_down.sql": {},
+				"3_c_up.sql":   {},
+				"3_c_down.sql": {},
+			},
+			want: MissingMigration,
+		},
+		{
+			name: "gap between ids allowed",
+			fsys: fstest.MapFS{
+				"1_a_up.sql":   {},
+				"1_a_down.sql": {},
+				"3_c_up.sql":   {},
+				"3_c_down.sql": {},
+			},
+			options: []Option{WithAllowGaps()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fsMigrator(tt.fsys, tt.options...).fetchMigrations()
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, tt.want), "got %v, want %v", err, tt.want)
+		})
+	}
+}
@@ -1,13 +1,15 @@
 module github.com/HomesNZ/go-common/migrate/v4
 
-go 1.15
+go 1.16
 
 require (
 	github.com/HomesNZ/go-common/redis v0.0.0-20210628042012-06a0a5a35661
 	github.com/golang/mock v1.6.0
-	github.com/jackc/pgx/v4 v4.10.0
+	github.com/jackc/pgx/v4 v4.18.2
 	github.com/onsi/ginkgo v1.16.4
 	github.com/pkg/errors v0.9.1
 	github.com/sirupsen/logrus v1.8.1
-	github.com/stretchr/testify v1.7.0
+	github.com/stretchr/testify v1.8.1
 )
+
+replace github.com/HomesNZ/go-common/redis => ../../redis
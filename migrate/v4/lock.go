@@ -0,0 +1,145 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	pgx "github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	// DefaultLockTimeout is how long Migrate and Rollback wait for another instance to finish migrating.
+	DefaultLockTimeout = 10 * time.Minute
+	// lockPollInterval is how often the advisory lock is retried while another instance holds it.
+	lockPollInterval = time.Second
+)
+
+var LockTimeout = errors.New("Timed out waiting for the migration lock")
+
+// Locker locks migrations, so that only one instance runs them at a time. Lock blocks until the lock is acquired or
+// ctx is done, and returns a func which releases the lock. A redsync lock such as sqs_v2/lock.Redsync, with enough
+// tries to outlast a migration, can be used to lock with redis instead of Postgres.
+type Locker interface {
+	Lock(ctx context.Context, key string) (unlock func(), err error)
+}
+
+// WithLocker locks migrations and rollbacks with locker instead of a Postgres advisory lock.
+func WithLocker(locker Locker) Option {
+	return func(m *migrator) {
+		m.locker = locker
+	}
+}
+
+// WithLockTimeout sets how long Migrate and Rollback wait for another instance to finish migrating before returning
+// LockTimeout. Defaults to DefaultLockTimeout.
+func WithLockTimeout(d time.Duration) Option {
+	return func(m *migrator) {
+		m.lockTimeout = d
+	}
+}
+
+// lockConn is the part of *pgx.Conn an advisoryLocker uses.
+type lockConn interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	Close(ctx context.Context) error
+}
+
+// advisoryLocker locks with a session level Postgres advisory lock, held on a dedicated connection opened with the
+// pool's config and BeforeConnect hook, so that the pool's connections stay free for the migrations even when it only has one. The lock is
+// released by closing the connection, so it's also released if the process dies.
+type advisoryLocker struct {
+	connect      func(ctx context.Context) (lockConn, error)
+	logger       Logger
+	pollInterval time.Duration
+}
+
+func newAdvisoryLocker(pool *pgxpool.Pool, logger Logger) *advisoryLocker {
+	return &advisoryLocker{
+		connect: func(ctx context.Context) (lockConn, error) {
+			return connectLike(ctx, pool.Config(), pgx.ConnectConfig)
+		},
+		logger:       logger,
+		pollInterval: lockPollInterval,
+	}
+}
+
+// connectLike connects with the pool config cfg the way the pool does, calling its BeforeConnect hook first, so that
+// hooks which set the password, such as RDS IAM auth, also apply to the lock connection. cfg must be a copy, as
+// BeforeConnect may change its ConnConfig.
+func connectLike(ctx context.Context, cfg *pgxpool.Config, connect func(context.Context, *pgx.ConnConfig) (*pgx.Conn, error)) (lockConn, error) {
+	if cfg.BeforeConnect != nil {
+		if err := cfg.BeforeConnect(ctx, cfg.ConnConfig); err != nil {
+			return nil, err
+		}
+	}
+	conn, err := connect(ctx, cfg.ConnConfig)
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (l *advisoryLocker) Lock(ctx context.Context, key string) (func(), error) {
+	conn, err := l.connect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to lock migrations: %w", err)
+	}
+	unlock := func() {
+		if err := conn.Close(context.Background()); err != nil {
+			l.logger.Printf("Error releasing migration lock: %v", err)
+		}
+	}
+
+	id := advisoryLockID(key)
+	waiting := false
+	for {
+		var locked bool
+		if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", id).Scan(&locked); err != nil {
+			unlock()
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("error locking migrations: %w", err)
+		}
+		if locked {
+			return unlock, nil
+		}
+
+		if !waiting {
+			l.logger.Printf("Waiting for migration lock %s held by another instance", key)
+			waiting = true
+		}
+		select {
+		case <-ctx.Done():
+			unlock()
+			return nil, ctx.Err()
+		case <-time.After(l.pollInterval):
+		}
+	}
+}
+
+// advisoryLockID returns the advisory lock id for key.
+func advisoryLockID(key string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return int64(h.Sum64())
+}
+
+// lock acquires the migration lock, waiting up to the lock timeout.
+func (m *migrator) lock(ctx context.Context) (func(), error) {
+	lockCtx := ctx
+	if m.lockTimeout > 0 {
+		var cancel context.CancelFunc
+		lockCtx, cancel = context.WithTimeout(ctx, m.lockTimeout)
+		defer cancel()
+	}
+	unlock, err := m.locker.Lock(lockCtx, m.dbAdapter.SchemaName+migrationLock)
+	if err != nil && ctx.Err() == nil && lockCtx.Err() == context.DeadlineExceeded {
+		return nil, LockTimeout
+	}
+	return unlock, err
+}
@@ -0,0 +1,124 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"testing"
+	"time"
+
+	pgx "github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeLockConn returns each result of pg_try_advisory_lock in turn, then true.
+type fakeLockConn struct {
+	results []bool
+	tries   int
+	args    []interface{}
+	closed  bool
+}
+
+func (c *fakeLockConn) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	c.tries++
+	c.args = args
+	locked := true
+	if len(c.results) > 0 {
+		locked, c.results = c.results[0], c.results[1:]
+	}
+	return fakeLockRow(locked)
+}
+
+func (c *fakeLockConn) Close(ctx context.Context) error {
+	c.closed = true
+	return nil
+}
+
+type fakeLockRow bool
+
+func (r fakeLockRow) Scan(dest ...interface{}) error {
+	*dest[0].(*bool) = bool(r)
+	return nil
+}
+
+func fakeAdvisoryLocker(conn *fakeLockConn) *advisoryLocker {
+	return &advisoryLocker{
+		connect: func(ctx context.Context) (lockConn, error) {
+			return conn, nil
+		},
+		logger:       log.New(ioutil.Discard, "", 0),
+		pollInterval: time.Millisecond,
+	}
+}
+
+func TestAdvisoryLockWaits(t *testing.T) {
+	conn := &fakeLockConn{results: []bool{false, false}}
+	unlock, err := fakeAdvisoryLocker(conn).Lock(context.Background(), "test:migration-lock")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, conn.tries)
+	assert.Equal(t, []interface{}{advisoryLockID("test:migration-lock")}, conn.args)
+	assert.False(t, conn.closed)
+
+	unlock()
+	assert.True(t, conn.closed)
+}
+
+func TestAdvisoryLockConnectsWithBeforeConnect(t *testing.T) {
+	cfg, err := pgxpool.ParseConfig("host=db.internal user=app dbname=listings")
+	assert.NoError(t, err)
+	cfg.BeforeConnect = func(ctx context.Context, connConfig *pgx.ConnConfig) error {
+		connConfig.Password = "iam-token"
+		return nil
+	}
+
+	var password string
+	_, err = connectLike(context.Background(), cfg, func(ctx context.Context, connConfig *pgx.ConnConfig) (*pgx.Conn, error) {
+		password = connConfig.Password
+		return nil, errors.New("connection refused")
+	})
+	assert.EqualError(t, err, "connection refused")
+	assert.Equal(t, "iam-token", password)
+
+	cfg.BeforeConnect = func(ctx context.Context, connConfig *pgx.ConnConfig) error {
+		return errors.New("no credentials")
+	}
+	_, err = connectLike(context.Background(), cfg, func(ctx context.Context, connConfig *pgx.ConnConfig) (*pgx.Conn, error) {
+		t.Error("connected after BeforeConnect failed")
+		return nil, nil
+	})
+	assert.EqualError(t, err, "no credentials")
+}
+
+func TestAdvisoryLockTimeout(t *testing.T) {
+	conn := &fakeLockConn{results: make([]bool, 1000)}
+	m := &migrator{
+		dbAdapter:   Postgres{SchemaName: "test"},
+		locker:      fakeAdvisoryLocker(conn),
+		lockTimeout: 10 * time.Millisecond,
+	}
+	_, err := m.lock(context.Background())
+	assert.Equal(t, LockTimeout, err)
+	assert.True(t, conn.closed)
+}
+
+type fakeLocker struct {
+	key string
+	err error
+}
+
+func (l *fakeLocker) Lock(ctx context.Context, key string) (func(), error) {
+	l.key = key
+	return func() {}, l.err
+}
+
+func TestWithLocker(t *testing.T) {
+	locker := &fakeLocker{err: errors.New("redis down")}
+	m := &migrator{dbAdapter: Postgres{SchemaName: "test"}}
+	WithLocker(locker)(m)
+
+	_, err := m.lock(context.Background())
+	assert.EqualError(t, err, "redis down")
+	assert.Equal(t, "test:migration-lock", locker.key)
+}
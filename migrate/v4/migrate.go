@@ -4,11 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"io/fs"
 	"log"
 	"os"
-	"path/filepath"
 	"sort"
+	"time"
 
 	pgx "github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
@@ -21,7 +21,6 @@ const (
 	upMigration        = migrationType("up")
 	downMigration      = migrationType("down")
 	migrationLock      = ":migration-lock"
-	rollbackLock       = ":rollback-lock"
 	expirationTime     = 86400 //24 hrs
 )
 
@@ -30,11 +29,15 @@ var (
 	InvalidMigrationPair  = errors.New("Invalid pair of migration files")
 	InvalidMigrationsPath = errors.New("Invalid migrations path")
 	InvalidMigrationType  = errors.New("Invalid migration type")
+	DuplicateMigration    = errors.New("Duplicate migration id")
+	MissingMigration      = errors.New("Missing migration id")
 	NoActiveMigrations    = errors.New("No active migrations to rollback")
 )
 
 type Migrator interface {
+	// Deprecated: Migrate and Rollback lock with a Postgres advisory lock, or the Locker set with WithLocker.
 	Lock(key string) (bool, error)
+	// Deprecated: Migrate and Rollback lock with a Postgres advisory lock, or the Locker set with WithLocker.
 	Unlock(key string) error
 	MigrationTableExists(ctx context.Context) (bool, error)
 	CreateMigrationsTable(ctx context.Context) error
@@ -52,6 +55,28 @@ type migrator struct {
 	migrations     map[uint64]*Migration
 	logger         Logger
 	Redis          Redis
+	fsys           fs.FS
+	allowGaps      bool
+	locker         Locker
+	lockTimeout    time.Duration
+}
+
+// Option configures a migrator created with NewMigratorFS.
+type Option func(*migrator)
+
+// WithLogger sets the logger. Defaults to the standard logger, writing to stderr.
+func WithLogger(logger Logger) Option {
+	return func(m *migrator) {
+		m.logger = logger
+	}
+}
+
+// WithAllowGaps allows gaps between migration ids, e.g. 1, 2 and 4. By default a gap returns MissingMigration, as
+// it's usually a migration file which was left out of a merge or the embedded files.
+func WithAllowGaps() Option {
+	return func(m *migrator) {
+		m.allowGaps = true
+	}
 }
 
 type Logger interface {
@@ -119,12 +144,13 @@ func (m *migrator) CreateMigrationsTable(ctx context.Context) error {
 	return nil
 }
 
-// Returns a new migrator.
+// Returns a new migrator. Gaps between migration ids are allowed, see NewMigratorFS. Migrations are locked with a
+// Postgres advisory lock; redis is only used by the deprecated Lock and Unlock methods, and can be nil.
 func NewMigrator(ctx context.Context, db *pgxpool.Pool, adapter Postgres, migrationsPath string, redis Redis) (Migrator, error) {
 	return NewMigratorWithLogger(ctx, db, adapter, migrationsPath, redis, log.New(os.Stderr, "[gomigrate] ", log.LstdFlags))
 }
 
-// Returns a new migrator with the specified logger.
+// Returns a new migrator with the specified logger. Gaps between migration ids are allowed, see NewMigratorFS.
 func NewMigratorWithLogger(ctx context.Context, db *pgxpool.Pool, adapter Postgres, migrationsPath string, redis Redis, logger Logger) (Migrator, error) {
 	// Normalize the migrations path.
 	path := []byte(migrationsPath)
@@ -135,13 +161,50 @@ func NewMigratorWithLogger(ctx context.Context, db *pgxpool.Pool, adapter Postgr
 
 	logger.Printf("Migrations path: %s", path)
 
-	m := migrator{
-		DB:             db,
-		MigrationsPath: string(path),
-		dbAdapter:      adapter,
-		migrations:     make(map[uint64]*Migration),
-		Redis:          redis,
-		logger:         logger,
+	m, err := newMigrator(ctx, db, adapter, os.DirFS(string(path)), WithLogger(logger), WithAllowGaps())
+	if err != nil {
+		return nil, err
+	}
+	m.MigrationsPath = string(path)
+	m.Redis = redis
+	return m, nil
+}
+
+// NewMigratorFS returns a new migrator which loads the migrations in the root of fsys, named like 1_name_up.sql and
+// 1_name_down.sql. Migrations can be embedded in the binary:
+//
+//	//go:embed migrations/*.sql
+//	var migrations embed.FS
+//
+//	fsys, err := fs.Sub(migrations, "migrations")
+//	...
+//	m, err := migrate.NewMigratorFS(ctx, pool, migrate.Postgres{SchemaName: "public"}, fsys)
+//
+// It returns DuplicateMigration if two migrations have the same id, InvalidMigrationPair if a migration is missing
+// its up or down file, and MissingMigration if there's a gap between migration ids, unless WithAllowGaps is used.
+func NewMigratorFS(ctx context.Context, db *pgxpool.Pool, adapter Postgres, fsys fs.FS, options ...Option) (Migrator, error) {
+	return newMigrator(ctx, db, adapter, fsys, options...)
+}
+
+func newMigrator(ctx context.Context, db *pgxpool.Pool, adapter Postgres, fsys fs.FS, options ...Option) (*migrator, error) {
+	m := &migrator{
+		DB:          db,
+		dbAdapter:   adapter,
+		migrations:  make(map[uint64]*Migration),
+		logger:      log.New(os.Stderr, "[gomigrate] ", log.LstdFlags),
+		fsys:        fsys,
+		lockTimeout: DefaultLockTimeout,
+	}
+	for _, opt := range options {
+		opt(m)
+	}
+	if m.locker == nil {
+		m.locker = newAdvisoryLocker(db, m.logger)
+	}
+
+	// Load the migrations first, so that invalid migrations are reported before the database is touched.
+	if err := m.fetchMigrations(); err != nil {
+		return nil, err
 	}
 
 	// Create the migrations table if it doesn't exist.
@@ -156,23 +219,18 @@ func NewMigratorWithLogger(ctx context.Context, db *pgxpool.Pool, adapter Postgr
 	}
 
 	// Get all metadata from the database.
-	if err := m.fetchMigrations(); err != nil {
-		return nil, err
-	}
 	if err := m.getMigrationStatuses(ctx); err != nil {
 		return nil, err
 	}
 
-	return &m, nil
+	return m, nil
 }
 
 // Populates a migrator with a sorted list of migrations from the file system.
 func (m *migrator) fetchMigrations() error {
-	pathGlob := append([]byte(m.MigrationsPath), []byte("*")...)
-
-	matches, err := filepath.Glob(string(pathGlob))
+	matches, err := fs.Glob(m.fsys, "*")
 	if err != nil {
-		m.logger.Fatalf("Error while globbing migrations: %v", err)
+		return err
 	}
 
 	for _, match := range matches {
@@ -189,9 +247,18 @@ func (m *migrator) fetchMigrations() error {
 			migration = &Migration{Id: num, Name: name, Status: Inactive}
 			m.migrations[num] = migration
 		}
+		if migration.Name != name {
+			return fmt.Errorf("%w: %d_%s and %d_%s", DuplicateMigration, num, migration.Name, num, name)
+		}
 		if migrationType == upMigration {
+			if migration.UpPath != "" {
+				return fmt.Errorf("%w: %s and %s", DuplicateMigration, migration.UpPath, match)
+			}
 			migration.UpPath = match
 		} else {
+			if migration.DownPath != "" {
+				return fmt.Errorf("%w: %s and %s", DuplicateMigration, migration.DownPath, match)
+			}
 			migration.DownPath = match
 		}
 	}
@@ -208,6 +275,15 @@ func (m *migrator) fetchMigrations() error {
 		}
 	}
 
+	if !m.allowGaps {
+		migrations := m.Migrations(-1)
+		for i := 1; i < len(migrations); i++ {
+			if prev, id := migrations[i-1].Id, migrations[i].Id; id != prev+1 {
+				return fmt.Errorf("%w: %d is followed by %d", MissingMigration, prev, id)
+			}
+		}
+	}
+
 	m.logger.Printf("Migrations file pairs found: %v", len(m.migrations))
 
 	return nil
@@ -221,6 +297,7 @@ func (m *migrator) getMigrationStatuses(ctx context.Context) error {
 		var mid uint64
 		err := row.Scan(&mid)
 		if err == pgx.ErrNoRows {
+			migration.Status = Inactive
 			continue
 		}
 		if err != nil {
@@ -270,7 +347,7 @@ func (m *migrator) applyMigration(ctx context.Context, migration *Migration, mTy
 
 	m.logger.Printf("Applying migration: %s", path)
 
-	sql, err := ioutil.ReadFile(path)
+	sql, err := fs.ReadFile(m.fsys, path)
 	if err != nil {
 		m.logger.Printf("Error reading migration: %s", path)
 		return err
@@ -334,23 +411,18 @@ func (m *migrator) applyMigration(ctx context.Context, migration *Migration, mTy
 	return nil
 }
 
-// Applies all inactive migrations.
+// Applies all inactive migrations. If another instance is migrating, it waits for it to finish, up to the lock
+// timeout, then applies any migrations which are still inactive.
 func (m *migrator) Migrate(ctx context.Context) error {
-	if m.Redis != nil {
-		free, err := m.Lock(m.dbAdapter.SchemaName + migrationLock)
-		if err != nil {
-			return err
-		}
-		if !free {
-			m.logger.Print("locked")
-			return nil
-		}
-		defer func() {
-			err := m.Unlock(m.dbAdapter.SchemaName + migrationLock)
-			if err != nil {
-				m.logger.Printf("%+v", err)
-			}
-		}()
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Another instance may have migrated while this one was waiting for the lock.
+	if err := m.getMigrationStatuses(ctx); err != nil {
+		return err
 	}
 
 	for _, migration := range m.Migrations(Inactive) {
@@ -368,21 +440,14 @@ func (m *migrator) Rollback(ctx context.Context) error {
 
 // Rolls back N migrations.
 func (m *migrator) RollbackN(ctx context.Context, n int) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	if m.Redis != nil {
-		free, err := m.Lock(m.dbAdapter.SchemaName + rollbackLock)
-		if err != nil {
-			return err
-		}
-		if !free {
-			return nil
-		}
-		defer func() {
-			err := m.Unlock(m.dbAdapter.SchemaName + rollbackLock)
-			if err != nil {
-				m.logger.Printf("%+v", err)
-			}
-		}()
+	if err := m.getMigrationStatuses(ctx); err != nil {
+		return err
 	}
 
 	migrations := m.Migrations(Active)
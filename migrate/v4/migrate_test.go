@@ -14,18 +14,21 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 var (
 	logger  logrus.FieldLogger
 	adapter Postgres
 	db      *pgxpool.Pool
+	dbErr   error
 	ctx     context.Context
 	redisCtrl *gomock.Controller
 	redisMock     *mockRedis.MockCache
 )
 
 func TestNewMigrator(t *testing.T) {
+	requireDB(t)
 	m := getMigrator()
 
 	if len(m.Migrations(-1)) != 3 {
@@ -48,6 +51,7 @@ func TestNewMigrator(t *testing.T) {
 }
 
 func TestCreatingMigratorWhenTableExists(t *testing.T) {
+	requireDB(t)
 	// Create the table and populate it with a row.
 	_, err := db.Exec(ctx, adapter.CreateMigrationTableSql())
 	if err != nil {
@@ -74,9 +78,10 @@ func TestCreatingMigratorWhenTableExists(t *testing.T) {
 }
 
 func TestMigrationAndRollback(t *testing.T) {
+	requireDB(t)
 	cleanup()
 	m := getMigrator()
-	if err := m.Migrate(ctx, logger); err != nil {
+	if err := m.Migrate(ctx); err != nil {
 		t.Error(err)
 	}
 
@@ -108,7 +113,7 @@ func TestMigrationAndRollback(t *testing.T) {
 	if status != Active || migrations[1].Status != Active {
 		t.Error("Invalid status for migration")
 	}
-	if err := m.RollbackN(ctx, len(migrations)+1, logger); err != nil {
+	if err := m.RollbackN(ctx, len(migrations)+1); err != nil {
 		t.Error(err)
 	}
 
@@ -139,31 +144,87 @@ func TestMigrationAndRollback(t *testing.T) {
 	cleanup()
 }
 
+func TestConcurrentMigrations(t *testing.T) {
+	requireDB(t)
+	cleanup()
+	first, second := getMigrator(), getMigrator()
+
+	errs := make(chan error, 2)
+	for _, m := range []Migrator{first, second} {
+		go func(m Migrator) {
+			errs <- m.Migrate(ctx)
+		}(m)
+	}
+	assert.NoError(t, <-errs)
+	assert.NoError(t, <-errs)
+	assert.Len(t, first.Migrations(Active), 3)
+	assert.Len(t, second.Migrations(Active), 3)
+
+	if err := first.RollbackN(ctx, 3); err != nil {
+		t.Error(err)
+	}
+	cleanup()
+}
+
+// The advisory lock must not hold one of the pool's connections, or a pool with one connection can't migrate.
+func TestMigrateWithSingleConnectionPool(t *testing.T) {
+	requireDB(t)
+	cleanup()
+	config := db.Config()
+	config.MaxConns = 1
+	pool, err := pgxpool.ConnectConfig(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	m, err := NewMigrator(timeout, pool, adapter, "test_migrations", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, m.Migrate(timeout))
+	assert.Len(t, m.Migrations(Active), 3)
+	assert.NoError(t, m.RollbackN(timeout, 3))
+	cleanup()
+}
+
 //should Lock redis
 func TestLockSuccess(t *testing.T) {
+	requireDB(t)
 	m := getMigratorWithRedis()
 	redisMock.EXPECT().Exists(gomock.Any()).Return(true, nil)
 	redisMock.EXPECT().SetExpiry(gomock.Any(),gomock.Any(),gomock.Any()).Return(nil)
-	res, _ := m.Lock("test", logger)
+	res, _ := m.Lock("test")
 	assert.Equal(t, res, true)
 }
 
 //should failure Locking, because key doen't exist
 func TestLockFailure(t *testing.T) {
+	requireDB(t)
 	m := getMigratorWithRedis()
 	redisMock.EXPECT().Exists(gomock.Any()).Return(false, errors.New("Error"))
-	res, _ := m.Lock("test", logger)
+	res, _ := m.Lock("test")
 
 	assert.Equal(t, res, false)
 }
 
 func TestUnlock(t *testing.T) {
+	requireDB(t)
 	m := getMigratorWithRedis()
 	redisMock.EXPECT().Delete(gomock.Any()).Return("",nil)
 	err := m.Unlock("test")
 	assert.Equal(t, err, nil)
 }
 
+// requireDB skips tests which need the test database when it isn't running.
+func requireDB(t *testing.T) {
+	if dbErr != nil {
+		t.Skipf("test database unavailable: %v", dbErr)
+	}
+}
+
 func getMigrator() Migrator {
 	path := fmt.Sprintf("%s", "test_migrations")
 	m, err := NewMigrator(ctx, db, adapter, path, nil)
@@ -195,11 +256,17 @@ func init() {
 	logger = logrus.New()
 	adapter = Postgres{SchemaName: "test"}
 	ctx = context.Background()
-	db, err = pgxpool.Connect(ctx, "host=localhost user=postgres dbname=postgres sslmode=disable")
+	config, err := pgxpool.ParseConfig("host=localhost user=postgres dbname=postgres sslmode=disable")
+	if err != nil {
+		panic(err)
+	}
+	config.LazyConnect = true
+	db, err = pgxpool.ConnectConfig(ctx, config)
 	redisCtrl = gomock.NewController(GinkgoT())
 	redisMock = mockRedis.NewMockCache(redisCtrl)
 
 	if err != nil {
 		panic(err)
 	}
+	_, dbErr = db.Exec(ctx, "SELECT 1")
 }
@@ -5,7 +5,8 @@ const (
 	Active
 )
 
-// Holds configuration information for a given migration.
+// Holds configuration information for a given migration. UpPath and DownPath are the names of the migration's files in
+// the migrations directory or fs.FS.
 type Migration struct {
 	DownPath string
 	Id       uint64
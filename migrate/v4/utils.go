@@ -1,21 +1,21 @@
 package migrate
 
 import (
-	"path/filepath"
+	"path"
 	"regexp"
 	"strconv"
 )
 
 var (
-	upMigrationFile   = regexp.MustCompile(`(\d+)_([\w-]+)_up\.sql`)
-	downMigrationFile = regexp.MustCompile(`(\d+)_([\w-]+)_down\.sql`)
+	upMigrationFile   = regexp.MustCompile(`^(\d+)_([\w-]+)_up\.sql$`)
+	downMigrationFile = regexp.MustCompile(`^(\d+)_([\w-]+)_down\.sql$`)
 	subMigrationSplit = regexp.MustCompile(`;\s*`)
 	allWhitespace     = regexp.MustCompile(`^\s*$`)
 )
 
 // Returns the migration number, type and base name, so 1, "up", "migration" from "01_migration_up.sql"
-func parseMigrationPath(path string) (uint64, migrationType, string, error) {
-	filebase := filepath.Base(path)
+func parseMigrationPath(name string) (uint64, migrationType, string, error) {
+	filebase := path.Base(name)
 
 	matches := upMigrationFile.FindAllSubmatch([]byte(filebase), -1)
 	if matches != nil {
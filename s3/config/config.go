@@ -1,6 +1,12 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
 	"github.com/HomesNZ/go-common/env"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	validation "github.com/go-ozzo/ozzo-validation"
@@ -8,41 +14,96 @@ import (
 
 // ACL is policy for S3 assets.
 // Region is the default region that assets are uploaded to.
-// Endpoint is the default endpoint to be used when uploading assets.
+// Endpoint is a custom endpoint, e.g. a local MinIO server. AWS endpoints are used if it's empty.
+// UsePathStyle addresses buckets in the path rather than the host name, which most S3 compatible servers need. It's
+// off by default, even with an Endpoint, as AWS endpoints such as VPC endpoints use virtual hosted style.
 // BucketName is aws S3 bucket Name
 // CloudfrontURL is CDN url
+// AccessKeyID and SecretAccessKey are optional, the default AWS credential chain is used if they're empty.
+// MaxAttempts and MaxBackoff configure retries, the AWS defaults are used if they're 0.
+// Timeout limits each request, including reading the response body. There is no limit if it's 0.
 type Config struct {
 	BucketName      string
 	ACL             types.ObjectCannedACL
 	Region          string
 	Endpoint        string
+	UsePathStyle    bool
 	CloudfrontURL   string
 	AccessKeyID     string
 	SecretAccessKey string
 	SessionToken    string
+	MaxAttempts     int
+	MaxBackoff      time.Duration
+	Timeout         time.Duration
 }
 
 func NewFromEnv() *Config {
 	cfg := &Config{
 		ACL:             ACL(env.GetString("AWS_S3_ACL", "private")),
 		Region:          env.GetString("AWS_S3_REGION", "ap-southeast-2"),
-		Endpoint:        env.GetString("AWS_S3_ENDPOINT", "s3-ap-southeast-2.amazonaws.com"),
+		Endpoint:        env.GetString("AWS_S3_ENDPOINT", ""),
+		UsePathStyle:    env.GetBool("AWS_S3_USE_PATH_STYLE", false),
 		BucketName:      env.GetString("AWS_S3_BUCKET", ""),
 		CloudfrontURL:   env.GetString("CDN_URL", ""),
 		AccessKeyID:     env.GetString("AWS_ACCESS_KEY_ID", ""),
 		SecretAccessKey: env.GetString("AWS_SECRET_ACCESS_KEY", ""),
 		SessionToken:    env.GetString("AWS_SESSION_TOKEN", ""),
+		MaxAttempts:     env.GetInt("AWS_S3_MAX_ATTEMPTS", 0),
+		MaxBackoff:      env.GetDuration("AWS_S3_MAX_BACKOFF", 0),
+		Timeout:         env.GetDuration("AWS_S3_TIMEOUT", 0),
 	}
 
 	return cfg
 }
 
 func (c *Config) Validate() error {
-	return validation.ValidateStruct(c,
-		validation.Field(&c.AccessKeyID, validation.Required, validation.Required.Error("AWS access key was not provided")),
-		validation.Field(&c.SecretAccessKey, validation.Required, validation.Required.Error("AWS secret access key was not provided")),
+	err := validation.ValidateStruct(c,
 		validation.Field(&c.BucketName, validation.Required, validation.Required.Error("Bucket name was not provided")),
 	)
+	if err != nil {
+		return err
+	}
+	if c.AccessKeyID == "" && c.SecretAccessKey != "" {
+		return errors.New("AWS access key was not provided")
+	}
+	if c.AccessKeyID != "" && c.SecretAccessKey == "" {
+		return errors.New("AWS secret access key was not provided")
+	}
+	return nil
+}
+
+// EndpointURL returns Endpoint with a scheme, as it has historically been configured as a bare host name.
+func (c *Config) EndpointURL() string {
+	return endpointURL(c.Endpoint)
+}
+
+func endpointURL(endpoint string) string {
+	if endpoint == "" || strings.Contains(endpoint, "://") {
+		return endpoint
+	}
+	return "https://" + endpoint
+}
+
+// AssetURL returns the URL of the asset with the provided key, on the Cloudfront distribution if CloudfrontURL is set,
+// otherwise on S3.
+func (c *Config) AssetURL(key string) string {
+	if c.CloudfrontURL != "" {
+		return fmt.Sprintf("%s/%s", strings.TrimSuffix(c.CloudfrontURL, "/"), key)
+	}
+
+	if c.Endpoint == "" {
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", c.BucketName, c.Region, key)
+	}
+	endpoint := strings.TrimSuffix(c.EndpointURL(), "/")
+	if c.UsePathStyle {
+		return fmt.Sprintf("%s/%s/%s", endpoint, c.BucketName, key)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Sprintf("%s/%s/%s", endpoint, c.BucketName, key)
+	}
+	u.Host = c.BucketName + "." + u.Host
+	return fmt.Sprintf("%s/%s", u.String(), key)
 }
 
 func ACL(val string) types.ObjectCannedACL {
@@ -1,26 +1,38 @@
 package config
 
 import (
-	"github.com/HomesNZ/env"
+	"github.com/HomesNZ/go-common/env"
 	validation "github.com/go-ozzo/ozzo-validation"
 )
 
+// Endpoint and UsePathStyle are as in Config, and must match the Config of the Service storing the objects.
 type PresignConfig struct {
 	AccessKeyID     string
 	SecretAccessKey string
+	SessionToken    string
 	Region          string
+	Endpoint        string
+	UsePathStyle    bool
 }
 
 func NewPresignConfigFromEnv() *PresignConfig {
 	cfg := &PresignConfig{
 		AccessKeyID:     env.GetString("AWS_ACCESS_KEY_ID", ""),
 		SecretAccessKey: env.GetString("AWS_SECRET_ACCESS_KEY", ""),
+		SessionToken:    env.GetString("AWS_SESSION_TOKEN", ""),
 		Region:          env.GetString("AWS_S3_REGION", "ap-southeast-2"),
+		Endpoint:        env.GetString("AWS_S3_ENDPOINT", ""),
+		UsePathStyle:    env.GetBool("AWS_S3_USE_PATH_STYLE", false),
 	}
 
 	return cfg
 }
 
+// EndpointURL returns Endpoint with a scheme, as it has historically been configured as a bare host name.
+func (c *PresignConfig) EndpointURL() string {
+	return endpointURL(c.Endpoint)
+}
+
 func (c *PresignConfig) Validate() error {
 	return validation.ValidateStruct(c,
 		validation.Field(&c.AccessKeyID, validation.Required, validation.Required.Error("AWS access key was not provided")),
@@ -25,6 +25,16 @@ var _ = Describe("Config", func() {
 			err := cfg.Validate()
 			Expect(err).NotTo(HaveOccurred())
 		})
+		It("does not return an error without keys, to use the default credential chain", func() {
+			cfg := &Config{BucketName: "test-bucket"}
+			err := cfg.Validate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("returns an error for a partial key", func() {
+			cfg := &Config{AccessKeyID: "key-id", BucketName: "test-bucket"}
+			err := cfg.Validate()
+			Expect(err).To(HaveOccurred())
+		})
 
 		It("does not return an error for NewFromEnv, because it'll set default port and host", func() {
 			accessKeyID := "key-id"
@@ -41,4 +51,40 @@ var _ = Describe("Config", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 	})
+
+	Describe("#NewFromEnv", func() {
+		AfterEach(func() {
+			os.Unsetenv("AWS_S3_ENDPOINT")
+			os.Unsetenv("AWS_S3_USE_PATH_STYLE")
+		})
+
+		It("uses virtual hosted style with an endpoint by default", func() {
+			os.Setenv("AWS_S3_ENDPOINT", "https://bucket.vpce-1a2b3c4d.s3.ap-southeast-2.vpce.amazonaws.com")
+			Expect(NewFromEnv().UsePathStyle).To(BeFalse())
+		})
+		It("uses path style when it's enabled", func() {
+			os.Setenv("AWS_S3_ENDPOINT", "http://localhost:9000")
+			os.Setenv("AWS_S3_USE_PATH_STYLE", "true")
+			Expect(NewFromEnv().UsePathStyle).To(BeTrue())
+		})
+	})
+
+	Describe("#AssetURL", func() {
+		It("uses the Cloudfront URL", func() {
+			cfg := &Config{BucketName: "test-bucket", CloudfrontURL: "https://cdn.example.com/"}
+			Expect(cfg.AssetURL("photos/1.jpg")).To(Equal("https://cdn.example.com/photos/1.jpg"))
+		})
+		It("uses the AWS endpoint", func() {
+			cfg := &Config{BucketName: "test-bucket", Region: "ap-southeast-2"}
+			Expect(cfg.AssetURL("photos/1.jpg")).To(Equal("https://test-bucket.s3.ap-southeast-2.amazonaws.com/photos/1.jpg"))
+		})
+		It("uses a custom endpoint with path style", func() {
+			cfg := &Config{BucketName: "test-bucket", Endpoint: "http://localhost:9000", UsePathStyle: true}
+			Expect(cfg.AssetURL("photos/1.jpg")).To(Equal("http://localhost:9000/test-bucket/photos/1.jpg"))
+		})
+		It("uses a custom endpoint without a scheme", func() {
+			cfg := &Config{BucketName: "test-bucket", Endpoint: "s3-ap-southeast-2.amazonaws.com"}
+			Expect(cfg.AssetURL("photos/1.jpg")).To(Equal("https://test-bucket.s3-ap-southeast-2.amazonaws.com/photos/1.jpg"))
+		})
+	})
 })
@@ -0,0 +1,345 @@
+package s3
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/pkg/errors"
+)
+
+const (
+	// cseKeyMetadata is the user metadata holding the wrapped data key of a client side encrypted object.
+	cseKeyMetadata = "cse-key"
+	// cseAlgorithmMetadata is the user metadata holding the algorithm a client side encrypted object was encrypted with.
+	cseAlgorithmMetadata = "cse-alg"
+	// cseAlgorithm is AES-256-GCM over 64KB segments, so objects can be encrypted and decrypted as they're streamed.
+	cseAlgorithm = "AES256-GCM-64K"
+
+	cseSegmentSize   = 64 * 1024
+	cseTagSize       = 16
+	cseDataKeySize   = 32
+	cseMaxSegmentNum = 1<<32 - 1
+)
+
+// ErrEncryptedRange is returned when a range is read from a client side encrypted object.
+var ErrEncryptedRange = errors.New("s3: range reads of client side encrypted objects aren't supported")
+
+// KeyWrapper encrypts and decrypts the per object data keys used by client side encryption.
+type KeyWrapper interface {
+	WrapKey(ctx context.Context, key []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// WithClientSideEncryption encrypts every uploaded object with a new data key before it leaves the process, and
+// decrypts client side encrypted objects as they're read. The data key is wrapped with wrapper and stored in the
+// object's user metadata. Objects which weren't client side encrypted are read unchanged.
+func WithClientSideEncryption(wrapper KeyWrapper) Option {
+	return func(s *s3) {
+		s.keyWrapper = wrapper
+	}
+}
+
+// aesKeyWrapper wraps data keys with AES-GCM using a master key held by the service.
+type aesKeyWrapper struct {
+	aead cipher.AEAD
+}
+
+// NewAESKeyWrapper returns a KeyWrapper which wraps data keys with masterKey, which must be 16, 24 or 32 bytes.
+func NewAESKeyWrapper(masterKey []byte) (KeyWrapper, error) {
+	block, err := aes.NewCipher(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return aesKeyWrapper{aead: aead}, nil
+}
+
+func (w aesKeyWrapper) WrapKey(ctx context.Context, key []byte) ([]byte, error) {
+	nonce := make([]byte, w.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return w.aead.Seal(nonce, nonce, key, nil), nil
+}
+
+func (w aesKeyWrapper) UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < w.aead.NonceSize() {
+		return nil, errors.New("wrapped key is too short")
+	}
+	nonce, ciphertext := wrapped[:w.aead.NonceSize()], wrapped[w.aead.NonceSize():]
+	return w.aead.Open(nil, nonce, ciphertext, nil)
+}
+
+// KMSClient is the part of the KMS client used by NewKMSKeyWrapper.
+type KMSClient interface {
+	Encrypt(ctx context.Context, params *kms.EncryptInput, optFns ...func(*kms.Options)) (*kms.EncryptOutput, error)
+	Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+// kmsKeyWrapper wraps data keys with a KMS key.
+type kmsKeyWrapper struct {
+	client KMSClient
+	keyID  string
+}
+
+// NewKMSKeyWrapper returns a KeyWrapper which wraps data keys with the KMS key keyID.
+func NewKMSKeyWrapper(client KMSClient, keyID string) KeyWrapper {
+	return kmsKeyWrapper{client: client, keyID: keyID}
+}
+
+func (w kmsKeyWrapper) WrapKey(ctx context.Context, key []byte) ([]byte, error) {
+	output, err := w.client.Encrypt(ctx, &kms.EncryptInput{KeyId: aws.String(w.keyID), Plaintext: key})
+	if err != nil {
+		return nil, errors.Wrap(err, "kms encrypt data key")
+	}
+	return output.CiphertextBlob, nil
+}
+
+func (w kmsKeyWrapper) UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	output, err := w.client.Decrypt(ctx, &kms.DecryptInput{KeyId: aws.String(w.keyID), CiphertextBlob: wrapped})
+	if err != nil {
+		return nil, errors.Wrap(err, "kms decrypt data key")
+	}
+	return output.Plaintext, nil
+}
+
+// encrypt returns a reader of the encrypted contents of r, and the metadata to store with the object.
+func (s s3) encrypt(ctx context.Context, r io.Reader, metadata map[string]string) (io.Reader, map[string]string, error) {
+	key := make([]byte, cseDataKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, nil, err
+	}
+	wrapped, err := s.keyWrapper.WrapKey(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	aead, err := newSegmentAEAD(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encryptedMetadata := map[string]string{
+		cseKeyMetadata:       base64.StdEncoding.EncodeToString(wrapped),
+		cseAlgorithmMetadata: cseAlgorithm,
+	}
+	for k, v := range metadata {
+		encryptedMetadata[k] = v
+	}
+	return &encryptReader{aead: aead, r: r}, encryptedMetadata, nil
+}
+
+// decrypt returns a reader of the decrypted body of an object, or body unchanged if the object wasn't client side
+// encrypted.
+func (s s3) decrypt(ctx context.Context, body io.ReadCloser, metadata map[string]string) (io.ReadCloser, error) {
+	aead, err := s.objectAEAD(ctx, metadata)
+	if err != nil || aead == nil {
+		return body, err
+	}
+	return &decryptReader{aead: aead, r: body, closer: body}, nil
+}
+
+// objectAEAD returns the cipher of a client side encrypted object, or nil if the object isn't encrypted.
+func (s s3) objectAEAD(ctx context.Context, metadata map[string]string) (cipher.AEAD, error) {
+	encoded, ok := metadata[cseKeyMetadata]
+	if !ok || s.keyWrapper == nil {
+		return nil, nil
+	}
+	if alg := metadata[cseAlgorithmMetadata]; alg != cseAlgorithm {
+		return nil, errors.Errorf("unsupported client side encryption algorithm %q", alg)
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode data key")
+	}
+	key, err := s.keyWrapper.UnwrapKey(ctx, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	return newSegmentAEAD(key)
+}
+
+// isEncrypted returns whether the metadata is of a client side encrypted object which the service can decrypt.
+func (s s3) isEncrypted(metadata map[string]string) bool {
+	_, ok := metadata[cseKeyMetadata]
+	return ok && s.keyWrapper != nil
+}
+
+func newSegmentAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// segmentNonce returns the nonce of segment i. Data keys are never reused, so the nonce only needs to be unique within
+// an object. The last byte marks the final segment, so a truncated object fails to decrypt.
+func segmentNonce(i uint32, final bool) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint32(nonce[7:11], i)
+	if final {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// plaintextSize returns the size of the plaintext of a client side encrypted object of size bytes.
+func plaintextSize(size int64) int64 {
+	full := size / (cseSegmentSize + cseTagSize)
+	rem := size % (cseSegmentSize + cseTagSize)
+	if rem == 0 {
+		return full * cseSegmentSize
+	}
+	return full*cseSegmentSize + rem - cseTagSize
+}
+
+// encryptReader encrypts r in segments. A segment is only sealed once the next has been read, so the final segment
+// can be marked.
+type encryptReader struct {
+	aead    cipher.AEAD
+	r       io.Reader
+	segment uint32
+	next    []byte
+	eof     bool
+	out     []byte
+	done    bool
+	err     error
+}
+
+func (e *encryptReader) Read(b []byte) (int, error) {
+	for len(e.out) == 0 {
+		if e.done {
+			return 0, io.EOF
+		}
+		if e.err != nil {
+			return 0, e.err
+		}
+		e.seal()
+	}
+	n := copy(b, e.out)
+	e.out = e.out[n:]
+	return n, nil
+}
+
+func (e *encryptReader) seal() {
+	if e.next == nil {
+		e.next, e.eof, e.err = readSegment(e.r, cseSegmentSize)
+		if e.err != nil {
+			return
+		}
+	}
+	current, final := e.next, e.eof
+	if !final {
+		e.next, e.eof, e.err = readSegment(e.r, cseSegmentSize)
+		if e.err != nil {
+			return
+		}
+		// The object ends on a segment boundary, mark the full segment as final rather than adding an empty one.
+		if len(e.next) == 0 && e.eof {
+			final = true
+		}
+	}
+	if e.segment == cseMaxSegmentNum {
+		e.err = errors.New("object is too large to encrypt")
+		return
+	}
+
+	e.out = e.aead.Seal(nil, segmentNonce(e.segment, final), current, nil)
+	e.segment++
+	e.done = final
+}
+
+// decryptReader decrypts the segments written by encryptReader.
+type decryptReader struct {
+	aead    cipher.AEAD
+	r       io.Reader
+	closer  io.Closer
+	segment uint32
+	next    []byte
+	eof     bool
+	out     []byte
+	done    bool
+	err     error
+}
+
+func (d *decryptReader) Read(b []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if d.err != nil {
+			return 0, d.err
+		}
+		d.open()
+	}
+	n := copy(b, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+func (d *decryptReader) open() {
+	if d.next == nil {
+		d.next, d.eof, d.err = readSegment(d.r, cseSegmentSize+cseTagSize)
+		if d.err != nil {
+			return
+		}
+	}
+	current, final := d.next, d.eof
+	if !final {
+		d.next, d.eof, d.err = readSegment(d.r, cseSegmentSize+cseTagSize)
+		if d.err != nil {
+			return
+		}
+		if len(d.next) == 0 && d.eof {
+			final = true
+		}
+	}
+
+	out, err := d.aead.Open(nil, segmentNonce(d.segment, final), current, nil)
+	if err != nil {
+		d.err = errors.Wrap(err, "decrypt object")
+		return
+	}
+	d.out = out
+	d.segment++
+	d.done = final
+}
+
+func (d *decryptReader) Close() error {
+	return d.closer.Close()
+}
+
+// readSegment reads up to size bytes from r, and returns whether r is exhausted.
+func readSegment(r io.Reader, size int) ([]byte, bool, error) {
+	b := make([]byte, size)
+	n, err := io.ReadFull(r, b)
+	switch err {
+	case nil:
+		return b, false, nil
+	case io.EOF, io.ErrUnexpectedEOF:
+		return b[:n], true, nil
+	default:
+		return nil, false, err
+	}
+}
+
+// userMetadata returns metadata without the client side encryption keys.
+func userMetadata(metadata map[string]string) map[string]string {
+	user := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		if k != cseKeyMetadata && k != cseAlgorithmMetadata {
+			user[k] = v
+		}
+	}
+	return user
+}
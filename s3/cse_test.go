@@ -0,0 +1,131 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/HomesNZ/go-common/s3/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsS3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client side encryption", func() {
+	key := make([]byte, cseDataKeySize)
+	rand.Read(key)
+	aead, _ := newSegmentAEAD(key)
+
+	DescribeTable("round trips",
+		func(size int) {
+			plaintext := make([]byte, size)
+			rand.Read(plaintext)
+
+			ciphertext, err := ioutil.ReadAll(&encryptReader{aead: aead, r: bytes.NewReader(plaintext)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plaintextSize(int64(len(ciphertext)))).To(Equal(int64(size)))
+
+			decrypted, err := ioutil.ReadAll(&decryptReader{aead: aead, r: bytes.NewReader(ciphertext)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bytes.Equal(decrypted, plaintext)).To(BeTrue())
+		},
+		Entry("empty", 0),
+		Entry("less than a segment", 100),
+		Entry("a segment", cseSegmentSize),
+		Entry("more than a segment", cseSegmentSize+1),
+		Entry("several segments", 3*cseSegmentSize-1),
+	)
+
+	It("detects truncated objects", func() {
+		plaintext := make([]byte, 2*cseSegmentSize+10)
+		ciphertext, err := ioutil.ReadAll(&encryptReader{aead: aead, r: bytes.NewReader(plaintext)})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = ioutil.ReadAll(&decryptReader{aead: aead, r: bytes.NewReader(ciphertext[:2*(cseSegmentSize+cseTagSize)])})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("wraps keys", func() {
+		wrapper, err := NewAESKeyWrapper(key)
+		Expect(err).NotTo(HaveOccurred())
+		wrapped, err := wrapper.WrapKey(context.Background(), []byte("data key"))
+		Expect(err).NotTo(HaveOccurred())
+		unwrapped, err := wrapper.UnwrapKey(context.Background(), wrapped)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(unwrapped)).To(Equal("data key"))
+	})
+
+	Describe("service", func() {
+		var (
+			server  *httptest.Server
+			service s3
+			stored  []byte
+			headers http.Header
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case http.MethodPut:
+					stored, _ = ioutil.ReadAll(r.Body)
+					headers = r.Header.Clone()
+				case http.MethodGet:
+					for k, v := range headers {
+						if strings.HasPrefix(strings.ToLower(k), "x-amz-meta-") {
+							w.Header()[k] = v
+						}
+					}
+					w.Write(stored)
+				}
+			}))
+			wrapper, err := NewAESKeyWrapper(key)
+			Expect(err).NotTo(HaveOccurred())
+			service = s3{
+				client: awsS3.New(awsS3.Options{
+					Region:           "ap-southeast-2",
+					Credentials:      aws.AnonymousCredentials{},
+					EndpointResolver: awsS3.EndpointResolverFromURL(server.URL),
+					UsePathStyle:     true,
+				}),
+				config:     &config.Config{BucketName: "test-bucket"},
+				keyWrapper: wrapper,
+			}
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("encrypts uploads and decrypts downloads", func() {
+			_, err := service.Upload(context.Background(), "vendor.csv", []byte("personal data"), time.Time{}, "text/csv", WithMetadata(map[string]string{"source": "vendor"}))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stored).NotTo(ContainSubstring("personal data"))
+			Expect(headers.Get("X-Amz-Meta-Cse-Key")).NotTo(BeEmpty())
+			Expect(headers.Get("X-Amz-Meta-Source")).To(Equal("vendor"))
+
+			b, err := service.Download(context.Background(), "vendor.csv")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal("personal data"))
+
+			r, info, err := service.Open(context.Background(), "vendor.csv")
+			Expect(err).NotTo(HaveOccurred())
+			defer r.Close()
+			Expect(info.Size).To(Equal(int64(len("personal data"))))
+			Expect(info.Metadata).To(Equal(map[string]string{"source": "vendor"}))
+		})
+
+		It("sets server side encryption headers", func() {
+			_, err := service.UploadStream(context.Background(), "vendor.csv", strings.NewReader("data"), WithSSEKMS("key-id"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(headers.Get("X-Amz-Server-Side-Encryption")).To(Equal("aws:kms"))
+			Expect(headers.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id")).To(Equal("key-id"))
+		})
+	})
+})
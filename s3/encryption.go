@@ -0,0 +1,76 @@
+package s3
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsS3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// sseCustomerAlgorithm is the only algorithm S3 supports for customer provided keys.
+const sseCustomerAlgorithm = "AES256"
+
+// WithSSE encrypts uploaded objects at rest with keys managed by S3 (SSE-S3).
+func WithSSE() TransferOption {
+	return func(o *transferOptions) {
+		o.sse = types.ServerSideEncryptionAes256
+	}
+}
+
+// WithSSEKMS encrypts uploaded objects at rest with the KMS key keyID (SSE-KMS). If keyID is empty the AWS managed
+// key for S3 is used.
+func WithSSEKMS(keyID string) TransferOption {
+	return func(o *transferOptions) {
+		o.sse = types.ServerSideEncryptionAwsKms
+		o.kmsKeyID = keyID
+	}
+}
+
+// WithSSEC encrypts uploaded objects at rest with a 256 bit key provided by the caller (SSE-C). S3 doesn't store the
+// key, so the same option must be passed to read the object, including Download, Open, Head and as the source of
+// Copy and Move.
+func WithSSEC(key []byte) TransferOption {
+	return func(o *transferOptions) {
+		o.customerKey = key
+	}
+}
+
+// sseCustomer returns the algorithm, base64 encoded key and base64 encoded key MD5 S3 expects for SSE-C, or nils if
+// no customer key was set.
+func (o *transferOptions) sseCustomer() (algorithm, key, keyMD5 *string) {
+	if len(o.customerKey) == 0 {
+		return nil, nil, nil
+	}
+	sum := md5.Sum(o.customerKey)
+	return aws.String(sseCustomerAlgorithm),
+		aws.String(base64.StdEncoding.EncodeToString(o.customerKey)),
+		aws.String(base64.StdEncoding.EncodeToString(sum[:]))
+}
+
+func (o *transferOptions) applyPut(params *awsS3.PutObjectInput) {
+	params.ServerSideEncryption = o.sse
+	if o.kmsKeyID != "" {
+		params.SSEKMSKeyId = aws.String(o.kmsKeyID)
+	}
+	params.SSECustomerAlgorithm, params.SSECustomerKey, params.SSECustomerKeyMD5 = o.sseCustomer()
+}
+
+func (o *transferOptions) applyGet(params *awsS3.GetObjectInput) {
+	params.SSECustomerAlgorithm, params.SSECustomerKey, params.SSECustomerKeyMD5 = o.sseCustomer()
+}
+
+func (o *transferOptions) applyHead(params *awsS3.HeadObjectInput) {
+	params.SSECustomerAlgorithm, params.SSECustomerKey, params.SSECustomerKeyMD5 = o.sseCustomer()
+}
+
+// applyCopy encrypts the copy like an upload, and decrypts the source with the same customer key.
+func (o *transferOptions) applyCopy(params *awsS3.CopyObjectInput) {
+	params.ServerSideEncryption = o.sse
+	if o.kmsKeyID != "" {
+		params.SSEKMSKeyId = aws.String(o.kmsKeyID)
+	}
+	params.SSECustomerAlgorithm, params.SSECustomerKey, params.SSECustomerKeyMD5 = o.sseCustomer()
+	params.CopySourceSSECustomerAlgorithm, params.CopySourceSSECustomerKey, params.CopySourceSSECustomerKeyMD5 = o.sseCustomer()
+}
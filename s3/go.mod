@@ -5,11 +5,11 @@ go 1.15
 require (
 	github.com/HomesNZ/go-common/env v0.0.0-20211028023116-06d601bd3f83
 	github.com/asaskevich/govalidator v0.0.0-20210307081110-f21760c49a8d // indirect
-	github.com/aws/aws-sdk-go v1.42.6 // indirect
 	github.com/aws/aws-sdk-go-v2 v1.11.0
 	github.com/aws/aws-sdk-go-v2/config v1.10.1
 	github.com/aws/aws-sdk-go-v2/credentials v1.6.1
 	github.com/aws/aws-sdk-go-v2/feature/s3/manager v1.7.1
+	github.com/aws/aws-sdk-go-v2/service/kms v1.10.0
 	github.com/aws/aws-sdk-go-v2/service/s3 v1.19.0
 	github.com/go-ozzo/ozzo-validation v3.6.0+incompatible
 	github.com/golang/mock v1.6.0
@@ -17,4 +17,5 @@ require (
 	github.com/onsi/gomega v1.17.0
 	github.com/pkg/errors v0.9.1
 	github.com/sirupsen/logrus v1.8.1 // indirect
+	golang.org/x/net v0.0.0-20210614182718-04defd469f4e // indirect
 )
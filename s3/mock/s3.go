@@ -6,9 +6,11 @@ package mock_s3
 
 import (
 	context "context"
+	io "io"
 	reflect "reflect"
 	time "time"
 
+	s3 "github.com/HomesNZ/go-common/s3"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -35,6 +37,25 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 	return m.recorder
 }
 
+// Copy mocks base method.
+func (m *MockService) Copy(ctx context.Context, srcKey, dstKey string, opts ...s3.TransferOption) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, srcKey, dstKey}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Copy", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Copy indicates an expected call of Copy.
+func (mr *MockServiceMockRecorder) Copy(ctx, srcKey, dstKey interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, srcKey, dstKey}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Copy", reflect.TypeOf((*MockService)(nil).Copy), varargs...)
+}
+
 // Delete mocks base method.
 func (m *MockService) Delete(ctx context.Context, key string) error {
 	m.ctrl.T.Helper()
@@ -49,32 +70,190 @@ func (mr *MockServiceMockRecorder) Delete(ctx, key interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockService)(nil).Delete), ctx, key)
 }
 
+// DeleteMany mocks base method.
+func (m *MockService) DeleteMany(ctx context.Context, keys []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMany", ctx, keys)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMany indicates an expected call of DeleteMany.
+func (mr *MockServiceMockRecorder) DeleteMany(ctx, keys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMany", reflect.TypeOf((*MockService)(nil).DeleteMany), ctx, keys)
+}
+
 // Download mocks base method.
-func (m *MockService) Download(ctx context.Context, key string) ([]byte, error) {
+func (m *MockService) Download(ctx context.Context, key string, opts ...s3.TransferOption) ([]byte, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Download", ctx, key)
+	varargs := []interface{}{ctx, key}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Download", varargs...)
 	ret0, _ := ret[0].([]byte)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Download indicates an expected call of Download.
-func (mr *MockServiceMockRecorder) Download(ctx, key interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) Download(ctx, key interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, key}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Download", reflect.TypeOf((*MockService)(nil).Download), varargs...)
+}
+
+// DownloadTo mocks base method.
+func (m *MockService) DownloadTo(ctx context.Context, key string, w io.WriterAt, opts ...s3.TransferOption) (int64, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, key, w}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DownloadTo", varargs...)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DownloadTo indicates an expected call of DownloadTo.
+func (mr *MockServiceMockRecorder) DownloadTo(ctx, key, w interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, key, w}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadTo", reflect.TypeOf((*MockService)(nil).DownloadTo), varargs...)
+}
+
+// Exists mocks base method.
+func (m *MockService) Exists(ctx context.Context, key string, opts ...s3.TransferOption) (bool, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, key}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Exists", varargs...)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Exists indicates an expected call of Exists.
+func (mr *MockServiceMockRecorder) Exists(ctx, key interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, key}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exists", reflect.TypeOf((*MockService)(nil).Exists), varargs...)
+}
+
+// Head mocks base method.
+func (m *MockService) Head(ctx context.Context, key string, opts ...s3.TransferOption) (s3.ObjectInfo, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, key}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Head", varargs...)
+	ret0, _ := ret[0].(s3.ObjectInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Head indicates an expected call of Head.
+func (mr *MockServiceMockRecorder) Head(ctx, key interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, key}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Head", reflect.TypeOf((*MockService)(nil).Head), varargs...)
+}
+
+// List mocks base method.
+func (m *MockService) List(ctx context.Context, prefix string) *s3.ObjectIterator {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, prefix)
+	ret0, _ := ret[0].(*s3.ObjectIterator)
+	return ret0
+}
+
+// List indicates an expected call of List.
+func (mr *MockServiceMockRecorder) List(ctx, prefix interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Download", reflect.TypeOf((*MockService)(nil).Download), ctx, key)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockService)(nil).List), ctx, prefix)
+}
+
+// Move mocks base method.
+func (m *MockService) Move(ctx context.Context, srcKey, dstKey string, opts ...s3.TransferOption) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, srcKey, dstKey}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Move", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Move indicates an expected call of Move.
+func (mr *MockServiceMockRecorder) Move(ctx, srcKey, dstKey interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, srcKey, dstKey}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Move", reflect.TypeOf((*MockService)(nil).Move), varargs...)
+}
+
+// Open mocks base method.
+func (m *MockService) Open(ctx context.Context, key string, opts ...s3.TransferOption) (io.ReadCloser, s3.ObjectInfo, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, key}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Open", varargs...)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(s3.ObjectInfo)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Open indicates an expected call of Open.
+func (mr *MockServiceMockRecorder) Open(ctx, key interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, key}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Open", reflect.TypeOf((*MockService)(nil).Open), varargs...)
 }
 
 // Upload mocks base method.
-func (m *MockService) Upload(ctx context.Context, key string, b []byte, expiry time.Time, contentType string) (string, error) {
+func (m *MockService) Upload(ctx context.Context, key string, b []byte, expiry time.Time, contentType string, opts ...s3.TransferOption) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Upload", ctx, key, b, expiry, contentType)
+	varargs := []interface{}{ctx, key, b, expiry, contentType}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Upload", varargs...)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Upload indicates an expected call of Upload.
-func (mr *MockServiceMockRecorder) Upload(ctx, key, b, expiry, contentType interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) Upload(ctx, key, b, expiry, contentType interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, key, b, expiry, contentType}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upload", reflect.TypeOf((*MockService)(nil).Upload), varargs...)
+}
+
+// UploadStream mocks base method.
+func (m *MockService) UploadStream(ctx context.Context, key string, r io.Reader, opts ...s3.TransferOption) (string, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, key, r}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "UploadStream", varargs...)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UploadStream indicates an expected call of UploadStream.
+func (mr *MockServiceMockRecorder) UploadStream(ctx, key, r interface{}, opts ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upload", reflect.TypeOf((*MockService)(nil).Upload), ctx, key, b, expiry, contentType)
+	varargs := append([]interface{}{ctx, key, r}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadStream", reflect.TypeOf((*MockService)(nil).UploadStream), varargs...)
 }
@@ -7,7 +7,9 @@ package mock_s3
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
+	s3 "github.com/HomesNZ/go-common/s3"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -34,17 +36,62 @@ func (m *MockPresignService) EXPECT() *MockPresignServiceMockRecorder {
 	return m.recorder
 }
 
+// SignedDeleteObjectUrl mocks base method.
+func (m *MockPresignService) SignedDeleteObjectUrl(ctx context.Context, bucket, key string, expiry time.Duration) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignedDeleteObjectUrl", ctx, bucket, key, expiry)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignedDeleteObjectUrl indicates an expected call of SignedDeleteObjectUrl.
+func (mr *MockPresignServiceMockRecorder) SignedDeleteObjectUrl(ctx, bucket, key, expiry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignedDeleteObjectUrl", reflect.TypeOf((*MockPresignService)(nil).SignedDeleteObjectUrl), ctx, bucket, key, expiry)
+}
+
+// SignedGetObjectUrl mocks base method.
+func (m *MockPresignService) SignedGetObjectUrl(ctx context.Context, bucket, key, contentDisposition string, expiry time.Duration) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignedGetObjectUrl", ctx, bucket, key, contentDisposition, expiry)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignedGetObjectUrl indicates an expected call of SignedGetObjectUrl.
+func (mr *MockPresignServiceMockRecorder) SignedGetObjectUrl(ctx, bucket, key, contentDisposition, expiry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignedGetObjectUrl", reflect.TypeOf((*MockPresignService)(nil).SignedGetObjectUrl), ctx, bucket, key, contentDisposition, expiry)
+}
+
+// SignedPostPolicy mocks base method.
+func (m *MockPresignService) SignedPostPolicy(ctx context.Context, bucket, key string, conditions s3.PostConditions, expiry time.Duration) (*s3.PostPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignedPostPolicy", ctx, bucket, key, conditions, expiry)
+	ret0, _ := ret[0].(*s3.PostPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignedPostPolicy indicates an expected call of SignedPostPolicy.
+func (mr *MockPresignServiceMockRecorder) SignedPostPolicy(ctx, bucket, key, conditions, expiry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignedPostPolicy", reflect.TypeOf((*MockPresignService)(nil).SignedPostPolicy), ctx, bucket, key, conditions, expiry)
+}
+
 // SignedPutObjectUrl mocks base method.
-func (m *MockPresignService) SignedPutObjectUrl(ctx context.Context, bucket, key, contentType string) (string, error) {
+func (m *MockPresignService) SignedPutObjectUrl(ctx context.Context, bucket, key, contentType string, expiry time.Duration) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SignedPutObjectUrl", ctx, bucket, key, contentType)
+	ret := m.ctrl.Call(m, "SignedPutObjectUrl", ctx, bucket, key, contentType, expiry)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // SignedPutObjectUrl indicates an expected call of SignedPutObjectUrl.
-func (mr *MockPresignServiceMockRecorder) SignedPutObjectUrl(ctx, bucket, key, contentType interface{}) *gomock.Call {
+func (mr *MockPresignServiceMockRecorder) SignedPutObjectUrl(ctx, bucket, key, contentType, expiry interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignedPutObjectUrl", reflect.TypeOf((*MockPresignService)(nil).SignedPutObjectUrl), ctx, bucket, key, contentType)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignedPutObjectUrl", reflect.TypeOf((*MockPresignService)(nil).SignedPutObjectUrl), ctx, bucket, key, contentType, expiry)
 }
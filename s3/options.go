@@ -0,0 +1,142 @@
+package s3
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ProgressFunc is called as an object is transferred with the number of bytes transferred so far, and the total size
+// of the transfer, or -1 if it isn't known.
+type ProgressFunc func(transferred, total int64)
+
+// TransferOption configures uploads, downloads and copies.
+type TransferOption func(*transferOptions)
+
+type transferOptions struct {
+	contentType string
+	expiry      time.Time
+	partSize    int64
+	concurrency int
+	offset      int64
+	length      int64
+	progress    ProgressFunc
+	metadata    map[string]string
+	tags        map[string]string
+	srcBucket   string
+	dstBucket   string
+	sse         types.ServerSideEncryption
+	kmsKeyID    string
+	customerKey []byte
+}
+
+func newTransferOptions(opts []TransferOption) *transferOptions {
+	o := &transferOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithContentType sets the content type of an uploaded object.
+func WithContentType(contentType string) TransferOption {
+	return func(o *transferOptions) {
+		o.contentType = contentType
+	}
+}
+
+// WithExpiry sets the date and time an uploaded object is no longer cacheable.
+func WithExpiry(expiry time.Time) TransferOption {
+	return func(o *transferOptions) {
+		o.expiry = expiry
+	}
+}
+
+// WithPartSize sets the size of each part of a multipart upload or download. Defaults to 5MB, the minimum part size
+// S3 allows.
+func WithPartSize(size int64) TransferOption {
+	return func(o *transferOptions) {
+		o.partSize = size
+	}
+}
+
+// WithConcurrency sets how many parts of a multipart upload or download are transferred in parallel. Defaults to 5.
+func WithConcurrency(n int) TransferOption {
+	return func(o *transferOptions) {
+		o.concurrency = n
+	}
+}
+
+// WithRange only downloads length bytes of the object, starting at offset. If length is 0 the object is read to the
+// end. The part size and concurrency are ignored for range downloads, the range is fetched with a single request.
+func WithRange(offset, length int64) TransferOption {
+	return func(o *transferOptions) {
+		o.offset = offset
+		o.length = length
+	}
+}
+
+// WithProgress sets a func which is called as the object is transferred.
+func WithProgress(progress ProgressFunc) TransferOption {
+	return func(o *transferOptions) {
+		o.progress = progress
+	}
+}
+
+// WithMetadata sets the user metadata stored with an uploaded or copied object. When copying, the metadata replaces
+// the metadata of the source object.
+func WithMetadata(metadata map[string]string) TransferOption {
+	return func(o *transferOptions) {
+		o.metadata = metadata
+	}
+}
+
+// WithTags sets the tags of an uploaded or copied object. When copying, the tags replace the tags of the source
+// object.
+func WithTags(tags map[string]string) TransferOption {
+	return func(o *transferOptions) {
+		o.tags = tags
+	}
+}
+
+// WithSourceBucket sets the bucket an object is copied or moved from. Defaults to the configured bucket.
+func WithSourceBucket(bucket string) TransferOption {
+	return func(o *transferOptions) {
+		o.srcBucket = bucket
+	}
+}
+
+// WithDestinationBucket sets the bucket an object is copied or moved to. Defaults to the configured bucket.
+func WithDestinationBucket(bucket string) TransferOption {
+	return func(o *transferOptions) {
+		o.dstBucket = bucket
+	}
+}
+
+// tagging returns the tags as the URL encoded query string S3 expects, or nil if there are no tags.
+func (o *transferOptions) tagging() *string {
+	if len(o.tags) == 0 {
+		return nil
+	}
+	values := url.Values{}
+	for k, v := range o.tags {
+		values.Set(k, v)
+	}
+	tagging := values.Encode()
+	return &tagging
+}
+
+// byteRange returns the HTTP Range header for the configured range, or nil if the whole object is read.
+func (o *transferOptions) byteRange() *string {
+	if o.offset == 0 && o.length == 0 {
+		return nil
+	}
+	if o.length <= 0 {
+		r := fmt.Sprintf("bytes=%d-", o.offset)
+		return &r
+	}
+	r := fmt.Sprintf("bytes=%d-%d", o.offset, o.offset+o.length-1)
+	return &r
+}
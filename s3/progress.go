@@ -0,0 +1,54 @@
+package s3
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// progress counts the bytes transferred and reports them to a ProgressFunc. It's safe for concurrent use, as the
+// multipart uploader and downloader transfer parts in parallel.
+type progress struct {
+	transferred int64
+	total       int64
+	report      ProgressFunc
+}
+
+func (p *progress) add(n int) {
+	if n <= 0 {
+		return
+	}
+	p.report(atomic.AddInt64(&p.transferred, int64(n)), p.total)
+}
+
+type progressReader struct {
+	io.Reader
+	*progress
+}
+
+func (r progressReader) Read(b []byte) (int, error) {
+	n, err := r.Reader.Read(b)
+	r.add(n)
+	return n, err
+}
+
+type progressReadCloser struct {
+	io.ReadCloser
+	*progress
+}
+
+func (r progressReadCloser) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	r.add(n)
+	return n, err
+}
+
+type progressWriterAt struct {
+	io.WriterAt
+	*progress
+}
+
+func (w progressWriterAt) WriteAt(b []byte, off int64) (int, error) {
+	n, err := w.WriterAt.WriteAt(b, off)
+	w.add(n)
+	return n, err
+}
@@ -0,0 +1,122 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultBlockSize is the size of each ranged read made by a ReaderAt.
+	DefaultBlockSize = 1 << 20
+	// readerAtBlocks is how many blocks a ReaderAt caches.
+	readerAtBlocks = 4
+)
+
+// ReaderAt reads an object with ranged GET requests, so formats with an index such as ZIP can be read without
+// downloading the whole object. Reads are aligned to blocks, and the most recently read blocks are cached so that
+// small sequential reads don't each make a request. Objects encrypted with WithClientSideEncryption can't be read
+// with ranged requests and return ErrEncryptedRange.
+type ReaderAt struct {
+	ctx       context.Context
+	service   Service
+	key       string
+	size      int64
+	blockSize int64
+	opts      []TransferOption
+
+	mu     sync.Mutex
+	blocks []*block
+}
+
+type block struct {
+	offset int64
+	data   []byte
+}
+
+// NewReaderAt returns a ReaderAt for the object at key. ctx is used for every read. WithPartSize sets the block
+// size, which defaults to DefaultBlockSize, and other options are passed to each read.
+func NewReaderAt(ctx context.Context, service Service, key string, opts ...TransferOption) (*ReaderAt, error) {
+	info, err := service.Head(ctx, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	blockSize := newTransferOptions(opts).partSize
+	if blockSize <= 0 {
+		blockSize = DefaultBlockSize
+	}
+	return &ReaderAt{
+		ctx:       ctx,
+		service:   service,
+		key:       key,
+		size:      info.Size,
+		blockSize: blockSize,
+		opts:      opts,
+	}, nil
+}
+
+// Size returns the size of the object.
+func (r *ReaderAt) Size() int64 {
+	return r.size
+}
+
+// ReadAt implements io.ReaderAt.
+func (r *ReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("s3: negative offset")
+	}
+	n := 0
+	for n < len(p) {
+		if off >= r.size {
+			return n, io.EOF
+		}
+		b, err := r.block(off - off%r.blockSize)
+		if err != nil {
+			return n, err
+		}
+		copied := copy(p[n:], b.data[off-b.offset:])
+		n += copied
+		off += int64(copied)
+	}
+	return n, nil
+}
+
+// block returns the block at offset, reading it if it isn't cached.
+func (r *ReaderAt) block(offset int64) (*block, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, b := range r.blocks {
+		if b.offset == offset {
+			// Move the block to the front so the least recently used block is evicted first.
+			copy(r.blocks[1:i+1], r.blocks[:i])
+			r.blocks[0] = b
+			return b, nil
+		}
+	}
+
+	length := r.blockSize
+	if offset+length > r.size {
+		length = r.size - offset
+	}
+	opts := append(append([]TransferOption{}, r.opts...), WithRange(offset, length))
+	body, _, err := r.service.Open(r.ctx, r.key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	b := &block{offset: offset, data: make([]byte, length)}
+	if _, err := io.ReadFull(body, b.data); err != nil {
+		return nil, errors.Wrapf(err, "read %s at %d", r.key, offset)
+	}
+
+	if len(r.blocks) < readerAtBlocks {
+		r.blocks = append(r.blocks, nil)
+	}
+	copy(r.blocks[1:], r.blocks)
+	r.blocks[0] = b
+	return b, nil
+}
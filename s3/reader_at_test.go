@@ -0,0 +1,90 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+
+	"github.com/HomesNZ/go-common/s3/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsS3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReaderAt", func() {
+	var (
+		server   *httptest.Server
+		service  s3
+		ranges   []string
+		contents = []byte("0123456789abcdefghij")
+	)
+
+	BeforeEach(func() {
+		ranges = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead {
+				w.Header().Set("Content-Length", strconv.Itoa(len(contents)))
+				return
+			}
+			ranges = append(ranges, r.Header.Get("Range"))
+			var start, end int
+			fmt.Sscanf(strings.TrimPrefix(r.Header.Get("Range"), "bytes="), "%d-%d", &start, &end)
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(contents)))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(contents[start : end+1])
+		}))
+		service = s3{
+			client: awsS3.New(awsS3.Options{
+				Region:           "ap-southeast-2",
+				Credentials:      aws.AnonymousCredentials{},
+				EndpointResolver: awsS3.EndpointResolverFromURL(server.URL),
+				UsePathStyle:     true,
+			}),
+			config: &config.Config{BucketName: "test-bucket"},
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("reads across blocks with ranged requests", func() {
+		r, err := NewReaderAt(context.Background(), service, "archive.zip", WithPartSize(8))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Size()).To(Equal(int64(20)))
+
+		p := make([]byte, 6)
+		n, err := r.ReadAt(p, 5)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(p[:n])).To(Equal("56789a"))
+		Expect(ranges).To(Equal([]string{"bytes=0-7", "bytes=8-15"}))
+	})
+
+	It("caches blocks", func() {
+		r, err := NewReaderAt(context.Background(), service, "archive.zip", WithPartSize(8))
+		Expect(err).NotTo(HaveOccurred())
+
+		p := make([]byte, 2)
+		for off := int64(0); off < 8; off += 2 {
+			_, err := r.ReadAt(p, off)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(ranges).To(HaveLen(1))
+	})
+
+	It("returns io.EOF at the end of the object", func() {
+		r, err := NewReaderAt(context.Background(), service, "archive.zip", WithPartSize(8))
+		Expect(err).NotTo(HaveOccurred())
+
+		p := make([]byte, 10)
+		n, err := r.ReadAt(p, 15)
+		Expect(err).To(Equal(io.EOF))
+		Expect(string(p[:n])).To(Equal("fghij"))
+		Expect(ranges).To(Equal([]string{"bytes=8-15", "bytes=16-19"}))
+	})
+})
@@ -3,7 +3,8 @@ package s3
 import (
 	"bytes"
 	"context"
-	"fmt"
+	"io"
+	"io/ioutil"
 
 	"github.com/HomesNZ/go-common/s3/config"
 
@@ -16,20 +17,32 @@ import (
 )
 
 type Service interface {
-	Upload(ctx context.Context, key string, b []byte, expiry time.Time, contentType string) (url string, err error)
+	Upload(ctx context.Context, key string, b []byte, expiry time.Time, contentType string, opts ...TransferOption) (url string, err error)
 	Delete(ctx context.Context, key string) error
-	Download(ctx context.Context, key string) ([]byte, error)
+	Download(ctx context.Context, key string, opts ...TransferOption) ([]byte, error)
+	UploadStream(ctx context.Context, key string, r io.Reader, opts ...TransferOption) (url string, err error)
+	DownloadTo(ctx context.Context, key string, w io.WriterAt, opts ...TransferOption) (int64, error)
+	Open(ctx context.Context, key string, opts ...TransferOption) (io.ReadCloser, ObjectInfo, error)
+	List(ctx context.Context, prefix string) *ObjectIterator
+	Head(ctx context.Context, key string, opts ...TransferOption) (ObjectInfo, error)
+	Exists(ctx context.Context, key string, opts ...TransferOption) (bool, error)
+	Copy(ctx context.Context, srcKey, dstKey string, opts ...TransferOption) error
+	Move(ctx context.Context, srcKey, dstKey string, opts ...TransferOption) error
+	DeleteMany(ctx context.Context, keys []string) error
 }
 
 // S3 is a concrete implementation of cdn.Interface backed by S3 and Cloudfront.
 type s3 struct {
-	client *awsS3.Client
-	config *config.Config
+	client     *awsS3.Client
+	config     *config.Config
+	keyWrapper KeyWrapper
 }
 
-// UploadAsset uploads a new asset to S3 with the provided key. The URL returned will be the Cloudfront asset url ifcc
-// S3.CloudfrontURL is not nil, otherwise a raw S3 URL is returned.
-func (s s3) Upload(ctx context.Context, key string, b []byte, expiry time.Time, contentType string) (url string, err error) {
+// Upload uploads a new asset to S3 with the provided key. The URL returned will be the Cloudfront asset url if
+// CloudfrontURL is configured, otherwise a raw S3 URL is returned. Metadata, tags and encryption can be set with
+// TransferOptions.
+func (s s3) Upload(ctx context.Context, key string, b []byte, expiry time.Time, contentType string, opts ...TransferOption) (url string, err error) {
+	o := newTransferOptions(opts)
 	reader := bytes.NewReader(b)
 
 	params := &awsS3.PutObjectInput{
@@ -39,6 +52,16 @@ func (s s3) Upload(ctx context.Context, key string, b []byte, expiry time.Time,
 		Body:          reader,
 		ContentLength: int64(reader.Len()),
 		ContentType:   &contentType,
+		Metadata:      o.metadata,
+		Tagging:       o.tagging(),
+	}
+	o.applyPut(params)
+	if s.keyWrapper != nil {
+		params.Body, params.Metadata, err = s.encrypt(ctx, reader, o.metadata)
+		if err != nil {
+			return "", errors.Wrap(err, "Failed to encrypt asset")
+		}
+		params.ContentLength = 0
 	}
 
 	if !expiry.IsZero() {
@@ -46,12 +69,11 @@ func (s s3) Upload(ctx context.Context, key string, b []byte, expiry time.Time,
 	}
 
 	uploader := manager.NewUploader(s.client)
-	result, err := uploader.Upload(ctx, params)
-	if err != nil {
+	if _, err := uploader.Upload(ctx, params); err != nil {
 		return "", errors.Wrap(err, "Failed to upload asset to aws S3 bucket")
 	}
 
-	return result.Location, nil
+	return s.assetURL(key), nil
 }
 
 func (s s3) Delete(ctx context.Context, key string) error {
@@ -68,14 +90,28 @@ func (s s3) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
-func (s s3) Download(ctx context.Context, key string) ([]byte, error) {
+// Download downloads the object with the provided key into memory. Client side encrypted objects are read with a
+// single request so they can be decrypted as they're read.
+func (s s3) Download(ctx context.Context, key string, opts ...TransferOption) ([]byte, error) {
+	if s.keyWrapper != nil {
+		r, _, err := s.Open(ctx, key, opts...)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to download asset from aws S3 bucket")
+		}
+		defer r.Close()
+		return ioutil.ReadAll(r)
+	}
+
+	o := newTransferOptions(opts)
+	params := &awsS3.GetObjectInput{
+		Bucket: &s.config.BucketName,
+		Key:    aws.String(key),
+	}
+	o.applyGet(params)
+
 	file := &manager.WriteAtBuffer{}
 	downloader := manager.NewDownloader(s.client)
-	_, err := downloader.Download(ctx, file,
-		&awsS3.GetObjectInput{
-			Bucket: &s.config.BucketName,
-			Key:    aws.String(key),
-		})
+	_, err := downloader.Download(ctx, file, params)
 
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to download asset from aws S3 bucket")
@@ -85,9 +121,5 @@ func (s s3) Download(ctx context.Context, key string) ([]byte, error) {
 }
 
 func (s s3) assetURL(key string) string {
-	if s.config.CloudfrontURL != "" {
-		return fmt.Sprintf("%s/%s", s.config.CloudfrontURL, key)
-	}
-
-	return fmt.Sprintf("%s/%s", s.config.Endpoint, key)
+	return s.config.AssetURL(key)
 }
@@ -4,40 +4,79 @@ import (
 	"context"
 
 	"github.com/HomesNZ/go-common/s3/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awsHttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	awsConfig "github.com/aws/aws-sdk-go-v2/config"
 	awsCred "github.com/aws/aws-sdk-go-v2/credentials"
 	awsS3 "github.com/aws/aws-sdk-go-v2/service/s3"
 )
 
+// Option configures the Service.
+type Option func(*s3)
+
 // New initializes a new S3. If cloudfrontURL is not nil, URLs returned from UploadAsset will return the assets URL on
 // Cloudfront distibution, otherwise the raw S3 URL will be returned.
-func New(ctx context.Context, cfg *config.Config) (Service, error) {
+func New(ctx context.Context, cfg *config.Config, options ...Option) (Service, error) {
 	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
-	return newService(ctx, cfg)
+	return newService(ctx, cfg, options...)
 }
 
-func NewFromEnv(ctx context.Context) (Service, error) {
+func NewFromEnv(ctx context.Context, options ...Option) (Service, error) {
 	cfg := config.NewFromEnv()
 	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
-	return newService(ctx, cfg)
+	return newService(ctx, cfg, options...)
 }
 
-func newService(ctx context.Context, cfg *config.Config) (Service, error) {
-	creds := awsCred.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken)
-	awsCfg, err := awsConfig.LoadDefaultConfig(ctx,
-		awsConfig.WithRegion(cfg.Region),
-		awsConfig.WithCredentialsProvider(creds),
-	)
+func newService(ctx context.Context, cfg *config.Config, options ...Option) (Service, error) {
+	awsCfg, err := awsConfig.LoadDefaultConfig(ctx, loadOptions(cfg)...)
 	if err != nil {
 		return nil, err
 	}
-	client := awsS3.NewFromConfig(awsCfg)
-	return &s3{
+	client := awsS3.NewFromConfig(awsCfg, func(o *awsS3.Options) {
+		o.UsePathStyle = cfg.UsePathStyle
+		if cfg.Endpoint != "" {
+			o.EndpointResolver = awsS3.EndpointResolverFromURL(cfg.EndpointURL())
+		}
+	})
+	s := &s3{
 		client: client,
 		config: cfg,
-	}, nil
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+	return s, nil
+}
+
+// loadOptions returns the AWS config options for cfg. Static credentials are only used when keys are configured,
+// otherwise the default credential chain finds them, e.g. from the ECS task or Lambda role.
+func loadOptions(cfg *config.Config) []func(*awsConfig.LoadOptions) error {
+	options := []func(*awsConfig.LoadOptions) error{
+		awsConfig.WithRegion(cfg.Region),
+	}
+	if cfg.AccessKeyID != "" {
+		creds := awsCred.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken)
+		options = append(options, awsConfig.WithCredentialsProvider(creds))
+	}
+	if cfg.MaxAttempts > 0 || cfg.MaxBackoff > 0 {
+		options = append(options, awsConfig.WithRetryer(func() aws.Retryer {
+			return retry.NewStandard(func(o *retry.StandardOptions) {
+				if cfg.MaxAttempts > 0 {
+					o.MaxAttempts = cfg.MaxAttempts
+				}
+				if cfg.MaxBackoff > 0 {
+					o.MaxBackoff = cfg.MaxBackoff
+				}
+			})
+		}))
+	}
+	if cfg.Timeout > 0 {
+		options = append(options, awsConfig.WithHTTPClient(awsHttp.NewBuildableClient().WithTimeout(cfg.Timeout)))
+	}
+	return options
 }
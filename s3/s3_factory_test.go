@@ -0,0 +1,39 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/HomesNZ/go-common/s3/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("newService", func() {
+	It("uses the custom endpoint with path style", func() {
+		var path string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+		}))
+		defer server.Close()
+
+		service, err := New(context.Background(), &config.Config{
+			BucketName:      "test-bucket",
+			Region:          "ap-southeast-2",
+			Endpoint:        server.URL,
+			UsePathStyle:    true,
+			AccessKeyID:     "key-id",
+			SecretAccessKey: "secret",
+			MaxAttempts:     1,
+			Timeout:         time.Second,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		url, err := service.Upload(context.Background(), "photos/1.jpg", []byte("photo"), time.Time{}, "image/jpeg")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(path).To(Equal("/test-bucket/photos/1.jpg"))
+		Expect(url).To(Equal(server.URL + "/test-bucket/photos/1.jpg"))
+	})
+})
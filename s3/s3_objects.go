@@ -0,0 +1,253 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsHttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsS3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/pkg/errors"
+)
+
+// maxDeleteKeys is the maximum number of keys DeleteObjects accepts in a single request.
+const maxDeleteKeys = 1000
+
+// ObjectIterator iterates over the objects returned by List, fetching a page at a time.
+//
+//	it := service.List(ctx, "imports/")
+//	for it.Next() {
+//		object := it.Object()
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+type ObjectIterator struct {
+	ctx       context.Context
+	paginator *awsS3.ListObjectsV2Paginator
+	page      []types.Object
+	object    ObjectInfo
+	err       error
+}
+
+// Next advances the iterator to the next object. It returns false when there are no more objects or an error
+// occurred.
+func (it *ObjectIterator) Next() bool {
+	for len(it.page) == 0 {
+		if it.err != nil || !it.paginator.HasMorePages() {
+			return false
+		}
+		output, err := it.paginator.NextPage(it.ctx)
+		if err != nil {
+			it.err = errors.Wrap(err, "Failed to list assets in aws S3 bucket")
+			return false
+		}
+		it.page = output.Contents
+	}
+
+	object := it.page[0]
+	it.page = it.page[1:]
+	it.object = ObjectInfo{
+		Key:  aws.ToString(object.Key),
+		Size: object.Size,
+		ETag: aws.ToString(object.ETag),
+	}
+	if object.LastModified != nil {
+		it.object.LastModified = *object.LastModified
+	}
+	return true
+}
+
+// Object returns the current object.
+func (it *ObjectIterator) Object() ObjectInfo {
+	return it.object
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *ObjectIterator) Err() error {
+	return it.err
+}
+
+// List returns an iterator over the objects whose keys start with prefix.
+func (s s3) List(ctx context.Context, prefix string) *ObjectIterator {
+	return &ObjectIterator{
+		ctx: ctx,
+		paginator: awsS3.NewListObjectsV2Paginator(s.client, &awsS3.ListObjectsV2Input{
+			Bucket: &s.config.BucketName,
+			Prefix: aws.String(prefix),
+		}),
+	}
+}
+
+// Head returns the details of the object with the provided key, without downloading it.
+func (s s3) Head(ctx context.Context, key string, opts ...TransferOption) (ObjectInfo, error) {
+	return s.head(ctx, s.config.BucketName, key, newTransferOptions(opts))
+}
+
+func (s s3) head(ctx context.Context, bucket, key string, o *transferOptions) (ObjectInfo, error) {
+	params := &awsS3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	o.applyHead(params)
+
+	output, err := s.client.HeadObject(ctx, params)
+	if err != nil {
+		return ObjectInfo{}, errors.Wrap(err, "Failed to head asset in aws S3 bucket")
+	}
+
+	info := ObjectInfo{
+		Key:         key,
+		Size:        output.ContentLength,
+		ContentType: aws.ToString(output.ContentType),
+		ETag:        aws.ToString(output.ETag),
+		Metadata:    output.Metadata,
+	}
+	if output.LastModified != nil {
+		info.LastModified = *output.LastModified
+	}
+	if s.isEncrypted(output.Metadata) {
+		info.Size = plaintextSize(info.Size)
+		info.Metadata = userMetadata(info.Metadata)
+	}
+	return info, nil
+}
+
+// Exists returns whether an object with the provided key exists.
+func (s s3) Exists(ctx context.Context, key string, opts ...TransferOption) (bool, error) {
+	_, err := s.Head(ctx, key, opts...)
+	if err == nil {
+		return true, nil
+	}
+	if IsNotFound(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// IsNotFound returns whether err was caused by the object or bucket not existing.
+func IsNotFound(err error) bool {
+	var responseErr *awsHttp.ResponseError
+	return errors.As(err, &responseErr) && responseErr.HTTPStatusCode() == http.StatusNotFound
+}
+
+// Copy copies the object at srcKey to dstKey. Use WithSourceBucket and WithDestinationBucket to copy between buckets.
+// The copy keeps the metadata and tags of the source object unless WithMetadata or WithTags are used, and is
+// encrypted at rest with the SSE options. Client side encrypted objects stay encrypted with the same data key. Objects
+// larger than 5GB can't be copied with a single request and aren't supported.
+func (s s3) Copy(ctx context.Context, srcKey, dstKey string, opts ...TransferOption) error {
+	o := newTransferOptions(opts)
+	srcBucket, dstBucket := s.buckets(o)
+
+	params := &awsS3.CopyObjectInput{
+		Bucket:     aws.String(dstBucket),
+		Key:        aws.String(dstKey),
+		CopySource: aws.String(url.PathEscape(fmt.Sprintf("%s/%s", srcBucket, srcKey))),
+		ACL:        s.config.ACL,
+	}
+	if o.metadata != nil {
+		params.Metadata = o.metadata
+		params.MetadataDirective = types.MetadataDirectiveReplace
+		if s.keyWrapper != nil {
+			// Replacing the metadata would drop the wrapped data key of a client side encrypted object.
+			metadata, err := s.encryptionMetadata(ctx, srcBucket, srcKey, o)
+			if err != nil {
+				return errors.Wrap(err, "Failed to copy asset in aws S3 bucket")
+			}
+			for k, v := range o.metadata {
+				metadata[k] = v
+			}
+			params.Metadata = metadata
+		}
+	}
+	o.applyCopy(params)
+	if o.tags != nil {
+		params.Tagging = o.tagging()
+		params.TaggingDirective = types.TaggingDirectiveReplace
+	}
+
+	if _, err := s.client.CopyObject(ctx, params); err != nil {
+		return errors.Wrap(err, "Failed to copy asset in aws S3 bucket")
+	}
+	return nil
+}
+
+// encryptionMetadata returns the client side encryption metadata of an object, which is empty if the object isn't
+// client side encrypted.
+func (s s3) encryptionMetadata(ctx context.Context, bucket, key string, o *transferOptions) (map[string]string, error) {
+	params := &awsS3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	o.applyHead(params)
+
+	output, err := s.client.HeadObject(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	metadata := map[string]string{}
+	for _, k := range []string{cseKeyMetadata, cseAlgorithmMetadata} {
+		if v, ok := output.Metadata[k]; ok {
+			metadata[k] = v
+		}
+	}
+	return metadata, nil
+}
+
+// Move copies the object at srcKey to dstKey, then deletes the source object. It accepts the same options as Copy.
+func (s s3) Move(ctx context.Context, srcKey, dstKey string, opts ...TransferOption) error {
+	if err := s.Copy(ctx, srcKey, dstKey, opts...); err != nil {
+		return err
+	}
+
+	srcBucket, _ := s.buckets(newTransferOptions(opts))
+	_, err := s.client.DeleteObject(ctx, &awsS3.DeleteObjectInput{
+		Bucket: aws.String(srcBucket),
+		Key:    aws.String(srcKey),
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to delete moved asset from aws S3 bucket")
+	}
+	return nil
+}
+
+// DeleteMany deletes the objects with the provided keys, in batches of 1000. Keys which don't exist are ignored.
+func (s s3) DeleteMany(ctx context.Context, keys []string) error {
+	for start := 0; start < len(keys); start += maxDeleteKeys {
+		end := start + maxDeleteKeys
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		objects := make([]types.ObjectIdentifier, 0, end-start)
+		for _, key := range keys[start:end] {
+			objects = append(objects, types.ObjectIdentifier{Key: aws.String(key)})
+		}
+		output, err := s.client.DeleteObjects(ctx, &awsS3.DeleteObjectsInput{
+			Bucket: &s.config.BucketName,
+			Delete: &types.Delete{Objects: objects, Quiet: true},
+		})
+		if err != nil {
+			return errors.Wrap(err, "Failed to delete assets from aws S3 bucket")
+		}
+		if len(output.Errors) > 0 {
+			failed := output.Errors[0]
+			return errors.Errorf("Failed to delete %d assets from aws S3 bucket, %s: %s", len(output.Errors), aws.ToString(failed.Key), aws.ToString(failed.Message))
+		}
+	}
+	return nil
+}
+
+func (s s3) buckets(o *transferOptions) (src, dst string) {
+	src, dst = s.config.BucketName, s.config.BucketName
+	if o.srcBucket != "" {
+		src = o.srcBucket
+	}
+	if o.dstBucket != "" {
+		dst = o.dstBucket
+	}
+	return src, dst
+}
@@ -0,0 +1,95 @@
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/HomesNZ/go-common/s3/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsS3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Objects", func() {
+	var (
+		server  *httptest.Server
+		service s3
+		deletes []int
+	)
+
+	BeforeEach(func() {
+		deletes = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == http.MethodHead && r.URL.Path == "/test-bucket/missing":
+				w.WriteHeader(http.StatusNotFound)
+			case r.Method == http.MethodHead:
+				w.Header().Set("Content-Length", "5")
+				w.Header().Set("x-amz-meta-source", "import")
+			case r.Method == http.MethodPost && r.URL.Query()["delete"] != nil:
+				body := struct {
+					Objects []struct{ Key string } `xml:"Object"`
+				}{}
+				Expect(xml.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+				deletes = append(deletes, len(body.Objects))
+				w.Write([]byte(`<DeleteResult></DeleteResult>`))
+			}
+		}))
+		service = s3{
+			client: awsS3.New(awsS3.Options{
+				Region:           "ap-southeast-2",
+				Credentials:      aws.AnonymousCredentials{},
+				EndpointResolver: awsS3.EndpointResolverFromURL(server.URL),
+				UsePathStyle:     true,
+			}),
+			config: &config.Config{BucketName: "test-bucket"},
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("#Exists", func() {
+		It("returns true for an existing object", func() {
+			exists, err := service.Exists(context.Background(), "found")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exists).To(BeTrue())
+		})
+		It("returns false for a missing object", func() {
+			exists, err := service.Exists(context.Background(), "missing")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exists).To(BeFalse())
+		})
+	})
+
+	Describe("#Head", func() {
+		It("returns the metadata", func() {
+			info, err := service.Head(context.Background(), "found")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Size).To(Equal(int64(5)))
+			Expect(info.Metadata).To(HaveKeyWithValue("source", "import"))
+		})
+	})
+
+	Describe("#DeleteMany", func() {
+		It("deletes in chunks of 1000 keys", func() {
+			keys := make([]string, 2500)
+			for i := range keys {
+				keys[i] = "key"
+			}
+			Expect(service.DeleteMany(context.Background(), keys)).To(Succeed())
+			Expect(deletes).To(Equal([]int{1000, 1000, 500}))
+		})
+	})
+})
+
+var _ = Describe("#tagging", func() {
+	It("encodes the tags", func() {
+		o := newTransferOptions([]TransferOption{WithTags(map[string]string{"type": "import", "owner": "a&b"})})
+		Expect(*o.tagging()).To(Equal("owner=a%26b&type=import"))
+	})
+})
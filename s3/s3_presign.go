@@ -2,35 +2,139 @@ package s3
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/HomesNZ/go-common/s3/config"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	awsS3 "github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/pkg/errors"
 )
 
+// unsignedPayload is the payload hash used for presigned S3 requests, as the body isn't known when the URL is signed.
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
 type PresignService interface {
-	SignedPutObjectUrl(ctx context.Context, bucket, key, contentType string) (string, error)
+	SignedPutObjectUrl(ctx context.Context, bucket, key, contentType string, expiry time.Duration) (string, error)
+	SignedGetObjectUrl(ctx context.Context, bucket, key, contentDisposition string, expiry time.Duration) (string, error)
+	SignedDeleteObjectUrl(ctx context.Context, bucket, key string, expiry time.Duration) (string, error)
+	SignedPostPolicy(ctx context.Context, bucket, key string, conditions PostConditions, expiry time.Duration) (*PostPolicy, error)
 }
 
 // presignS3 is a concrete implementation of presigned s3 client
 type presignS3 struct {
 	presignClient *awsS3.PresignClient
 	presignConfig *config.PresignConfig
+	credentials   aws.CredentialsProvider
+	now           func() time.Time
 }
 
-// SignedPutObjectUrl is to get a presigned URL for uploading an object
-func (s presignS3) SignedPutObjectUrl(ctx context.Context, bucket, key, contentType string) (string, error) {
+// SignedPutObjectUrl is to get a presigned URL for uploading an object, valid for expiry. The content type isn't part
+// of the signature, so uploads with another Content-Type are accepted; use SignedPostPolicy to restrict it.
+func (s presignS3) SignedPutObjectUrl(ctx context.Context, bucket, key, contentType string, expiry time.Duration) (string, error) {
 	params := &awsS3.PutObjectInput{
 		Bucket:      aws.String(bucket),
 		Key:         aws.String(key),
 		ContentType: aws.String(contentType),
 	}
 
-	result, err := s.presignClient.PresignPutObject(ctx, params)
+	result, err := s.presignClient.PresignPutObject(ctx, params, awsS3.WithPresignExpires(expiry))
 	if err != nil {
 		return "", errors.Wrap(err, "PresignPutObject")
 	}
 
 	return result.URL, nil
 }
+
+// SignedGetObjectUrl is to get a presigned URL for downloading an object, valid for expiry. If contentDisposition is
+// not empty it overrides the Content-Disposition header of the response, e.g. `attachment; filename="report.pdf"` to
+// make browsers download the object with a friendly name.
+func (s presignS3) SignedGetObjectUrl(ctx context.Context, bucket, key, contentDisposition string, expiry time.Duration) (string, error) {
+	params := &awsS3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if contentDisposition != "" {
+		params.ResponseContentDisposition = aws.String(contentDisposition)
+	}
+
+	result, err := s.presignClient.PresignGetObject(ctx, params, awsS3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", errors.Wrap(err, "PresignGetObject")
+	}
+
+	return result.URL, nil
+}
+
+// SignedDeleteObjectUrl is to get a presigned URL for deleting an object, valid for expiry.
+func (s presignS3) SignedDeleteObjectUrl(ctx context.Context, bucket, key string, expiry time.Duration) (string, error) {
+	creds, err := s.credentials.Retrieve(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "PresignDeleteObject")
+	}
+
+	u := s.bucketURL(bucket)
+	u.Path += key
+	// The path is escaped the way the SDK escapes keys, as the signer signs the escaped path as is.
+	u.RawPath = escapePath(u.Path)
+	query := url.Values{}
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(expiry/time.Second), 10))
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return "", errors.Wrap(err, "PresignDeleteObject")
+	}
+
+	signer := v4.NewSigner(func(o *v4.SignerOptions) {
+		o.DisableURIPathEscaping = true
+	})
+	signed, _, err := signer.PresignHTTP(ctx, creds, req, unsignedPayload, "s3", s.presignConfig.Region, s.now())
+	if err != nil {
+		return "", errors.Wrap(err, "PresignDeleteObject")
+	}
+
+	return signed, nil
+}
+
+// bucketURL returns the URL of bucket, ending in a /, on the configured endpoint or AWS.
+func (s presignS3) bucketURL(bucket string) *url.URL {
+	u := &url.URL{
+		Scheme: "https",
+		Host:   fmt.Sprintf("s3.%s.amazonaws.com", s.presignConfig.Region),
+		Path:   "/",
+	}
+	if s.presignConfig.Endpoint != "" {
+		if endpoint, err := url.Parse(s.presignConfig.EndpointURL()); err == nil {
+			u.Scheme = endpoint.Scheme
+			u.Host = endpoint.Host
+			u.Path = strings.TrimSuffix(endpoint.Path, "/") + "/"
+		}
+	}
+	if s.presignConfig.UsePathStyle {
+		u.Path += bucket + "/"
+	} else {
+		u.Host = bucket + "." + u.Host
+	}
+	return u
+}
+
+// escapePath escapes each segment of path with the strict RFC 3986 rules used by SigV4, keeping the / separators.
+func escapePath(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-._~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -2,6 +2,7 @@ package s3
 
 import (
 	"context"
+	"time"
 
 	"github.com/HomesNZ/go-common/s3/config"
 	awsConfig "github.com/aws/aws-sdk-go-v2/config"
@@ -27,7 +28,7 @@ func NewPresignServiceFromEnv(ctx context.Context) (PresignService, error) {
 }
 
 func newPresignService(ctx context.Context, cfg *config.PresignConfig) (PresignService, error) {
-	creds := awsCred.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, "")
+	creds := awsCred.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken)
 	awsCfg, err := awsConfig.LoadDefaultConfig(ctx,
 		awsConfig.WithRegion(cfg.Region),
 		awsConfig.WithCredentialsProvider(creds),
@@ -35,10 +36,22 @@ func newPresignService(ctx context.Context, cfg *config.PresignConfig) (PresignS
 	if err != nil {
 		return nil, err
 	}
-	client := awsS3.NewFromConfig(awsCfg)
+	client := awsS3.NewFromConfig(awsCfg, presignClientOptions(cfg))
 	presignClient := awsS3.NewPresignClient(client)
 	return &presignS3{
 		presignClient: presignClient,
 		presignConfig: cfg,
+		credentials:   awsCfg.Credentials,
+		now:           time.Now,
 	}, nil
 }
+
+// presignClientOptions addresses buckets the same way as the Service, so that URLs are signed for cfg's endpoint.
+func presignClientOptions(cfg *config.PresignConfig) func(*awsS3.Options) {
+	return func(o *awsS3.Options) {
+		o.UsePathStyle = cfg.UsePathStyle
+		if cfg.Endpoint != "" {
+			o.EndpointResolver = awsS3.EndpointResolverFromURL(cfg.EndpointURL())
+		}
+	}
+}
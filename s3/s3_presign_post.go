@@ -0,0 +1,126 @@
+package s3
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	postAlgorithm  = "AWS4-HMAC-SHA256"
+	postDateFormat = "20060102"
+	postTimeFormat = "20060102T150405Z"
+)
+
+// PostConditions restrict what a browser can upload with a POST policy. S3 rejects uploads which don't match them.
+type PostConditions struct {
+	// KeyPrefix allows the form to choose any key starting with the prefix, instead of the exact key the policy was
+	// signed for. The key field of the policy is set to the signed key and can be changed by the form.
+	KeyPrefix bool
+	// ContentType is the exact content type the upload must have. Ignored if ContentTypePrefix is set.
+	ContentType string
+	// ContentTypePrefix is a prefix the content type must start with, e.g. "image/".
+	ContentTypePrefix string
+	// MinContentLength and MaxContentLength are the allowed size of the upload in bytes. The length isn't restricted
+	// if MaxContentLength is 0.
+	MinContentLength int64
+	MaxContentLength int64
+	// ACL is the canned ACL of the uploaded object, which the form must send unchanged.
+	ACL string
+}
+
+// PostPolicy is a signed POST policy. The browser posts a multipart form to URL with Fields, followed by a "file"
+// field with the content of the upload.
+type PostPolicy struct {
+	URL    string
+	Fields map[string]string
+}
+
+// SignedPostPolicy signs a POST policy, valid for expiry, which lets a browser upload directly to bucket with the
+// restrictions in conditions.
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-HTTPPOSTConstructPolicy.html
+func (s presignS3) SignedPostPolicy(ctx context.Context, bucket, key string, conditions PostConditions, expiry time.Duration) (*PostPolicy, error) {
+	creds, err := s.credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "SignedPostPolicy")
+	}
+
+	now := s.now().UTC()
+	credential := fmt.Sprintf("%s/%s/%s/s3/aws4_request", creds.AccessKeyID, now.Format(postDateFormat), s.presignConfig.Region)
+
+	fields := map[string]string{
+		"key":              key,
+		"x-amz-algorithm":  postAlgorithm,
+		"x-amz-credential": credential,
+		"x-amz-date":       now.Format(postTimeFormat),
+	}
+	policyConditions := []interface{}{
+		map[string]string{"bucket": bucket},
+	}
+	if conditions.KeyPrefix {
+		policyConditions = append(policyConditions, []string{"starts-with", "$key", key})
+	} else {
+		policyConditions = append(policyConditions, map[string]string{"key": key})
+	}
+	switch {
+	case conditions.ContentTypePrefix != "":
+		policyConditions = append(policyConditions, []string{"starts-with", "$Content-Type", conditions.ContentTypePrefix})
+	case conditions.ContentType != "":
+		fields["Content-Type"] = conditions.ContentType
+		policyConditions = append(policyConditions, map[string]string{"Content-Type": conditions.ContentType})
+	}
+	if conditions.MaxContentLength > 0 {
+		policyConditions = append(policyConditions, []interface{}{"content-length-range", conditions.MinContentLength, conditions.MaxContentLength})
+	}
+	if conditions.ACL != "" {
+		fields["acl"] = conditions.ACL
+		policyConditions = append(policyConditions, map[string]string{"acl": conditions.ACL})
+	}
+	if creds.SessionToken != "" {
+		fields["x-amz-security-token"] = creds.SessionToken
+	}
+	for _, field := range []string{"x-amz-algorithm", "x-amz-credential", "x-amz-date", "x-amz-security-token"} {
+		if value, ok := fields[field]; ok {
+			policyConditions = append(policyConditions, map[string]string{field: value})
+		}
+	}
+
+	policy, err := json.Marshal(map[string]interface{}{
+		"expiration": now.Add(expiry).Format(time.RFC3339),
+		"conditions": policyConditions,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "SignedPostPolicy")
+	}
+	encoded := base64.StdEncoding.EncodeToString(policy)
+	fields["policy"] = encoded
+	fields["x-amz-signature"] = postSignature(creds.SecretAccessKey, now, s.presignConfig.Region, encoded)
+
+	return &PostPolicy{
+		URL:    strings.TrimSuffix(s.bucketURL(bucket).String(), "/"),
+		Fields: fields,
+	}, nil
+}
+
+// postSignature signs the base64 encoded policy with the SigV4 signing key for the date and region.
+func postSignature(secret string, date time.Time, region, policy string) string {
+	key := hmacSHA256([]byte("AWS4"+secret), date.Format(postDateFormat))
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, policy))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,170 @@
+package s3
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/HomesNZ/go-common/s3/config"
+	awsCred "github.com/aws/aws-sdk-go-v2/credentials"
+	awsS3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// newTestPresignService returns a presignS3 for cfg which signs with fixed credentials at now.
+func newTestPresignService(cfg *config.PresignConfig, now time.Time) presignS3 {
+	credentials := awsCred.NewStaticCredentialsProvider("key-id", "secret", "token")
+	return presignS3{
+		presignClient: awsS3.NewPresignClient(awsS3.New(awsS3.Options{
+			Region:      cfg.Region,
+			Credentials: credentials,
+		}, presignClientOptions(cfg))),
+		presignConfig: cfg,
+		credentials:   credentials,
+		now:           func() time.Time { return now },
+	}
+}
+
+// withoutQuery returns the scheme, host and escaped path of a signed URL.
+func withoutQuery(signed string) string {
+	return strings.SplitN(signed, "?", 2)[0]
+}
+
+var _ = Describe("Presign", func() {
+	now := time.Date(2021, 11, 2, 3, 4, 5, 0, time.UTC)
+	service := newTestPresignService(&config.PresignConfig{Region: "ap-southeast-2"}, now)
+
+	Describe("#SignedPutObjectUrl", func() {
+		It("signs a put request", func() {
+			signed, err := service.SignedPutObjectUrl(context.Background(), "test-bucket", "photos/1.jpg", "image/jpeg", 15*time.Minute)
+			Expect(err).NotTo(HaveOccurred())
+
+			u, err := url.Parse(signed)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(u.Host).To(Equal("test-bucket.s3.ap-southeast-2.amazonaws.com"))
+			Expect(u.Path).To(Equal("/photos/1.jpg"))
+			Expect(u.Query().Get("X-Amz-Expires")).To(Equal("900"))
+			// The SDK doesn't sign the content type, so it isn't enforced on upload.
+			Expect(u.Query().Get("X-Amz-SignedHeaders")).To(Equal("host"))
+			Expect(u.Query().Get("X-Amz-Credential")).To(HavePrefix("key-id/"))
+			Expect(u.Query().Get("X-Amz-Security-Token")).To(Equal("token"))
+			Expect(u.Query().Get("X-Amz-Signature")).NotTo(BeEmpty())
+		})
+	})
+
+	Describe("#SignedGetObjectUrl", func() {
+		It("signs a get request", func() {
+			signed, err := service.SignedGetObjectUrl(context.Background(), "test-bucket", "photos/1.jpg", "", time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+
+			u, err := url.Parse(signed)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(u.Host).To(Equal("test-bucket.s3.ap-southeast-2.amazonaws.com"))
+			Expect(u.Path).To(Equal("/photos/1.jpg"))
+			Expect(u.Query().Get("X-Amz-Expires")).To(Equal("3600"))
+			Expect(u.Query().Get("X-Amz-SignedHeaders")).To(Equal("host"))
+			Expect(u.Query().Get("X-Amz-Security-Token")).To(Equal("token"))
+			Expect(u.Query().Get("X-Amz-Signature")).NotTo(BeEmpty())
+			Expect(u.Query()).NotTo(HaveKey("response-content-disposition"))
+		})
+
+		It("overrides the content disposition of the response", func() {
+			signed, err := service.SignedGetObjectUrl(context.Background(), "test-bucket", "reports/1.pdf", `attachment; filename="report.pdf"`, time.Minute)
+			Expect(err).NotTo(HaveOccurred())
+
+			u, err := url.Parse(signed)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(u.Query().Get("X-Amz-Expires")).To(Equal("60"))
+			Expect(u.Query().Get("X-Amz-SignedHeaders")).To(Equal("host"))
+			Expect(u.Query().Get("response-content-disposition")).To(Equal(`attachment; filename="report.pdf"`))
+		})
+	})
+
+	Describe("#SignedDeleteObjectUrl", func() {
+		It("signs a delete request", func() {
+			signed, err := service.SignedDeleteObjectUrl(context.Background(), "test-bucket", "photos/1.jpg", time.Minute)
+			Expect(err).NotTo(HaveOccurred())
+
+			u, err := url.Parse(signed)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(u.Host).To(Equal("test-bucket.s3.ap-southeast-2.amazonaws.com"))
+			Expect(u.Path).To(Equal("/photos/1.jpg"))
+			Expect(u.Query().Get("X-Amz-Expires")).To(Equal("60"))
+			Expect(u.Query().Get("X-Amz-Security-Token")).To(Equal("token"))
+			Expect(u.Query().Get("X-Amz-Signature")).NotTo(BeEmpty())
+		})
+
+		It("escapes the key the way the SDK does", func() {
+			key := "photos/a b+c=d:e~(1)é.jpg"
+			signed, err := service.SignedDeleteObjectUrl(context.Background(), "test-bucket", key, time.Minute)
+			Expect(err).NotTo(HaveOccurred())
+			get, err := service.SignedGetObjectUrl(context.Background(), "test-bucket", key, "", time.Minute)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(withoutQuery(signed)).To(Equal("https://test-bucket.s3.ap-southeast-2.amazonaws.com/photos/a%20b%2Bc%3Dd%3Ae~%281%29%C3%A9.jpg"))
+			Expect(withoutQuery(signed)).To(Equal(withoutQuery(get)))
+		})
+
+		It("uses the configured endpoint with path style", func() {
+			service := newTestPresignService(&config.PresignConfig{
+				Region:       "ap-southeast-2",
+				Endpoint:     "http://localhost:9000",
+				UsePathStyle: true,
+			}, now)
+			signed, err := service.SignedDeleteObjectUrl(context.Background(), "test-bucket", "photos/a b.jpg", time.Minute)
+			Expect(err).NotTo(HaveOccurred())
+			get, err := service.SignedGetObjectUrl(context.Background(), "test-bucket", "photos/a b.jpg", "", time.Minute)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(withoutQuery(signed)).To(Equal("http://localhost:9000/test-bucket/photos/a%20b.jpg"))
+			Expect(withoutQuery(signed)).To(Equal(withoutQuery(get)))
+		})
+
+		It("uses the configured endpoint with virtual hosted style", func() {
+			service := newTestPresignService(&config.PresignConfig{
+				Region:   "ap-southeast-2",
+				Endpoint: "s3.example.com",
+			}, now)
+			signed, err := service.SignedDeleteObjectUrl(context.Background(), "test-bucket", "photos/1.jpg", time.Minute)
+			Expect(err).NotTo(HaveOccurred())
+			get, err := service.SignedGetObjectUrl(context.Background(), "test-bucket", "photos/1.jpg", "", time.Minute)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(withoutQuery(signed)).To(Equal("https://test-bucket.s3.example.com/photos/1.jpg"))
+			Expect(withoutQuery(signed)).To(Equal(withoutQuery(get)))
+		})
+	})
+
+	Describe("#SignedPostPolicy", func() {
+		It("signs a policy with the conditions", func() {
+			policy, err := service.SignedPostPolicy(context.Background(), "test-bucket", "photos/", PostConditions{
+				KeyPrefix:         true,
+				ContentTypePrefix: "image/",
+				MaxContentLength:  10 << 20,
+			}, time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policy.URL).To(Equal("https://test-bucket.s3.ap-southeast-2.amazonaws.com"))
+			Expect(policy.Fields).To(HaveKeyWithValue("key", "photos/"))
+			Expect(policy.Fields).To(HaveKeyWithValue("x-amz-credential", "key-id/20211102/ap-southeast-2/s3/aws4_request"))
+			Expect(policy.Fields).To(HaveKeyWithValue("x-amz-date", "20211102T030405Z"))
+			Expect(policy.Fields).To(HaveKeyWithValue("x-amz-security-token", "token"))
+			Expect(policy.Fields).To(HaveKey("x-amz-signature"))
+
+			b, err := base64.StdEncoding.DecodeString(policy.Fields["policy"])
+			Expect(err).NotTo(HaveOccurred())
+			decoded := struct {
+				Expiration string        `json:"expiration"`
+				Conditions []interface{} `json:"conditions"`
+			}{}
+			Expect(json.Unmarshal(b, &decoded)).To(Succeed())
+			Expect(decoded.Expiration).To(Equal("2021-11-02T04:04:05Z"))
+			Expect(decoded.Conditions).To(ContainElement([]interface{}{"starts-with", "$key", "photos/"}))
+			Expect(decoded.Conditions).To(ContainElement([]interface{}{"starts-with", "$Content-Type", "image/"}))
+			Expect(decoded.Conditions).To(ContainElement([]interface{}{"content-length-range", float64(0), float64(10 << 20)}))
+		})
+	})
+})
@@ -0,0 +1,180 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	awsS3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/pkg/errors"
+)
+
+// ObjectInfo describes an object stored in S3. ContentType and Metadata aren't returned when listing objects. The
+// Size of client side encrypted objects is the size of the plaintext, except when listing.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+	Metadata     map[string]string
+}
+
+// UploadStream uploads the contents of r to S3 with the provided key, using a multipart upload so the object doesn't
+// need to fit in memory. The URL returned is the same as Upload.
+func (s s3) UploadStream(ctx context.Context, key string, r io.Reader, opts ...TransferOption) (url string, err error) {
+	o := newTransferOptions(opts)
+	if o.progress != nil {
+		r = progressReader{Reader: r, progress: &progress{total: -1, report: o.progress}}
+	}
+
+	metadata := o.metadata
+	if s.keyWrapper != nil {
+		r, metadata, err = s.encrypt(ctx, r, o.metadata)
+		if err != nil {
+			return "", errors.Wrap(err, "Failed to encrypt asset")
+		}
+	}
+
+	params := &awsS3.PutObjectInput{
+		Key:      aws.String(key),
+		Bucket:   &s.config.BucketName,
+		ACL:      s.config.ACL,
+		Body:     r,
+		Metadata: metadata,
+		Tagging:  o.tagging(),
+	}
+	if o.contentType != "" {
+		params.ContentType = aws.String(o.contentType)
+	}
+	if !o.expiry.IsZero() {
+		params.Expires = &o.expiry
+	}
+	o.applyPut(params)
+
+	uploader := manager.NewUploader(s.client, func(u *manager.Uploader) {
+		if o.partSize > 0 {
+			u.PartSize = o.partSize
+		}
+		if o.concurrency > 0 {
+			u.Concurrency = o.concurrency
+		}
+	})
+	if _, err := uploader.Upload(ctx, params); err != nil {
+		return "", errors.Wrap(err, "Failed to upload asset to aws S3 bucket")
+	}
+
+	return s.assetURL(key), nil
+}
+
+// DownloadTo downloads the object with the provided key into w, fetching parts in parallel, and returns the number of
+// bytes written. When WithRange is used the range is written to w starting at offset 0. Client side encrypted objects
+// are downloaded with a single request and decrypted as they're written.
+func (s s3) DownloadTo(ctx context.Context, key string, w io.WriterAt, opts ...TransferOption) (int64, error) {
+	o := newTransferOptions(opts)
+
+	if s.keyWrapper != nil {
+		info, err := s.Head(ctx, key, opts...)
+		if err != nil {
+			return 0, errors.Wrap(err, "Failed to download asset from aws S3 bucket")
+		}
+		if s.isEncrypted(info.Metadata) {
+			r, _, err := s.Open(ctx, key, opts...)
+			if err != nil {
+				return 0, errors.Wrap(err, "Failed to download asset from aws S3 bucket")
+			}
+			defer r.Close()
+			return io.Copy(&offsetWriter{w: w}, r)
+		}
+	}
+
+	if o.progress != nil {
+		w = progressWriterAt{WriterAt: w, progress: &progress{total: -1, report: o.progress}}
+	}
+
+	params := &awsS3.GetObjectInput{
+		Bucket: &s.config.BucketName,
+		Key:    aws.String(key),
+		Range:  o.byteRange(),
+	}
+	o.applyGet(params)
+
+	downloader := manager.NewDownloader(s.client, func(d *manager.Downloader) {
+		if o.partSize > 0 {
+			d.PartSize = o.partSize
+		}
+		if o.concurrency > 0 {
+			d.Concurrency = o.concurrency
+		}
+	})
+	n, err := downloader.Download(ctx, w, params)
+	if err != nil {
+		return n, errors.Wrap(err, "Failed to download asset from aws S3 bucket")
+	}
+
+	return n, nil
+}
+
+// Open returns a reader for the object with the provided key, which must be closed by the caller. The object is read
+// with a single request as the reader is consumed. The Size of the ObjectInfo is the number of bytes the reader will
+// return, which is the size of the range when WithRange is used. Ranges can't be read from client side encrypted
+// objects.
+func (s s3) Open(ctx context.Context, key string, opts ...TransferOption) (io.ReadCloser, ObjectInfo, error) {
+	o := newTransferOptions(opts)
+
+	params := &awsS3.GetObjectInput{
+		Bucket: &s.config.BucketName,
+		Key:    aws.String(key),
+		Range:  o.byteRange(),
+	}
+	o.applyGet(params)
+
+	output, err := s.client.GetObject(ctx, params)
+	if err != nil {
+		return nil, ObjectInfo{}, errors.Wrap(err, "Failed to open asset from aws S3 bucket")
+	}
+
+	info := ObjectInfo{
+		Key:         key,
+		Size:        output.ContentLength,
+		ContentType: aws.ToString(output.ContentType),
+		ETag:        aws.ToString(output.ETag),
+		Metadata:    output.Metadata,
+	}
+	if output.LastModified != nil {
+		info.LastModified = *output.LastModified
+	}
+
+	body := output.Body
+	if s.isEncrypted(output.Metadata) {
+		if params.Range != nil {
+			body.Close()
+			return nil, ObjectInfo{}, ErrEncryptedRange
+		}
+		info.Size = plaintextSize(info.Size)
+		info.Metadata = userMetadata(info.Metadata)
+		if body, err = s.decrypt(ctx, body, output.Metadata); err != nil {
+			output.Body.Close()
+			return nil, ObjectInfo{}, errors.Wrap(err, "Failed to decrypt asset")
+		}
+	}
+	if o.progress != nil {
+		body = progressReadCloser{ReadCloser: body, progress: &progress{total: info.Size, report: o.progress}}
+	}
+
+	return body, info, nil
+}
+
+// offsetWriter writes sequentially to an io.WriterAt.
+type offsetWriter struct {
+	w      io.WriterAt
+	offset int64
+}
+
+func (w *offsetWriter) Write(b []byte) (int, error) {
+	n, err := w.w.WriteAt(b, w.offset)
+	w.offset += int64(n)
+	return n, err
+}
@@ -0,0 +1,54 @@
+package s3
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestS3(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "S3")
+}
+
+var _ = Describe("TransferOptions", func() {
+	Describe("#byteRange", func() {
+		It("returns nil for the whole object", func() {
+			Expect(newTransferOptions(nil).byteRange()).To(BeNil())
+		})
+		It("returns a bounded range", func() {
+			Expect(*newTransferOptions([]TransferOption{WithRange(100, 50)}).byteRange()).To(Equal("bytes=100-149"))
+		})
+		It("returns an open ended range", func() {
+			Expect(*newTransferOptions([]TransferOption{WithRange(100, 0)}).byteRange()).To(Equal("bytes=100-"))
+		})
+	})
+})
+
+var _ = Describe("Progress", func() {
+	var transferred, total int64
+	report := func(t, n int64) {
+		transferred, total = t, n
+	}
+
+	It("reports bytes read", func() {
+		r := progressReader{Reader: bytes.NewReader([]byte("hello world")), progress: &progress{total: 11, report: report}}
+		_, err := ioutil.ReadAll(r)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(transferred).To(Equal(int64(11)))
+		Expect(total).To(Equal(int64(11)))
+	})
+	It("reports bytes written", func() {
+		w := progressWriterAt{WriterAt: &manager.WriteAtBuffer{}, progress: &progress{total: -1, report: report}}
+		_, err := w.WriteAt([]byte("hello"), 6)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = w.WriteAt([]byte("hello "), 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(transferred).To(Equal(int64(11)))
+		Expect(total).To(Equal(int64(-1)))
+	})
+})
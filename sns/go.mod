@@ -5,5 +5,6 @@ go 1.15
 require (
 	github.com/HomesNZ/go-common/env v0.0.0-20201120023436-5acd0c46a3d0
 	github.com/aws/aws-sdk-go v1.35.33
+	github.com/pkg/errors v0.9.1
 	github.com/sirupsen/logrus v1.7.0
 )
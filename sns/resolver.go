@@ -0,0 +1,102 @@
+package sns
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/HomesNZ/go-common/env"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/pkg/errors"
+)
+
+const (
+	// TopicModeCreate creates topics on first use, the default.
+	TopicModeCreate = "create"
+	// TopicModeLookup only looks up existing topics and fails when a topic is missing.
+	TopicModeLookup = "lookup"
+	// TopicModeStatic resolves topics from SNS_TOPICS only.
+	TopicModeStatic = "static"
+
+	// DefaultTopicNameTemplate is the default topic naming convention, event type suffixed with the environment.
+	DefaultTopicNameTemplate = "{event}_{env}"
+)
+
+// ErrTopicNotFound is returned when the topic doesn't exist and SNS_TOPIC_MODE is lookup or static.
+var ErrTopicNotFound = errors.New("sns: topic not found")
+
+// topicName returns the topic name for name according to SNS_TOPIC_NAME_TEMPLATE.
+func topicName(name string) string {
+	suffix := env.Env()
+	if suffix == "" {
+		suffix = "development"
+	}
+	template := env.GetString("SNS_TOPIC_NAME_TEMPLATE", DefaultTopicNameTemplate)
+	return strings.NewReplacer("{event}", name, "{env}", suffix).Replace(template)
+}
+
+// resolveTopicArn returns the ARN of the topic for name according to SNS_TOPIC_MODE.
+func resolveTopicArn(name string) (string, error) {
+	switch mode := env.GetString("SNS_TOPIC_MODE", TopicModeCreate); mode {
+	case TopicModeCreate:
+		output, err := Conn().CreateTopic(&sns.CreateTopicInput{
+			Name: aws.String(topicName(name)),
+		})
+		if err != nil {
+			return "", err
+		}
+		return aws.StringValue(output.TopicArn), nil
+	case TopicModeLookup:
+		return lookupTopicArn(topicName(name))
+	case TopicModeStatic:
+		return staticTopicArn(name)
+	default:
+		return "", fmt.Errorf("unknown SNS_TOPIC_MODE: %s", mode)
+	}
+}
+
+// lookupTopicArn builds the ARN from AWS_ACCOUNT_ID and checks it exists, or searches ListTopics when the account ID
+// isn't set.
+func lookupTopicArn(name string) (string, error) {
+	if accountID := env.GetString("AWS_ACCOUNT_ID", ""); accountID != "" {
+		arn := fmt.Sprintf("arn:aws:sns:%s:%s:%s", env.GetString("SNS_REGION", ""), accountID, name)
+		_, err := Conn().GetTopicAttributes(&sns.GetTopicAttributesInput{TopicArn: aws.String(arn)})
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == sns.ErrCodeNotFoundException {
+			return "", errors.Wrap(ErrTopicNotFound, name)
+		}
+		if err != nil {
+			return "", err
+		}
+		return arn, nil
+	}
+
+	arn := ""
+	err := Conn().ListTopicsPages(&sns.ListTopicsInput{}, func(page *sns.ListTopicsOutput, lastPage bool) bool {
+		for _, topic := range page.Topics {
+			if strings.HasSuffix(aws.StringValue(topic.TopicArn), ":"+name) {
+				arn = aws.StringValue(topic.TopicArn)
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return "", err
+	}
+	if arn == "" {
+		return "", errors.Wrap(ErrTopicNotFound, name)
+	}
+	return arn, nil
+}
+
+// staticTopicArn looks name up in SNS_TOPICS, a comma separated list of event_type=topic_arn pairs.
+func staticTopicArn(name string) (string, error) {
+	for _, pair := range strings.Split(env.GetString("SNS_TOPICS", ""), ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) == 2 && strings.TrimSpace(parts[0]) == name {
+			return strings.TrimSpace(parts[1]), nil
+		}
+	}
+	return "", errors.Wrap(ErrTopicNotFound, name)
+}
@@ -0,0 +1,172 @@
+package sns
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/pkg/errors"
+)
+
+const testTopicArn = "arn:aws:sns:ap-southeast-2:123456789012:listing_created_production"
+
+// newTestConn points Conn at an SNS query API endpoint which knows about topics, and returns the actions of the
+// requests made to it.
+func newTestConn(t *testing.T, topics ...string) *[]string {
+	var mu sync.Mutex
+	var actions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		action := r.PostForm.Get("Action")
+		mu.Lock()
+		actions = append(actions, action)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/xml")
+		switch action {
+		case "CreateTopic":
+			w.Write([]byte(response(action, `<TopicArn>arn:aws:sns:ap-southeast-2:123456789012:`+r.PostForm.Get("Name")+`</TopicArn>`)))
+		case "GetTopicAttributes":
+			for _, topic := range topics {
+				if r.PostForm.Get("TopicArn") == topic {
+					w.Write([]byte(response(action, `<Attributes></Attributes>`)))
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`<ErrorResponse><Error><Type>Sender</Type><Code>NotFound</Code>` +
+				`<Message>Topic does not exist</Message></Error><RequestId>test</RequestId></ErrorResponse>`))
+		case "ListTopics":
+			// Each topic is returned on its own page, to check every page is searched.
+			i, _ := strconv.Atoi(r.PostForm.Get("NextToken"))
+			page := `<Topics><member><TopicArn>` + topics[i] + `</TopicArn></member></Topics>`
+			if i+1 < len(topics) {
+				page += `<NextToken>` + strconv.Itoa(i+1) + `</NextToken>`
+			}
+			w.Write([]byte(response(action, page)))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	initOnce.Do(func() {})
+	previous := conn
+	conn = sns.New(session.Must(session.NewSession()), &aws.Config{
+		Region:      aws.String("ap-southeast-2"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("AKID", "secret", ""),
+		MaxRetries:  aws.Int(0),
+	})
+	t.Cleanup(func() {
+		conn = previous
+	})
+	return &actions
+}
+
+func response(action, result string) string {
+	return `<` + action + `Response><` + action + `Result>` + result + `</` + action + `Result>` +
+		`<ResponseMetadata><RequestId>test</RequestId></ResponseMetadata></` + action + `Response>`
+}
+
+func setenv(t *testing.T, key, value string) {
+	previous, ok := os.LookupEnv(key)
+	os.Setenv(key, value)
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, previous)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func TestResolveTopicArnCreate(t *testing.T) {
+	setenv(t, "ENV", "production")
+	setenv(t, "SNS_TOPIC_NAME_TEMPLATE", "{env}-{event}")
+	actions := newTestConn(t)
+
+	arn, err := resolveTopicArn("listing_created")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "arn:aws:sns:ap-southeast-2:123456789012:production-listing_created"; arn != want {
+		t.Errorf("got %s, want %s", arn, want)
+	}
+	if len(*actions) != 1 || (*actions)[0] != "CreateTopic" {
+		t.Errorf("got actions %v, want CreateTopic", *actions)
+	}
+}
+
+func TestResolveTopicArnLookupByAccountID(t *testing.T) {
+	setenv(t, "ENV", "production")
+	setenv(t, "SNS_TOPIC_MODE", TopicModeLookup)
+	setenv(t, "SNS_REGION", "ap-southeast-2")
+	setenv(t, "AWS_ACCOUNT_ID", "123456789012")
+	actions := newTestConn(t, testTopicArn)
+
+	arn, err := resolveTopicArn("listing_created")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if arn != testTopicArn {
+		t.Errorf("got %s, want %s", arn, testTopicArn)
+	}
+	if _, err := resolveTopicArn("listing_updated"); !errors.Is(err, ErrTopicNotFound) {
+		t.Errorf("got error %v, want ErrTopicNotFound", err)
+	}
+	for _, action := range *actions {
+		if action != "GetTopicAttributes" {
+			t.Errorf("unexpected action %s", action)
+		}
+	}
+}
+
+func TestResolveTopicArnLookupByListing(t *testing.T) {
+	setenv(t, "ENV", "production")
+	setenv(t, "SNS_TOPIC_MODE", TopicModeLookup)
+	setenv(t, "AWS_ACCOUNT_ID", "")
+	updated := "arn:aws:sns:ap-southeast-2:123456789012:listing_updated_production"
+	actions := newTestConn(t, testTopicArn, updated)
+
+	arn, err := resolveTopicArn("listing_updated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if arn != updated {
+		t.Errorf("got %s, want %s", arn, updated)
+	}
+	if len(*actions) != 2 {
+		t.Errorf("got actions %v, want a ListTopics request for each page", *actions)
+	}
+	if _, err := resolveTopicArn("listing_deleted"); !errors.Is(err, ErrTopicNotFound) {
+		t.Errorf("got error %v, want ErrTopicNotFound", err)
+	}
+}
+
+func TestResolveTopicArnStatic(t *testing.T) {
+	setenv(t, "SNS_TOPIC_MODE", TopicModeStatic)
+	setenv(t, "SNS_TOPICS", "listing_updated=arn:aws:sns:ap-southeast-2:123456789012:updated, listing_created="+testTopicArn)
+	actions := newTestConn(t)
+
+	arn, err := resolveTopicArn("listing_created")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if arn != testTopicArn {
+		t.Errorf("got %s, want %s", arn, testTopicArn)
+	}
+	if _, err := resolveTopicArn("listing_deleted"); !errors.Is(err, ErrTopicNotFound) {
+		t.Errorf("got error %v, want ErrTopicNotFound", err)
+	}
+	if len(*actions) != 0 {
+		t.Errorf("got actions %v, want none", *actions)
+	}
+}
@@ -3,7 +3,6 @@ package sns
 import (
 	"encoding/json"
 
-	"github.com/HomesNZ/go-common/env"
 	"github.com/aws/aws-sdk-go/service/sns"
 )
 
@@ -24,30 +23,25 @@ type Topic struct {
 	arn  string
 }
 
-// Name returns the name of the topic including environment
+// Name returns the name of the topic including environment, formatted with
+// SNS_TOPIC_NAME_TEMPLATE.
 func (t Topic) Name() *string {
-	suffix := env.Env()
-	if suffix == "" {
-		suffix = "development"
-	}
-	name := t.name + "_" + suffix
+	name := topicName(t.name)
 	return &name
 }
 
-// Create attempts to create the SNS topic. CreateTopic() SNS function is
+// Create resolves the ARN of the SNS topic according to SNS_TOPIC_MODE. In the
+// default create mode the topic is created, CreateTopic() SNS function is
 // idempotent, if the topic exists then the existing ARN will be returned.
 func (t *Topic) Create() error {
 	if !snsEnabled() {
 		return nil
 	}
-	input := &sns.CreateTopicInput{
-		Name: t.Name(),
-	}
-	createTopicOutput, err := Conn().CreateTopic(input)
+	arn, err := resolveTopicArn(t.name)
 	if err != nil {
 		return err
 	}
-	t.arn = *createTopicOutput.TopicArn
+	t.arn = arn
 	return nil
 }
 
@@ -0,0 +1,293 @@
+package sns_v2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBufferSize    = 1000
+	defaultFlushInterval = time.Second
+	defaultMaxAttempts   = 5
+	defaultMinBackoff    = 100 * time.Millisecond
+	defaultMaxBackoff    = 5 * time.Second
+)
+
+// ErrPublisherClosed is returned by AsyncPublisher.Send after Close has been called.
+var ErrPublisherClosed = errors.New("sns_v2: publisher closed")
+
+// ErrorHandler is called by AsyncPublisher for every message that could not be published after all attempts.
+type ErrorHandler func(eventType string, message interface{}, err error)
+
+// AsyncOption configures an AsyncPublisher.
+type AsyncOption func(*AsyncPublisher)
+
+// WithBufferSize sets how many messages can be queued before Send blocks. Defaults to 1000.
+func WithBufferSize(size int) AsyncOption {
+	return func(p *AsyncPublisher) {
+		p.bufferSize = size
+	}
+}
+
+// WithFlushInterval sets how often queued messages are published when fewer than a full batch are waiting. It must be
+// positive. Defaults to 1 second.
+func WithFlushInterval(d time.Duration) AsyncOption {
+	return func(p *AsyncPublisher) {
+		p.flushInterval = d
+	}
+}
+
+// WithRetry sets the number of attempts made to publish a message and the exponential backoff between attempts.
+// maxAttempts must be at least 1. Defaults to 5 attempts, backing off from 100ms up to 5s.
+func WithRetry(maxAttempts int, minBackoff, maxBackoff time.Duration) AsyncOption {
+	return func(p *AsyncPublisher) {
+		p.maxAttempts = maxAttempts
+		p.minBackoff = minBackoff
+		p.maxBackoff = maxBackoff
+	}
+}
+
+// WithErrorHandler sets the handler called for messages that could not be published.
+func WithErrorHandler(handler ErrorHandler) AsyncOption {
+	return func(p *AsyncPublisher) {
+		p.onError = handler
+	}
+}
+
+// AsyncPublisher is a Service which queues messages passed to Send in a bounded buffer and publishes them in the
+// background with SendBatch, retrying failed entries with backoff. Flush or Close must be called before shutdown,
+// otherwise queued messages are lost.
+type AsyncPublisher struct {
+	Service
+
+	bufferSize    int
+	flushInterval time.Duration
+	maxAttempts   int
+	minBackoff    time.Duration
+	maxBackoff    time.Duration
+	onError       ErrorHandler
+
+	mu      sync.RWMutex
+	closed  bool
+	closing chan struct{}
+	sending sync.WaitGroup
+	queue   chan asyncItem
+	flush   chan chan struct{}
+	done    chan struct{}
+	cancel  context.CancelFunc
+}
+
+type asyncItem struct {
+	eventType string
+	entry     BatchEntry
+}
+
+// NewAsyncPublisher starts an AsyncPublisher which publishes through service. Messages are published with ctx, and
+// once ctx is done the messages still queued fail rather than being retried.
+func NewAsyncPublisher(ctx context.Context, service Service, options ...AsyncOption) (*AsyncPublisher, error) {
+	p := &AsyncPublisher{
+		Service:       service,
+		bufferSize:    defaultBufferSize,
+		flushInterval: defaultFlushInterval,
+		maxAttempts:   defaultMaxAttempts,
+		minBackoff:    defaultMinBackoff,
+		maxBackoff:    defaultMaxBackoff,
+	}
+	for _, opt := range options {
+		opt(p)
+	}
+	switch {
+	case p.bufferSize < 0:
+		return nil, fmt.Errorf("sns_v2: buffer size must not be negative, got %d", p.bufferSize)
+	case p.flushInterval <= 0:
+		return nil, fmt.Errorf("sns_v2: flush interval must be positive, got %s", p.flushInterval)
+	case p.maxAttempts < 1:
+		return nil, fmt.Errorf("sns_v2: max attempts must be at least 1, got %d", p.maxAttempts)
+	case p.minBackoff < 0 || p.maxBackoff < p.minBackoff:
+		return nil, fmt.Errorf("sns_v2: invalid backoff %s to %s", p.minBackoff, p.maxBackoff)
+	}
+
+	p.queue = make(chan asyncItem, p.bufferSize)
+	p.closing = make(chan struct{})
+	p.flush = make(chan chan struct{})
+	p.done = make(chan struct{})
+	ctx, p.cancel = context.WithCancel(ctx)
+	go p.run(ctx)
+
+	return p, nil
+}
+
+// Send queues message to be published to the topic for eventType. It only blocks when the buffer is full, until there
+// is space, ctx is done or Close is called. Publishing errors are passed to the ErrorHandler rather than returned.
+func (p *AsyncPublisher) Send(ctx context.Context, eventType string, message interface{}, opts ...SendOption) error {
+	p.mu.RLock()
+	if p.closed {
+		p.mu.RUnlock()
+		return ErrPublisherClosed
+	}
+	// The lock isn't held while blocked on a full queue, so Close isn't blocked. Close waits for sends in progress
+	// before it closes the queue instead.
+	p.sending.Add(1)
+	p.mu.RUnlock()
+	defer p.sending.Done()
+
+	select {
+	case p.queue <- asyncItem{eventType: eventType, entry: BatchEntry{Message: message, Options: opts}}:
+		return nil
+	case <-p.closing:
+		return ErrPublisherClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Flush publishes every message queued before it was called, and returns once they have been published or failed, or
+// when ctx is done.
+func (p *AsyncPublisher) Flush(ctx context.Context) error {
+	reply := make(chan struct{})
+	select {
+	case p.flush <- reply:
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-reply:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting messages, publishes everything queued and stops the background publisher. Sends blocked on a
+// full buffer return ErrPublisherClosed. It returns once the queue is empty or ctx is done, in which case publishing
+// is cancelled and the messages still queued fail.
+func (p *AsyncPublisher) Close(ctx context.Context) error {
+	p.mu.Lock()
+	closing := !p.closed
+	p.closed = true
+	p.mu.Unlock()
+	if closing {
+		close(p.closing)
+		go func() {
+			p.sending.Wait()
+			close(p.queue)
+		}()
+	}
+
+	select {
+	case <-p.done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return ctx.Err()
+	}
+}
+
+func (p *AsyncPublisher) run(ctx context.Context) {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.flushInterval)
+	defer ticker.Stop()
+
+	pending := map[string][]BatchEntry{}
+	add := func(item asyncItem) {
+		entries := append(pending[item.eventType], item.entry)
+		if len(entries) < maxBatchSize {
+			pending[item.eventType] = entries
+			return
+		}
+		delete(pending, item.eventType)
+		p.publish(ctx, item.eventType, entries)
+	}
+	flushAll := func() {
+		for eventType, entries := range pending {
+			delete(pending, eventType)
+			p.publish(ctx, eventType, entries)
+		}
+	}
+
+	for {
+		select {
+		case item, ok := <-p.queue:
+			if !ok {
+				flushAll()
+				return
+			}
+			add(item)
+		case <-ticker.C:
+			flushAll()
+		case reply := <-p.flush:
+			for n := len(p.queue); n > 0; n-- {
+				item, ok := <-p.queue
+				if !ok {
+					break
+				}
+				add(item)
+			}
+			flushAll()
+			close(reply)
+		}
+	}
+}
+
+// publish sends entries with SendBatch, retrying the entries which failed with backoff until maxAttempts is reached or
+// ctx is done.
+func (p *AsyncPublisher) publish(ctx context.Context, eventType string, entries []BatchEntry) {
+	backoff := p.minBackoff
+	for attempt := 1; ; attempt++ {
+		err := p.Service.SendBatch(ctx, eventType, entries)
+		if err == nil {
+			return
+		}
+
+		var retry []BatchEntry
+		var batchErr BatchError
+		switch {
+		case errors.As(err, &batchErr):
+			for _, entryErr := range batchErr {
+				entry := entries[entryErr.Index]
+				if entryErr.SenderFault || attempt >= p.maxAttempts || ctx.Err() != nil {
+					p.fail(eventType, entry.Message, entryErr)
+					continue
+				}
+				retry = append(retry, entry)
+			}
+		case attempt >= p.maxAttempts || ctx.Err() != nil:
+			for _, entry := range entries {
+				p.fail(eventType, entry.Message, err)
+			}
+		default:
+			retry = entries
+		}
+		if len(retry) == 0 {
+			return
+		}
+
+		entries = retry
+		select {
+		case <-ctx.Done():
+			for _, entry := range entries {
+				p.fail(eventType, entry.Message, ctx.Err())
+			}
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > p.maxBackoff {
+			backoff = p.maxBackoff
+		}
+	}
+}
+
+func (p *AsyncPublisher) fail(eventType string, message interface{}, err error) {
+	if p.onError != nil {
+		p.onError(eventType, message, err)
+	}
+}
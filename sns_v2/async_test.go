@@ -0,0 +1,185 @@
+package sns_v2_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/HomesNZ/go-common/sns_v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestSNS(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SNS Suite")
+}
+
+// batchService records the batches sent and fails entries according to failures.
+type batchService struct {
+	Service
+
+	mu       sync.Mutex
+	batches  [][]BatchEntry
+	failures func(attempt int, entries []BatchEntry) error
+}
+
+func (s *batchService) SendBatch(ctx context.Context, eventType string, entries []BatchEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, entries)
+	if s.failures != nil {
+		return s.failures(len(s.batches), entries)
+	}
+	return nil
+}
+
+func (s *batchService) sent() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, b := range s.batches {
+		n += len(b)
+	}
+	return n
+}
+
+// blockingService blocks in SendBatch until its context is done.
+type blockingService struct {
+	Service
+	started chan struct{}
+}
+
+func (s *blockingService) SendBatch(ctx context.Context, eventType string, entries []BatchEntry) error {
+	s.started <- struct{}{}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func newPublisher(service Service, options ...AsyncOption) *AsyncPublisher {
+	p, err := NewAsyncPublisher(context.Background(), service, options...)
+	Expect(err).NotTo(HaveOccurred())
+	return p
+}
+
+var _ = Describe("AsyncPublisher", func() {
+	ctx := context.Background()
+
+	It("publishes queued messages in batches of 10 on flush", func() {
+		service := &batchService{}
+		p := newPublisher(service, WithFlushInterval(time.Hour))
+		for i := 0; i < 25; i++ {
+			Expect(p.Send(ctx, "listing_created", i)).To(Succeed())
+		}
+		Expect(p.Flush(ctx)).To(Succeed())
+		Expect(service.sent()).To(Equal(25))
+		Expect(service.batches).To(HaveLen(3))
+		Expect(service.batches[0]).To(HaveLen(10))
+
+		Expect(p.Close(ctx)).To(Succeed())
+		Expect(p.Send(ctx, "listing_created", 1)).To(Equal(ErrPublisherClosed))
+	})
+
+	It("retries failed entries", func() {
+		service := &batchService{failures: func(attempt int, entries []BatchEntry) error {
+			if attempt == 1 {
+				return BatchError{{Index: 1, Err: errors.New("throttled")}}
+			}
+			return nil
+		}}
+		p := newPublisher(service, WithRetry(3, time.Millisecond, time.Millisecond))
+		Expect(p.Send(ctx, "listing_created", "a")).To(Succeed())
+		Expect(p.Send(ctx, "listing_created", "b")).To(Succeed())
+		Expect(p.Close(ctx)).To(Succeed())
+
+		Expect(service.batches).To(HaveLen(2))
+		Expect(service.batches[1]).To(HaveLen(1))
+		Expect(service.batches[1][0].Message).To(Equal("b"))
+	})
+
+	It("reports messages that can't be published", func() {
+		service := &batchService{failures: func(attempt int, entries []BatchEntry) error {
+			return errors.New("unavailable")
+		}}
+		var failed []interface{}
+		p := newPublisher(service,
+			WithRetry(3, time.Millisecond, time.Millisecond),
+			WithErrorHandler(func(eventType string, message interface{}, err error) {
+				failed = append(failed, message)
+			}),
+		)
+		Expect(p.Send(ctx, "listing_created", "a")).To(Succeed())
+		Expect(p.Close(ctx)).To(Succeed())
+
+		Expect(service.batches).To(HaveLen(3))
+		Expect(failed).To(Equal([]interface{}{"a"}))
+	})
+
+	It("stops retrying when its context is done", func() {
+		service := &batchService{failures: func(attempt int, entries []BatchEntry) error {
+			return errors.New("unavailable")
+		}}
+		var mu sync.Mutex
+		var failed []error
+		runCtx, cancel := context.WithCancel(ctx)
+		p, err := NewAsyncPublisher(runCtx, service,
+			WithRetry(100, time.Hour, time.Hour),
+			WithErrorHandler(func(eventType string, message interface{}, err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				failed = append(failed, err)
+			}),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p.Send(ctx, "listing_created", "a")).To(Succeed())
+
+		closed := make(chan error)
+		go func() {
+			closed <- p.Close(ctx)
+		}()
+		Eventually(service.sent).Should(Equal(1))
+		cancel()
+		Eventually(closed).Should(Receive(BeNil()))
+
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(failed).To(Equal([]error{context.Canceled}))
+	})
+
+	It("closes by its deadline while Send is blocked on a full buffer", func() {
+		service := &blockingService{started: make(chan struct{}, 1)}
+		p := newPublisher(service, WithBufferSize(0), WithFlushInterval(time.Millisecond))
+		Expect(p.Send(ctx, "listing_created", "a")).To(Succeed())
+		Eventually(service.started).Should(Receive())
+
+		// The background publisher is stuck publishing "a", so nothing takes "b" off the queue.
+		sent := make(chan error)
+		go func() {
+			sent <- p.Send(ctx, "listing_created", "b")
+		}()
+		Consistently(sent, 20*time.Millisecond).ShouldNot(Receive())
+
+		closeCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		closed := make(chan error)
+		go func() {
+			closed <- p.Close(closeCtx)
+		}()
+		Eventually(closed).Should(Receive(Equal(context.DeadlineExceeded)))
+		Eventually(sent).Should(Receive(Equal(ErrPublisherClosed)))
+	})
+
+	It("returns an error for invalid options", func() {
+		for _, opt := range []AsyncOption{
+			WithFlushInterval(0),
+			WithBufferSize(-1),
+			WithRetry(0, time.Millisecond, time.Second),
+			WithRetry(3, time.Second, time.Millisecond),
+		} {
+			_, err := NewAsyncPublisher(ctx, &batchService{}, opt)
+			Expect(err).To(HaveOccurred())
+		}
+	})
+})
@@ -0,0 +1,133 @@
+package sns_v2
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+const (
+	// maxBatchSize is the maximum number of entries SNS accepts in a single PublishBatch request.
+	maxBatchSize = 10
+	// maxBatchBytes is the maximum total size of the messages and their attributes in a single PublishBatch request.
+	maxBatchBytes = 256 * 1024
+)
+
+// BatchEntry is a single message sent with Service.SendBatch.
+type BatchEntry struct {
+	Message interface{}
+	Options []SendOption
+}
+
+// BatchEntryError reports why a single entry of a batch was not published.
+type BatchEntryError struct {
+	Index       int    // Index of the entry in the slice passed to SendBatch.
+	Code        string // Code is the SNS error code, empty when the entry could not be encoded or the request failed.
+	SenderFault bool   // SenderFault is true when retrying the entry unchanged will fail again.
+	Err         error
+}
+
+func (e BatchEntryError) Error() string {
+	return fmt.Sprintf("entry %d: %v", e.Index, e.Err)
+}
+
+func (e BatchEntryError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError is returned by SendBatch when one or more entries were not published. Entries are ordered by index.
+type BatchError []BatchEntryError
+
+func (e BatchError) Error() string {
+	return fmt.Sprintf("%d batch entries failed, first: %v", len(e), e[0])
+}
+
+// SendBatch publishes entries to the topic for eventType using PublishBatch, in chunks of up to 10 entries and 256 KB.
+// Every entry is attempted even when some fail; failed entries are reported in a BatchError.
+func (s *service) SendBatch(ctx context.Context, eventType string, entries []BatchEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	topicArn, err := s.topic(ctx, eventType)
+	if err != nil {
+		return err
+	}
+
+	var failed BatchError
+	var batches [][]types.PublishBatchRequestEntry
+	var batch []types.PublishBatchRequestEntry
+	batchBytes := 0
+	for i, entry := range entries {
+		input, err := s.publishInput(topicArn, eventType, entry.Message, entry.Options...)
+		if err != nil {
+			failed = append(failed, BatchEntryError{Index: i, SenderFault: true, Err: err})
+			continue
+		}
+		requestEntry := types.PublishBatchRequestEntry{
+			Id:                     aws.String(strconv.Itoa(i)),
+			Message:                input.Message,
+			MessageStructure:       input.MessageStructure,
+			MessageAttributes:      input.MessageAttributes,
+			MessageGroupId:         input.MessageGroupId,
+			MessageDeduplicationId: input.MessageDeduplicationId,
+			Subject:                input.Subject,
+		}
+		size := entrySize(requestEntry)
+		if len(batch) == maxBatchSize || len(batch) > 0 && batchBytes+size > maxBatchBytes {
+			batches = append(batches, batch)
+			batch, batchBytes = nil, 0
+		}
+		batch = append(batch, requestEntry)
+		batchBytes += size
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+
+	for _, requestEntries := range batches {
+		output, err := s.conn.PublishBatch(ctx, &sns.PublishBatchInput{
+			TopicArn:                   topicArn,
+			PublishBatchRequestEntries: requestEntries,
+		})
+		if err != nil {
+			for _, entry := range requestEntries {
+				failed = append(failed, BatchEntryError{Index: entryIndex(entry.Id), Err: err})
+			}
+			continue
+		}
+		for _, entry := range output.Failed {
+			failed = append(failed, BatchEntryError{
+				Index:       entryIndex(entry.Id),
+				Code:        aws.ToString(entry.Code),
+				SenderFault: entry.SenderFault,
+				Err:         fmt.Errorf("%s: %s", aws.ToString(entry.Code), aws.ToString(entry.Message)),
+			})
+		}
+	}
+
+	if len(failed) > 0 {
+		sort.Slice(failed, func(i, j int) bool { return failed[i].Index < failed[j].Index })
+		return failed
+	}
+	return nil
+}
+
+// entrySize is the size of an entry counted towards maxBatchBytes: its message and the names, types and values of its
+// attributes.
+func entrySize(entry types.PublishBatchRequestEntry) int {
+	size := len(aws.ToString(entry.Message))
+	for name, attr := range entry.MessageAttributes {
+		size += len(name) + len(aws.ToString(attr.DataType)) + len(aws.ToString(attr.StringValue)) + len(attr.BinaryValue)
+	}
+	return size
+}
+
+func entryIndex(id *string) int {
+	i, _ := strconv.Atoi(aws.ToString(id))
+	return i
+}
@@ -0,0 +1,126 @@
+package sns_v2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/HomesNZ/go-common/sns_v2/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// batchIDs returns the entry IDs of a PublishBatch request.
+func batchIDs(form url.Values) []string {
+	var ids []string
+	for i := 1; form.Get(fmt.Sprintf("PublishBatchRequestEntries.member.%d.Id", i)) != ""; i++ {
+		ids = append(ids, form.Get(fmt.Sprintf("PublishBatchRequestEntries.member.%d.Id", i)))
+	}
+	return ids
+}
+
+var _ = Describe("SendBatch", func() {
+	var (
+		ctx  context.Context
+		aws  *fakeAWS
+		svc  *service
+		resp func(form url.Values) (int, string)
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		resp = func(form url.Values) (int, string) {
+			return http.StatusOK, awsResponse("PublishBatch", "<Successful></Successful><Failed></Failed>")
+		}
+		aws = newFakeAWS(func(form url.Values) (int, string) {
+			return resp(form)
+		})
+		svc = aws.service(&config.Config{
+			Region:           "ap-southeast-2",
+			MessageStructure: "json",
+			TopicMode:        config.TopicModeStatic,
+			Topics:           map[string]string{"listing_created": "arn:aws:sns:ap-southeast-2:123456789012:listing_created"},
+		})
+	})
+
+	AfterEach(func() {
+		aws.Close()
+	})
+
+	entries := func(n int) []BatchEntry {
+		entries := make([]BatchEntry, n)
+		for i := range entries {
+			entries[i] = BatchEntry{Message: i}
+		}
+		return entries
+	}
+
+	It("publishes entries in chunks of 10", func() {
+		Expect(svc.SendBatch(ctx, "listing_created", entries(23))).To(Succeed())
+
+		requests := aws.actions("PublishBatch")
+		Expect(requests).To(HaveLen(3))
+		Expect(batchIDs(requests[0])).To(HaveLen(10))
+		Expect(batchIDs(requests[1])).To(HaveLen(10))
+		Expect(batchIDs(requests[2])).To(Equal([]string{"20", "21", "22"}))
+		Expect(requests[0].Get("TopicArn")).To(Equal("arn:aws:sns:ap-southeast-2:123456789012:listing_created"))
+	})
+
+	It("publishes entries in chunks of up to 256 KB", func() {
+		batch := make([]BatchEntry, 5)
+		for i := range batch {
+			batch[i] = BatchEntry{Message: strings.Repeat("x", 100*1024)}
+		}
+		Expect(svc.SendBatch(ctx, "listing_created", batch)).To(Succeed())
+
+		requests := aws.actions("PublishBatch")
+		Expect(requests).To(HaveLen(3))
+		Expect(batchIDs(requests[0])).To(Equal([]string{"0", "1"}))
+		Expect(batchIDs(requests[1])).To(Equal([]string{"2", "3"}))
+		Expect(batchIDs(requests[2])).To(Equal([]string{"4"}))
+	})
+
+	It("doesn't send a request without entries", func() {
+		Expect(svc.SendBatch(ctx, "listing_created", nil)).To(Succeed())
+		Expect(aws.actions("PublishBatch")).To(BeEmpty())
+	})
+
+	It("maps failures to the index of each entry", func() {
+		resp = func(form url.Values) (int, string) {
+			ids := batchIDs(form)
+			switch ids[0] {
+			case "0":
+				return http.StatusOK, awsResponse("PublishBatch", `<Failed>
+					<member><Id>3</Id><Code>InternalError</Code><Message>try again</Message><SenderFault>false</SenderFault></member>
+					<member><Id>7</Id><Code>InvalidParameter</Code><Message>bad attribute</Message><SenderFault>true</SenderFault></member>
+				</Failed>`)
+			default:
+				return http.StatusForbidden, `<ErrorResponse><Error><Type>Sender</Type><Code>AuthorizationError</Code>` +
+					`<Message>denied</Message></Error><RequestId>test</RequestId></ErrorResponse>`
+			}
+		}
+		batch := entries(12)
+		batch[5].Message = make(chan int) // can't be encoded
+
+		err := svc.SendBatch(ctx, "listing_created", batch)
+
+		var batchErr BatchError
+		Expect(err).To(BeAssignableToTypeOf(batchErr))
+		batchErr = err.(BatchError)
+		var indexes []int
+		for _, e := range batchErr {
+			indexes = append(indexes, e.Index)
+		}
+		Expect(indexes).To(Equal([]int{3, 5, 7, 11}))
+		Expect(batchErr[0].Code).To(Equal("InternalError"))
+		Expect(batchErr[0].SenderFault).To(BeFalse())
+		Expect(batchErr[1].SenderFault).To(BeTrue())
+		Expect(batchErr[2].Code).To(Equal("InvalidParameter"))
+		Expect(batchErr[2].SenderFault).To(BeTrue())
+		Expect(batchErr[3].Err).To(MatchError(ContainSubstring("AuthorizationError")))
+
+		Expect(batchIDs(aws.actions("PublishBatch")[0])).NotTo(ContainElement("5"))
+	})
+})
@@ -1,17 +1,54 @@
 package config
 
 import (
+	"errors"
+	"strings"
+
 	validation "github.com/go-ozzo/ozzo-validation"
 )
 
+const (
+	// TopicModeCreate creates topics on first use. CreateTopic is idempotent, so existing topics are returned.
+	TopicModeCreate = "create"
+	// TopicModeLookup only looks up existing topics and fails when a topic is missing.
+	TopicModeLookup = "lookup"
+	// TopicModeStatic resolves topics from the Topics map only.
+	TopicModeStatic = "static"
+
+	// DefaultTopicNameTemplate is the default topic naming convention, event type suffixed with the environment.
+	DefaultTopicNameTemplate = "{event}_{env}"
+)
+
 type Config struct {
-	Region           string // - is aws SQS region
-	MessageStructure string
-	Env              string
+	Region            string // - is aws SQS region
+	MessageStructure  string
+	Env               string
+	Producer          string            // - is the service name set on published envelopes
+	TopicMode         string            // - is how topic ARNs are resolved, one of create, lookup or static
+	TopicNameTemplate string            // - is the topic name, {event} and {env} are replaced with the event type and Env
+	AccountID         string            // - is the aws account id, used to build topic ARNs in lookup mode
+	Topics            map[string]string // - is event type to topic ARN, used in static mode
+}
+
+// TopicName returns the name of the topic for eventType according to TopicNameTemplate.
+func (c Config) TopicName(eventType string) string {
+	template := c.TopicNameTemplate
+	if template == "" {
+		template = DefaultTopicNameTemplate
+	}
+	return strings.NewReplacer("{event}", eventType, "{env}", c.Env).Replace(template)
 }
 
 func (c Config) Validate() error {
-	return validation.ValidateStruct(&c,
+	err := validation.ValidateStruct(&c,
 		validation.Field(&c.Region, validation.Required.Error("AWS_REGION was not provided")),
+		validation.Field(&c.TopicMode, validation.In(TopicModeCreate, TopicModeLookup, TopicModeStatic).Error("AWS_SNS_TOPIC_MODE must be one of create, lookup or static")),
 	)
+	if err != nil {
+		return err
+	}
+	if c.TopicMode == TopicModeStatic && len(c.Topics) == 0 {
+		return errors.New("AWS_SNS_TOPICS was not provided")
+	}
+	return nil
 }
@@ -1,6 +1,10 @@
 package config
 
-import "github.com/HomesNZ/go-common/env"
+import (
+	"strings"
+
+	"github.com/HomesNZ/go-common/env"
+)
 
 func NewFromEnv() (*Config, error) {
 	region := env.GetString("AWS_SQS_REGION", "")
@@ -11,9 +15,14 @@ func NewFromEnv() (*Config, error) {
 	}
 
 	cfg := &Config{
-		Region:           region,
-		MessageStructure: messageStructure,
-		Env:              suffix,
+		Region:            region,
+		MessageStructure:  messageStructure,
+		Env:               suffix,
+		Producer:          env.GetString("SERVICE_NAME", ""),
+		TopicMode:         getString("AWS_SNS_TOPIC_MODE", "SNS_TOPIC_MODE", TopicModeCreate),
+		TopicNameTemplate: getString("AWS_SNS_TOPIC_NAME_TEMPLATE", "SNS_TOPIC_NAME_TEMPLATE", DefaultTopicNameTemplate),
+		AccountID:         env.GetString("AWS_ACCOUNT_ID", ""),
+		Topics:            parseTopics(getString("AWS_SNS_TOPICS", "SNS_TOPICS", "")),
 	}
 
 	if err := cfg.Validate(); err != nil {
@@ -22,3 +31,22 @@ func NewFromEnv() (*Config, error) {
 
 	return cfg, nil
 }
+
+// getString returns the value of key, or of legacyKey, the name used by the sns package, if key isn't set, so services
+// moving from sns to sns_v2 can keep their config.
+func getString(key, legacyKey, defVal string) string {
+	return env.GetString(key, env.GetString(legacyKey, defVal))
+}
+
+// parseTopics parses a comma separated list of event_type=topic_arn pairs.
+func parseTopics(s string) map[string]string {
+	topics := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		eventType, arn, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		topics[strings.TrimSpace(eventType)] = strings.TrimSpace(arn)
+	}
+	return topics
+}
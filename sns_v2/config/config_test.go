@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Config")
+}
+
+var _ = Describe("Config", func() {
+	Describe("#validate", func() {
+		It("returns an error", func() {
+			cfg := &Config{}
+			err := cfg.Validate()
+			Expect(err).To(HaveOccurred())
+		})
+		It("returns an error for an unknown topic mode", func() {
+			cfg := &Config{Region: "ap-southeast-2", TopicMode: "guess"}
+			err := cfg.Validate()
+			Expect(err).To(HaveOccurred())
+		})
+		It("returns an error for static mode without topics", func() {
+			cfg := &Config{Region: "ap-southeast-2", TopicMode: TopicModeStatic}
+			err := cfg.Validate()
+			Expect(err).To(HaveOccurred())
+		})
+		It("does not return an error", func() {
+			cfg := &Config{Region: "ap-southeast-2", TopicMode: TopicModeLookup}
+			err := cfg.Validate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("#TopicName", func() {
+		It("suffixes the environment by default", func() {
+			cfg := &Config{Env: "production"}
+			Expect(cfg.TopicName("listing_created")).To(Equal("listing_created_production"))
+		})
+		It("uses the template", func() {
+			cfg := &Config{Env: "production", TopicNameTemplate: "{env}-{event}.fifo"}
+			Expect(cfg.TopicName("listing_created")).To(Equal("production-listing_created.fifo"))
+		})
+	})
+
+	Describe(".NewFromEnv", func() {
+		It("parses static topics", func() {
+			os.Setenv("AWS_SQS_REGION", "ap-southeast-2")
+			defer os.Unsetenv("AWS_SQS_REGION")
+			os.Setenv("AWS_SNS_TOPIC_MODE", TopicModeStatic)
+			defer os.Unsetenv("AWS_SNS_TOPIC_MODE")
+			os.Setenv("AWS_SNS_TOPICS", "listing_created=arn:aws:sns:ap-southeast-2:123:listing_created, listing_updated=arn:aws:sns:ap-southeast-2:123:listing_updated")
+			defer os.Unsetenv("AWS_SNS_TOPICS")
+
+			cfg, err := NewFromEnv()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Topics).To(Equal(map[string]string{
+				"listing_created": "arn:aws:sns:ap-southeast-2:123:listing_created",
+				"listing_updated": "arn:aws:sns:ap-southeast-2:123:listing_updated",
+			}))
+		})
+
+		It("reads the sns package's topic variables", func() {
+			os.Setenv("AWS_SQS_REGION", "ap-southeast-2")
+			defer os.Unsetenv("AWS_SQS_REGION")
+			os.Setenv("SNS_TOPIC_MODE", TopicModeStatic)
+			defer os.Unsetenv("SNS_TOPIC_MODE")
+			os.Setenv("SNS_TOPIC_NAME_TEMPLATE", "{env}-{event}")
+			defer os.Unsetenv("SNS_TOPIC_NAME_TEMPLATE")
+			os.Setenv("SNS_TOPICS", "listing_created=arn:aws:sns:ap-southeast-2:123:listing_created")
+			defer os.Unsetenv("SNS_TOPICS")
+
+			cfg, err := NewFromEnv()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.TopicMode).To(Equal(TopicModeStatic))
+			Expect(cfg.TopicNameTemplate).To(Equal("{env}-{event}"))
+			Expect(cfg.Topics).To(HaveKeyWithValue("listing_created", "arn:aws:sns:ap-southeast-2:123:listing_created"))
+
+			os.Setenv("AWS_SNS_TOPIC_NAME_TEMPLATE", "{event}.{env}")
+			defer os.Unsetenv("AWS_SNS_TOPIC_NAME_TEMPLATE")
+			cfg, err = NewFromEnv()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.TopicNameTemplate).To(Equal("{event}.{env}"))
+		})
+	})
+})
@@ -0,0 +1,81 @@
+package sns_v2
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+
+	"github.com/HomesNZ/go-common/sns_v2/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+// fakeAWS is an SNS and SQS query API endpoint. It records the form of each request and responds with the XML body
+// returned by respond for the request's Action.
+type fakeAWS struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []url.Values
+	respond  func(form url.Values) (status int, body string)
+}
+
+func newFakeAWS(respond func(form url.Values) (int, string)) *fakeAWS {
+	f := &fakeAWS{respond: respond}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		f.requests = append(f.requests, r.PostForm)
+		f.mu.Unlock()
+
+		status, body := f.respond(r.PostForm)
+		w.Header().Set("Content-Type", "text/xml")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	return f
+}
+
+// actions returns the recorded requests for action.
+func (f *fakeAWS) actions(action string) []url.Values {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var requests []url.Values
+	for _, r := range f.requests {
+		if r.Get("Action") == action {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+// service returns a service using cfg which sends its SNS and SQS requests to f.
+func (f *fakeAWS) service(cfg *config.Config) *service {
+	conn := sns.New(sns.Options{
+		Region:           cfg.Region,
+		Credentials:      aws.AnonymousCredentials{},
+		EndpointResolver: sns.EndpointResolverFromURL(f.URL),
+	})
+	return &service{
+		conn: conn,
+		sqs: sqs.New(sqs.Options{
+			Region:           cfg.Region,
+			Credentials:      aws.AnonymousCredentials{},
+			EndpointResolver: sqs.EndpointResolverFromURL(f.URL),
+		}),
+		config:   cfg,
+		resolver: NewTopicResolver(conn, cfg),
+		topics:   make(map[string]TopicArn),
+	}
+}
+
+// awsResponse wraps result in the query API response envelope for action.
+func awsResponse(action, result string) string {
+	return `<` + action + `Response><` + action + `Result>` + result + `</` + action + `Result>` +
+		`<ResponseMetadata><RequestId>test</RequestId></ResponseMetadata></` + action + `Response>`
+}
@@ -8,6 +8,7 @@ import (
 	context "context"
 	reflect "reflect"
 
+	sns_v2 "github.com/HomesNZ/go-common/sns_v2"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -35,15 +36,54 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 }
 
 // Send mocks base method.
-func (m *MockService) Send(ctx context.Context, eventType string, message interface{}) error {
+func (m *MockService) Send(ctx context.Context, eventType string, message interface{}, opts ...sns_v2.SendOption) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Send", ctx, eventType, message)
+	varargs := []interface{}{ctx, eventType, message}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Send", varargs...)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Send indicates an expected call of Send.
-func (mr *MockServiceMockRecorder) Send(ctx, eventType, message interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) Send(ctx, eventType, message interface{}, opts ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockService)(nil).Send), ctx, eventType, message)
+	varargs := append([]interface{}{ctx, eventType, message}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockService)(nil).Send), varargs...)
+}
+
+// SendBatch mocks base method.
+func (m *MockService) SendBatch(ctx context.Context, eventType string, entries []sns_v2.BatchEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendBatch", ctx, eventType, entries)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendBatch indicates an expected call of SendBatch.
+func (mr *MockServiceMockRecorder) SendBatch(ctx, eventType, entries interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendBatch", reflect.TypeOf((*MockService)(nil).SendBatch), ctx, eventType, entries)
+}
+
+// Subscribe mocks base method.
+func (m *MockService) Subscribe(ctx context.Context, eventType, queueURL string, opts ...sns_v2.SubscribeOption) (string, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, eventType, queueURL}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Subscribe", varargs...)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockServiceMockRecorder) Subscribe(ctx, eventType, queueURL interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, eventType, queueURL}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockService)(nil).Subscribe), varargs...)
 }
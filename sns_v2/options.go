@@ -0,0 +1,73 @@
+package sns_v2
+
+import (
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+// EventTypeAttribute is the message attribute set to the event type on every message sent, so subscriptions can
+// filter on it.
+const EventTypeAttribute = "event_type"
+
+// SendOption customises the publish request made by Service.Send.
+type SendOption func(*sns.PublishInput)
+
+// WithAttribute sets a String message attribute.
+func WithAttribute(name, value string) SendOption {
+	return func(input *sns.PublishInput) {
+		setAttribute(input, name, types.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(value),
+		})
+	}
+}
+
+// WithAttributes sets a String message attribute for each entry of attributes.
+func WithAttributes(attributes map[string]string) SendOption {
+	return func(input *sns.PublishInput) {
+		for name, value := range attributes {
+			WithAttribute(name, value)(input)
+		}
+	}
+}
+
+// WithNumberAttribute sets a Number message attribute, which can be matched with numeric filter policies.
+func WithNumberAttribute(name string, value float64) SendOption {
+	return func(input *sns.PublishInput) {
+		setAttribute(input, name, types.MessageAttributeValue{
+			DataType:    aws.String("Number"),
+			StringValue: aws.String(strconv.FormatFloat(value, 'f', -1, 64)),
+		})
+	}
+}
+
+// WithSubject sets the subject of the message, used by email subscriptions.
+func WithSubject(subject string) SendOption {
+	return func(input *sns.PublishInput) {
+		input.Subject = aws.String(subject)
+	}
+}
+
+// WithMessageGroupID sets the message group ID. Required when publishing to a FIFO topic.
+func WithMessageGroupID(id string) SendOption {
+	return func(input *sns.PublishInput) {
+		input.MessageGroupId = aws.String(id)
+	}
+}
+
+// WithDeduplicationID sets the message deduplication ID for FIFO topics without content based deduplication.
+func WithDeduplicationID(id string) SendOption {
+	return func(input *sns.PublishInput) {
+		input.MessageDeduplicationId = aws.String(id)
+	}
+}
+
+func setAttribute(input *sns.PublishInput, name string, value types.MessageAttributeValue) {
+	if input.MessageAttributes == nil {
+		input.MessageAttributes = map[string]types.MessageAttributeValue{}
+	}
+	input.MessageAttributes[name] = value
+}
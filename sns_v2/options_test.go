@@ -0,0 +1,61 @@
+package sns_v2
+
+import (
+	"encoding/json"
+
+	"github.com/HomesNZ/go-common/sns_v2/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SendOption", func() {
+	svc := &service{config: &config.Config{MessageStructure: "json"}}
+	topicArn := aws.String("arn:aws:sns:ap-southeast-2:123456789012:listing_created.fifo")
+
+	stringAttribute := func(value string) types.MessageAttributeValue {
+		return types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(value)}
+	}
+
+	It("sets the event type attribute and wraps the message", func() {
+		input, err := svc.publishInput(topicArn, "listing_created", map[string]int{"listing_id": 1})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(input.TopicArn).To(Equal(topicArn))
+		Expect(input.MessageAttributes).To(Equal(map[string]types.MessageAttributeValue{
+			EventTypeAttribute: stringAttribute("listing_created"),
+		}))
+
+		var message Message
+		Expect(json.Unmarshal([]byte(aws.ToString(input.Message)), &message)).To(Succeed())
+		Expect(message.Default).To(Equal(`{"listing_id":1}`))
+	})
+
+	It("sets message attributes", func() {
+		input, err := svc.publishInput(topicArn, "listing_created", nil,
+			WithAttribute("region", "auckland"),
+			WithAttributes(map[string]string{"source": "import", "agency": "42"}),
+			WithNumberAttribute("price", 850000.5),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(input.MessageAttributes).To(Equal(map[string]types.MessageAttributeValue{
+			EventTypeAttribute: stringAttribute("listing_created"),
+			"region":           stringAttribute("auckland"),
+			"source":           stringAttribute("import"),
+			"agency":           stringAttribute("42"),
+			"price":            {DataType: aws.String("Number"), StringValue: aws.String("850000.5")},
+		}))
+	})
+
+	It("sets the FIFO group and deduplication IDs and the subject", func() {
+		input, err := svc.publishInput(topicArn, "listing_created", nil,
+			WithMessageGroupID("listing-1"),
+			WithDeduplicationID("listing-1-v2"),
+			WithSubject("Listing created"),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(aws.ToString(input.MessageGroupId)).To(Equal("listing-1"))
+		Expect(aws.ToString(input.MessageDeduplicationId)).To(Equal("listing-1-v2"))
+		Expect(aws.ToString(input.Subject)).To(Equal("Listing created"))
+	})
+})
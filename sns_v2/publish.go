@@ -0,0 +1,29 @@
+package sns_v2
+
+import (
+	"context"
+
+	"github.com/HomesNZ/go-common/envelope"
+)
+
+// producer returns the producer name of s, or "" if it doesn't implement Producer.
+func producer(s Service) string {
+	if p, ok := s.(Producer); ok {
+		return p.Producer()
+	}
+	return ""
+}
+
+// Publish wraps payload in an envelope.Envelope, using the event type and schema version declared by the payload, and
+// sends it to the topic for that event type. The trace in ctx is linked to the published event.
+func Publish[T envelope.Payload](ctx context.Context, s Service, payload T) error {
+	env := envelope.From(ctx, producer(s), payload)
+	return s.Send(ctx, env.Type, env)
+}
+
+// PublishVersion wraps payload in an envelope.Envelope with the given event type and schema version, and sends it to
+// the topic for that event type. The trace in ctx is linked to the published event.
+func PublishVersion[T any](ctx context.Context, s Service, eventType string, version int, payload T) error {
+	env := envelope.New(ctx, eventType, version, producer(s), payload)
+	return s.Send(ctx, eventType, env)
+}
@@ -0,0 +1,107 @@
+package sns_v2
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/HomesNZ/go-common/sns_v2/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/pkg/errors"
+)
+
+// ErrTopicNotFound is returned by resolvers which don't create topics when the topic for an event type doesn't exist.
+var ErrTopicNotFound = errors.New("sns_v2: topic not found")
+
+// TopicResolver resolves the ARN of the topic an event type is published to. Resolved ARNs are cached by the service,
+// so Resolve is only called once per event type.
+type TopicResolver interface {
+	Resolve(ctx context.Context, eventType string) (string, error)
+}
+
+// NewTopicResolver returns the resolver for cfg.TopicMode.
+func NewTopicResolver(conn *sns.Client, cfg *config.Config) TopicResolver {
+	switch cfg.TopicMode {
+	case config.TopicModeLookup:
+		return &LookupResolver{conn: conn, config: cfg}
+	case config.TopicModeStatic:
+		return StaticResolver(cfg.Topics)
+	default:
+		return &CreateResolver{conn: conn, config: cfg}
+	}
+}
+
+// CreateResolver creates the topic on first use. CreateTopic is idempotent, if the topic exists then the existing ARN
+// is returned. It requires sns:CreateTopic.
+type CreateResolver struct {
+	conn   *sns.Client
+	config *config.Config
+}
+
+func (r *CreateResolver) Resolve(ctx context.Context, eventType string) (string, error) {
+	output, err := r.conn.CreateTopic(ctx, &sns.CreateTopicInput{
+		Name: aws.String(r.config.TopicName(eventType)),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(output.TopicArn), nil
+}
+
+// LookupResolver only resolves topics which already exist. When the account ID is configured the ARN is built from
+// the account and region and checked with GetTopicAttributes, otherwise the topic is found with ListTopics.
+type LookupResolver struct {
+	conn   *sns.Client
+	config *config.Config
+}
+
+func (r *LookupResolver) Resolve(ctx context.Context, eventType string) (string, error) {
+	name := r.config.TopicName(eventType)
+	if r.config.AccountID != "" {
+		return r.resolveArn(ctx, name)
+	}
+	return r.list(ctx, name)
+}
+
+func (r *LookupResolver) resolveArn(ctx context.Context, name string) (string, error) {
+	arn := fmt.Sprintf("arn:aws:sns:%s:%s:%s", r.config.Region, r.config.AccountID, name)
+	_, err := r.conn.GetTopicAttributes(ctx, &sns.GetTopicAttributesInput{TopicArn: aws.String(arn)})
+	var notFound *types.NotFoundException
+	if errors.As(err, &notFound) {
+		return "", errors.Wrap(ErrTopicNotFound, name)
+	}
+	if err != nil {
+		return "", err
+	}
+	return arn, nil
+}
+
+func (r *LookupResolver) list(ctx context.Context, name string) (string, error) {
+	paginator := sns.NewListTopicsPaginator(r.conn, &sns.ListTopicsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return "", err
+		}
+		for _, topic := range page.Topics {
+			arn := aws.ToString(topic.TopicArn)
+			if strings.HasSuffix(arn, ":"+name) {
+				return arn, nil
+			}
+		}
+	}
+	return "", errors.Wrap(ErrTopicNotFound, name)
+}
+
+// StaticResolver resolves topics from a map of event type to topic ARN, usually configured with AWS_SNS_TOPICS (or SNS_TOPICS).
+type StaticResolver map[string]string
+
+func (r StaticResolver) Resolve(ctx context.Context, eventType string) (string, error) {
+	arn, ok := r[eventType]
+	if !ok {
+		return "", errors.Wrap(ErrTopicNotFound, eventType)
+	}
+	return arn, nil
+}
@@ -0,0 +1,116 @@
+package sns_v2
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/HomesNZ/go-common/sns_v2/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+const notFoundResponse = `<ErrorResponse><Error><Type>Sender</Type><Code>NotFound</Code>` +
+	`<Message>Topic does not exist</Message></Error><RequestId>test</RequestId></ErrorResponse>`
+
+var _ = Describe("TopicResolver", func() {
+	var (
+		ctx    context.Context
+		aws    *fakeAWS
+		topics []string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		topics = []string{"arn:aws:sns:ap-southeast-2:123456789012:listing_created_production"}
+		aws = newFakeAWS(func(form url.Values) (int, string) {
+			switch form.Get("Action") {
+			case "CreateTopic":
+				return http.StatusOK, awsResponse("CreateTopic", `<TopicArn>arn:aws:sns:ap-southeast-2:123456789012:`+form.Get("Name")+`</TopicArn>`)
+			case "GetTopicAttributes":
+				for _, topic := range topics {
+					if form.Get("TopicArn") == topic {
+						return http.StatusOK, awsResponse("GetTopicAttributes", `<Attributes></Attributes>`)
+					}
+				}
+				return http.StatusNotFound, notFoundResponse
+			case "ListTopics":
+				// Each topic is returned on its own page, to check every page is searched.
+				i, _ := strconv.Atoi(form.Get("NextToken"))
+				page := `<Topics><member><TopicArn>` + topics[i] + `</TopicArn></member></Topics>`
+				if i+1 < len(topics) {
+					page += `<NextToken>` + strconv.Itoa(i+1) + `</NextToken>`
+				}
+				return http.StatusOK, awsResponse("ListTopics", page)
+			}
+			return http.StatusBadRequest, ""
+		})
+	})
+
+	AfterEach(func() {
+		aws.Close()
+	})
+
+	newService := func(cfg config.Config) *service {
+		cfg.Region = "ap-southeast-2"
+		cfg.Env = "production"
+		return aws.service(&cfg)
+	}
+
+	Describe("create mode", func() {
+		It("creates the topic named by the template", func() {
+			svc := newService(config.Config{TopicMode: config.TopicModeCreate, TopicNameTemplate: "{env}-{event}"})
+
+			arn, err := svc.topic(ctx, "listing_created")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*arn).To(Equal("arn:aws:sns:ap-southeast-2:123456789012:production-listing_created"))
+
+			_, err = svc.topic(ctx, "listing_created")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(aws.actions("CreateTopic")).To(HaveLen(1))
+		})
+	})
+
+	Describe("lookup mode", func() {
+		It("checks the topic built from the account ID exists", func() {
+			svc := newService(config.Config{TopicMode: config.TopicModeLookup, AccountID: "123456789012"})
+
+			arn, err := svc.topic(ctx, "listing_created")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*arn).To(Equal(topics[0]))
+			Expect(aws.actions("CreateTopic")).To(BeEmpty())
+
+			_, err = svc.topic(ctx, "listing_updated")
+			Expect(errors.Is(err, ErrTopicNotFound)).To(BeTrue())
+		})
+
+		It("lists the topics without an account ID", func() {
+			topics = append(topics, "arn:aws:sns:ap-southeast-2:123456789012:listing_updated_production")
+			svc := newService(config.Config{TopicMode: config.TopicModeLookup})
+
+			arn, err := svc.topic(ctx, "listing_updated")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*arn).To(Equal(topics[1]))
+			Expect(aws.actions("ListTopics")).To(HaveLen(2))
+
+			_, err = svc.topic(ctx, "listing_deleted")
+			Expect(errors.Is(err, ErrTopicNotFound)).To(BeTrue())
+		})
+	})
+
+	Describe("static mode", func() {
+		It("resolves topics from the config", func() {
+			svc := newService(config.Config{TopicMode: config.TopicModeStatic, Topics: map[string]string{"listing_created": topics[0]}})
+
+			arn, err := svc.topic(ctx, "listing_created")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*arn).To(Equal(topics[0]))
+
+			_, err = svc.topic(ctx, "listing_updated")
+			Expect(errors.Is(err, ErrTopicNotFound)).To(BeTrue())
+			Expect(aws.requests).To(BeEmpty())
+		})
+	})
+})
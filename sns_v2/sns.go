@@ -7,52 +7,80 @@ import (
 
 	"github.com/HomesNZ/go-common/sns_v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
 )
 
 type Service interface {
-	Send(ctx context.Context, eventType string, message interface{}) error
+	Send(ctx context.Context, eventType string, message interface{}, opts ...SendOption) error
+	SendBatch(ctx context.Context, eventType string, entries []BatchEntry) error
+	Subscribe(ctx context.Context, eventType string, queueURL string, opts ...SubscribeOption) (string, error)
+}
+
+// Producer is implemented by services which know the name of the service publishing through them. The Service
+// returned by New and NewFromEnv implements it, using the configured producer.
+type Producer interface {
+	Producer() string
 }
 
 type TopicArn *string
 
 type service struct {
-	conn   *sns.Client
-	config *config.Config
-	mu     sync.RWMutex
-	topics map[string]TopicArn
+	conn     *sns.Client
+	sqs      *sqs.Client
+	config   *config.Config
+	resolver TopicResolver
+	mu       sync.RWMutex
+	topics   map[string]TopicArn
+	policyMu sync.Mutex // - serialises queue policy updates made by Subscribe
 }
 
-func (s *service) Send(ctx context.Context, eventType string, message interface{}) error {
+// Send publishes message to the topic for eventType. The event type is always set as the EventTypeAttribute message
+// attribute.
+func (s *service) Send(ctx context.Context, eventType string, message interface{}, opts ...SendOption) error {
 	topicArn, err := s.topic(ctx, eventType)
 	if err != nil {
 		return err
 	}
-	messageObjBytes, err := json.Marshal(message)
+	input, err := s.publishInput(topicArn, eventType, message, opts...)
+	if err != nil {
+		return err
+	}
+	_, err = s.conn.Publish(ctx, input)
 	if err != nil {
 		return err
 	}
 
+	return nil
+}
+
+// publishInput encodes message and applies opts to the publish request.
+func (s *service) publishInput(topicArn *string, eventType string, message interface{}, opts ...SendOption) (*sns.PublishInput, error) {
+	messageObjBytes, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+
 	messageWrapper := Message{string(messageObjBytes)}
 	messageBytes, err := json.Marshal(messageWrapper)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	m := string(messageBytes)
-	_, err = s.conn.Publish(ctx, &sns.PublishInput{
+	input := &sns.PublishInput{
 		MessageStructure: &s.config.MessageStructure,
 		TopicArn:         topicArn,
 		Message:          &m,
-	})
-	if err != nil {
-		return err
 	}
-
-	return nil
+	WithAttribute(EventTypeAttribute, eventType)(input)
+	for _, opt := range opts {
+		opt(input)
+	}
+	return input, nil
 }
 
-func (s *service) name(name string) *string {
-	n := name + "_" + s.config.Env
-	return &n
+// Producer returns the service name set on envelopes published through this service.
+func (s *service) Producer() string {
+	return s.config.Producer
 }
 
 func (s *service) topic(ctx context.Context, name string) (*string, error) {
@@ -60,15 +88,12 @@ func (s *service) topic(ctx context.Context, name string) (*string, error) {
 		return topic, nil
 	}
 
-	input := &sns.CreateTopicInput{
-		Name: s.name(name),
-	}
-	output, err := s.conn.CreateTopic(ctx, input)
+	arn, err := s.resolver.Resolve(ctx, name)
 	if err != nil {
 		return nil, err
 	}
-	s.setTopic(name, output.TopicArn)
-	return output.TopicArn, nil
+	s.setTopic(name, &arn)
+	return &arn, nil
 }
 
 func (s *service) getTopic(name string) (*string, bool) {
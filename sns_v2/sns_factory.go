@@ -6,15 +6,33 @@ import (
 	"github.com/HomesNZ/go-common/sns_v2/config"
 	awsCfg "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
 )
 
-func NewFromEnv(ctx context.Context) (Service, error) {
+type Options func(*service)
+
+// WithTopicResolver overrides the resolver selected by the TopicMode config.
+func WithTopicResolver(resolver TopicResolver) Options {
+	return func(s *service) {
+		s.resolver = resolver
+	}
+}
+
+func NewFromEnv(ctx context.Context, options ...Options) (Service, error) {
 
 	config, err := config.NewFromEnv()
 	if err != nil {
 		return nil, err
 	}
 
+	return New(ctx, config, options...)
+}
+
+func New(ctx context.Context, config *config.Config, options ...Options) (Service, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
 	cfg, err := awsCfg.LoadDefaultConfig(ctx, awsCfg.WithRegion(config.Region))
 	if err != nil {
 		return nil, err
@@ -22,5 +40,16 @@ func NewFromEnv(ctx context.Context) (Service, error) {
 
 	client := sns.NewFromConfig(cfg)
 
-	return &service{conn: client, config: config, topics: make(map[string]TopicArn)}, nil
+	s := &service{
+		conn:     client,
+		sqs:      sqs.NewFromConfig(cfg),
+		config:   config,
+		resolver: NewTopicResolver(client, config),
+		topics:   make(map[string]TopicArn),
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+
+	return s, nil
 }
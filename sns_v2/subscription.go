@@ -0,0 +1,166 @@
+package sns_v2
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqsTypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/pkg/errors"
+)
+
+// FilterPolicy is an SNS subscription filter policy, see
+// https://docs.aws.amazon.com/sns/latest/dg/sns-subscription-filter-policies.html
+type FilterPolicy map[string]interface{}
+
+// EventTypeFilter returns a filter policy which only delivers messages with one of the given event types.
+func EventTypeFilter(eventTypes ...string) FilterPolicy {
+	return FilterPolicy{EventTypeAttribute: eventTypes}
+}
+
+// SubscribeOption customises the subscription attributes set by Service.Subscribe.
+type SubscribeOption func(attributes map[string]string) error
+
+// WithFilterPolicy sets the filter policy of the subscription, so the queue only receives matching messages.
+func WithFilterPolicy(policy FilterPolicy) SubscribeOption {
+	return func(attributes map[string]string) error {
+		b, err := json.Marshal(policy)
+		if err != nil {
+			return errors.Wrap(err, "marshal filter policy")
+		}
+		attributes["FilterPolicy"] = string(b)
+		return nil
+	}
+}
+
+// WithRawMessageDelivery delivers the message body without the SNS JSON wrapper.
+func WithRawMessageDelivery() SubscribeOption {
+	return func(attributes map[string]string) error {
+		attributes["RawMessageDelivery"] = "true"
+		return nil
+	}
+}
+
+// Subscribe subscribes the SQS queue at queueURL to the topic for eventType and returns the subscription ARN. The
+// queue access policy is updated to allow the topic to send messages to it. Subscribing is idempotent as long as the
+// options don't change.
+func (s *service) Subscribe(ctx context.Context, eventType string, queueURL string, opts ...SubscribeOption) (string, error) {
+	attributes := map[string]string{}
+	for _, opt := range opts {
+		if err := opt(attributes); err != nil {
+			return "", err
+		}
+	}
+
+	topicArn, err := s.topic(ctx, eventType)
+	if err != nil {
+		return "", err
+	}
+
+	queueArn, err := s.allowTopic(ctx, queueURL, *topicArn)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := s.conn.Subscribe(ctx, &sns.SubscribeInput{
+		TopicArn:              topicArn,
+		Protocol:              aws.String("sqs"),
+		Endpoint:              aws.String(queueArn),
+		Attributes:            attributes,
+		ReturnSubscriptionArn: true,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "subscribe")
+	}
+
+	return aws.ToString(output.SubscriptionArn), nil
+}
+
+// policyStatements is the Statement of an access policy, which can be a single statement or an array of them.
+type policyStatements []map[string]interface{}
+
+func (p *policyStatements) UnmarshalJSON(b []byte) error {
+	var statement map[string]interface{}
+	if err := json.Unmarshal(b, &statement); err == nil {
+		*p = policyStatements{statement}
+		return nil
+	}
+	var statements []map[string]interface{}
+	if err := json.Unmarshal(b, &statements); err != nil {
+		return err
+	}
+	*p = statements
+	return nil
+}
+
+// allowTopic adds a statement to the queue access policy allowing topicArn to send messages to the queue, unless one
+// already exists. It returns the queue ARN. The policy is read, modified and written back, so updates are serialised
+// within the service, but concurrent subscriptions to the same queue from other processes can overwrite each other's
+// statements; subscribe a queue from one place, e.g. at deploy, if that matters.
+func (s *service) allowTopic(ctx context.Context, queueURL, topicArn string) (string, error) {
+	s.policyMu.Lock()
+	defer s.policyMu.Unlock()
+
+	output, err := s.sqs.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl: aws.String(queueURL),
+		AttributeNames: []sqsTypes.QueueAttributeName{
+			sqsTypes.QueueAttributeNameQueueArn,
+			sqsTypes.QueueAttributeNamePolicy,
+		},
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "get queue attributes")
+	}
+	queueArn := output.Attributes[string(sqsTypes.QueueAttributeNameQueueArn)]
+
+	// The policy is decoded into a map so that fields other than the statements, e.g. Id, are kept.
+	policy := map[string]json.RawMessage{"Version": json.RawMessage(`"2012-10-17"`)}
+	var statements policyStatements
+	if raw := output.Attributes[string(sqsTypes.QueueAttributeNamePolicy)]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &policy); err != nil {
+			return "", errors.Wrap(err, "unmarshal queue policy")
+		}
+		if statement, ok := policy["Statement"]; ok {
+			if err := json.Unmarshal(statement, &statements); err != nil {
+				return "", errors.Wrap(err, "unmarshal queue policy statement")
+			}
+		}
+	}
+
+	sid := "sns:" + topicArn
+	for _, statement := range statements {
+		if statement["Sid"] == sid {
+			return queueArn, nil
+		}
+	}
+	statements = append(statements, map[string]interface{}{
+		"Sid":       sid,
+		"Effect":    "Allow",
+		"Principal": map[string]string{"Service": "sns.amazonaws.com"},
+		"Action":    "sqs:SendMessage",
+		"Resource":  queueArn,
+		"Condition": map[string]interface{}{
+			"ArnEquals": map[string]string{"aws:SourceArn": topicArn},
+		},
+	})
+
+	b, err := json.Marshal(statements)
+	if err != nil {
+		return "", errors.Wrap(err, "marshal queue policy")
+	}
+	policy["Statement"] = b
+	if b, err = json.Marshal(policy); err != nil {
+		return "", errors.Wrap(err, "marshal queue policy")
+	}
+	_, err = s.sqs.SetQueueAttributes(ctx, &sqs.SetQueueAttributesInput{
+		QueueUrl:   aws.String(queueURL),
+		Attributes: map[string]string{string(sqsTypes.QueueAttributeNamePolicy): string(b)},
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "set queue policy")
+	}
+
+	return queueArn, nil
+}
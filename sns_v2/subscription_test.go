@@ -0,0 +1,138 @@
+package sns_v2
+
+import (
+	"context"
+	"encoding/json"
+	"html"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/HomesNZ/go-common/sns_v2/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	testTopicArn = "arn:aws:sns:ap-southeast-2:123456789012:listing_created"
+	testQueueArn = "arn:aws:sqs:ap-southeast-2:123456789012:search"
+	testQueueURL = "https://sqs.ap-southeast-2.amazonaws.com/123456789012/search"
+)
+
+// formMap returns the map a query API request encodes as prefix.entry.N.key and prefix.entry.N.value, or with
+// keyName and valueName instead of key and value.
+func formMap(form url.Values, prefix, entry, keyName, valueName string) map[string]string {
+	m := map[string]string{}
+	for i := 1; ; i++ {
+		key := form.Get(prefix + "." + entry + strconv.Itoa(i) + "." + keyName)
+		if key == "" {
+			return m
+		}
+		m[key] = form.Get(prefix + "." + entry + strconv.Itoa(i) + "." + valueName)
+	}
+}
+
+var _ = Describe("Subscribe", func() {
+	var (
+		ctx    context.Context
+		aws    *fakeAWS
+		svc    *service
+		policy string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		policy = ""
+		aws = newFakeAWS(func(form url.Values) (int, string) {
+			switch form.Get("Action") {
+			case "GetQueueAttributes":
+				attributes := `<Attribute><Name>QueueArn</Name><Value>` + testQueueArn + `</Value></Attribute>`
+				if policy != "" {
+					attributes += `<Attribute><Name>Policy</Name><Value>` + html.EscapeString(policy) + `</Value></Attribute>`
+				}
+				return http.StatusOK, awsResponse("GetQueueAttributes", attributes)
+			case "SetQueueAttributes":
+				return http.StatusOK, `<SetQueueAttributesResponse><ResponseMetadata><RequestId>test</RequestId></ResponseMetadata></SetQueueAttributesResponse>`
+			case "Subscribe":
+				return http.StatusOK, awsResponse("Subscribe", `<SubscriptionArn>`+testTopicArn+`:1</SubscriptionArn>`)
+			}
+			return http.StatusBadRequest, ""
+		})
+		svc = aws.service(&config.Config{
+			Region:    "ap-southeast-2",
+			TopicMode: config.TopicModeStatic,
+			Topics:    map[string]string{"listing_created": testTopicArn},
+		})
+	})
+
+	AfterEach(func() {
+		aws.Close()
+	})
+
+	// setPolicy returns the queue policy set by Subscribe.
+	setPolicy := func() map[string]interface{} {
+		requests := aws.actions("SetQueueAttributes")
+		Expect(requests).To(HaveLen(1))
+		attributes := formMap(requests[0], "Attribute", "", "Name", "Value")
+		var policy map[string]interface{}
+		Expect(json.Unmarshal([]byte(attributes["Policy"]), &policy)).To(Succeed())
+		return policy
+	}
+
+	It("subscribes the queue with the filter policy", func() {
+		arn, err := svc.Subscribe(ctx, "listing_created", testQueueURL,
+			WithFilterPolicy(EventTypeFilter("listing_created", "listing_updated")),
+			WithRawMessageDelivery(),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(arn).To(Equal(testTopicArn + ":1"))
+
+		requests := aws.actions("Subscribe")
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].Get("TopicArn")).To(Equal(testTopicArn))
+		Expect(requests[0].Get("Protocol")).To(Equal("sqs"))
+		Expect(requests[0].Get("Endpoint")).To(Equal(testQueueArn))
+		Expect(formMap(requests[0], "Attributes", "entry.", "key", "value")).To(Equal(map[string]string{
+			"FilterPolicy":       `{"event_type":["listing_created","listing_updated"]}`,
+			"RawMessageDelivery": "true",
+		}))
+	})
+
+	It("adds a statement allowing the topic to a new policy", func() {
+		_, err := svc.Subscribe(ctx, "listing_created", testQueueURL)
+		Expect(err).NotTo(HaveOccurred())
+
+		policy := setPolicy()
+		Expect(policy["Version"]).To(Equal("2012-10-17"))
+		Expect(policy["Statement"]).To(HaveLen(1))
+		statement := policy["Statement"].([]interface{})[0].(map[string]interface{})
+		Expect(statement["Sid"]).To(Equal("sns:" + testTopicArn))
+		Expect(statement["Resource"]).To(Equal(testQueueArn))
+		Expect(statement["Condition"]).To(Equal(map[string]interface{}{
+			"ArnEquals": map[string]interface{}{"aws:SourceArn": testTopicArn},
+		}))
+	})
+
+	It("keeps the existing policy when adding a statement", func() {
+		policy = `{"Version":"2012-10-17","Id":"search-policy","Statement":{"Sid":"existing","Effect":"Allow"}}`
+
+		_, err := svc.Subscribe(ctx, "listing_created", testQueueURL)
+		Expect(err).NotTo(HaveOccurred())
+
+		policy := setPolicy()
+		Expect(policy["Id"]).To(Equal("search-policy"))
+		statements := policy["Statement"].([]interface{})
+		Expect(statements).To(HaveLen(2))
+		Expect(statements[0].(map[string]interface{})["Sid"]).To(Equal("existing"))
+		Expect(statements[1].(map[string]interface{})["Sid"]).To(Equal("sns:" + testTopicArn))
+	})
+
+	It("doesn't update the policy if the topic is already allowed", func() {
+		policy = `{"Version":"2012-10-17","Statement":[{"Sid":"sns:` + testTopicArn + `","Effect":"Allow"}]}`
+
+		_, err := svc.Subscribe(ctx, "listing_created", testQueueURL)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(aws.actions("SetQueueAttributes")).To(BeEmpty())
+		Expect(aws.actions("Subscribe")).To(HaveLen(1))
+	})
+})
@@ -4,13 +4,27 @@ go 1.22.1
 
 require (
 	github.com/HomesNZ/go-common/env v0.0.0-20201208024358-01a507e2221c
+	github.com/HomesNZ/go-common/envelope v0.0.0-00010101000000-000000000000
 	github.com/HomesNZ/go-common/sns v0.0.0-20201208024358-01a507e2221c
-	github.com/asaskevich/govalidator v0.0.0-20210307081110-f21760c49a8d // indirect
 	github.com/aws/aws-sdk-go v1.36.3
 	github.com/go-ozzo/ozzo-validation v3.6.0+incompatible
 	github.com/pkg/errors v0.9.1
 	github.com/sirupsen/logrus v1.7.0
-	github.com/stretchr/testify v1.5.1 // indirect
 	go.uber.org/mock v0.4.0
+)
+
+require (
+	github.com/HomesNZ/go-common/trace v0.0.0-00010101000000-000000000000 // indirect
+	github.com/asaskevich/govalidator v0.0.0-20210307081110-f21760c49a8d // indirect
+	github.com/google/uuid v1.4.0 // indirect
+	github.com/jmespath/go-jmespath v0.4.0 // indirect
+	github.com/joho/godotenv v1.3.0 // indirect
+	github.com/stretchr/testify v1.5.1 // indirect
+	golang.org/x/sys v0.13.0 // indirect
 	gopkg.in/yaml.v2 v2.3.0 // indirect
 )
+
+replace (
+	github.com/HomesNZ/go-common/envelope => ../envelope
+	github.com/HomesNZ/go-common/trace => ../trace
+)
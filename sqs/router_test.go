@@ -0,0 +1,28 @@
+package sqs
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRouterPassesLegacyEventsThrough(t *testing.T) {
+	legacy := `{"type":"listing_created","id":123}`
+
+	var handled string
+	r := NewRouter()
+	r.AddUpcaster("listing_created", 1, func(payload json.RawMessage) (json.RawMessage, error) {
+		return payload, nil
+	})
+	r.AddRoute("listing_created", func(message SNSMessage) (bool, error) {
+		handled = message.Message
+		return true, nil
+	})
+
+	ok, err := r.Handle(SNSMessage{Message: legacy})
+	if err != nil || !ok {
+		t.Fatalf("Handle() = %v, %v, want true, nil", ok, err)
+	}
+	if handled != legacy {
+		t.Errorf("handled %s, want %s", handled, legacy)
+	}
+}
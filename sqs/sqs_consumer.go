@@ -84,7 +84,7 @@ func (c consumer) receive() {
 			c.doneChan = nil
 			c.responseChan = nil
 			c.started = false
-			contextLogger.Info("stopped polling SQS queue:", c.config.QueueName)
+			contextLogger.Info("stopped polling SQS queue:", c.config.QueueName())
 			return
 		default:
 			contextLogger.Debug("waiting for request...")
@@ -186,7 +186,7 @@ func (c consumer) Stop() error {
 	if !c.started {
 		return errors.New("can't stop sqs consumer: already stopped")
 	}
-	contextLogger.Info("stopping polling of SQS queue:", c.config.QueueName)
+	contextLogger.Info("stopping polling of SQS queue:", c.config.QueueName())
 	c.doneChan <- true
 	return nil
 }
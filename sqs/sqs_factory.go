@@ -1,6 +1,7 @@
 package sqs
 
 import (
+	"github.com/HomesNZ/go-common/envelope"
 	"github.com/HomesNZ/go-common/sqs/config"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -8,21 +9,31 @@ import (
 	"github.com/aws/aws-sdk-go/service/sqs"
 )
 
-func NewFromEnv(handlers map[string]SNSMessageHandler) (Consumer, error) {
+// RouterOption configures the router built by New and NewFromEnv.
+type RouterOption func(*Router)
+
+// WithUpcaster registers an upcaster on the router, see Router.AddUpcaster.
+func WithUpcaster(eventType string, fromVersion int, upcaster envelope.Upcaster) RouterOption {
+	return func(r *Router) {
+		r.AddUpcaster(eventType, fromVersion, upcaster)
+	}
+}
+
+func NewFromEnv(handlers map[string]SNSMessageHandler, options ...RouterOption) (Consumer, error) {
 	config, err := config.NewFromEnv()
 	if err != nil {
 		return nil, err
 	}
 
-	return newConsumer(config, handlers)
+	return newConsumer(config, handlers, options...)
 }
 
-func New(config config.Config, handlers map[string]SNSMessageHandler) (Consumer, error) {
-	return newConsumer(config, handlers)
+func New(config config.Config, handlers map[string]SNSMessageHandler, options ...RouterOption) (Consumer, error) {
+	return newConsumer(config, handlers, options...)
 }
 
 // New returns a pointer to a fresh Consumer instance.
-func newConsumer(config config.Config, handlers map[string]SNSMessageHandler) (Consumer, error) {
+func newConsumer(config config.Config, handlers map[string]SNSMessageHandler, options ...RouterOption) (Consumer, error) {
 
 	sess := session.New(&aws.Config{
 		Region: aws.String(config.Region()),
@@ -46,6 +57,9 @@ func newConsumer(config config.Config, handlers map[string]SNSMessageHandler) (C
 	for event, h := range handlers {
 		router.AddRoute(event, h)
 	}
+	for _, opt := range options {
+		opt(router)
+	}
 
 	handler := Handler{
 		Router: router,
@@ -1,3 +1,8 @@
+// Package sqsConsumer consumes SQS queues using goamz.
+//
+// Deprecated: use github.com/HomesNZ/go-common/sqs_v2. RedsyncEnabled is replaced by sqs_v2.WithDedupeLock with a
+// lock.Redsync, WaitForCompletion by sqs_v2.WithOrderedProcessing, and existing handlers can be adapted with
+// sqs_v2.SNSHandler and sqs_v2.SQSHandler.
 package sqsConsumer
 
 import (
@@ -60,6 +65,8 @@ type Consumer struct {
 }
 
 // NewConsumer returns a pointer to a fresh Consumer instance.
+//
+// Deprecated: use sqs_v2.NewFromEnv.
 func NewConsumer(conn *sqs.SQS, queueName string, handler interface{}) *Consumer {
 	return &Consumer{
 		conn:      conn,
@@ -1,12 +1,18 @@
 module github.com/HomesNZ/go-common/sqs_consumer_lambda
 
-go 1.16
+go 1.21.5
 
 require (
 	github.com/HomesNZ/events v0.0.0-20210526041501-6acb2a727cf4
 	github.com/HomesNZ/go-common/env v0.0.0-20201123033107-069310ef2e73 // indirect
+	github.com/HomesNZ/go-common/envelope v0.0.0-00010101000000-000000000000
 	github.com/aws/aws-lambda-go v1.24.0
 	github.com/pkg/errors v0.9.1
 	github.com/sirupsen/logrus v1.8.1
 	gopkg.in/yaml.v2 v2.3.0 // indirect
 )
+
+replace (
+	github.com/HomesNZ/go-common/envelope => ../envelope
+	github.com/HomesNZ/go-common/trace => ../trace
+)
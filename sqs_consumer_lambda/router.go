@@ -4,13 +4,15 @@ import (
 	"encoding/json"
 
 	"github.com/HomesNZ/events"
+	"github.com/HomesNZ/go-common/envelope"
 	"github.com/pkg/errors"
 )
 
 //type MessageHandler func(ctx context.Context, message sqs.SNSMessage) (bool, error)
 
 type Router struct {
-	routes map[string]SNSMessageHandler
+	routes    map[string]SNSMessageHandler
+	upcasters envelope.Upcasters
 }
 
 func newRouter() *Router {
@@ -23,6 +25,13 @@ func (r *Router) AddRoute(route string, handler SNSMessageHandler) {
 	r.routes[route] = handler
 }
 
+// AddUpcaster registers an upcaster which migrates enveloped eventType payloads from fromVersion to fromVersion+1.
+// Upcasters are applied before the message is passed to the route handler, so handlers only need to decode the
+// current version. It panics if an upcaster is already registered for eventType and fromVersion.
+func (r *Router) AddUpcaster(eventType string, fromVersion int, upcaster envelope.Upcaster) {
+	r.upcasters.Register(eventType, fromVersion, upcaster)
+}
+
 func (r *Router) Handle(message SNSMessage) (bool, error) {
 	rawJSON := []byte(message.Message)
 	genericEvent := &events.Event{}
@@ -36,5 +45,11 @@ func (r *Router) Handle(message SNSMessage) (bool, error) {
 		return true, errors.New("unknown event type: " + genericEvent.Type)
 	}
 
+	rawJSON, err = r.upcasters.Upcast(rawJSON)
+	if err != nil {
+		return true, errors.Wrap(err, "upcast")
+	}
+	message.Message = string(rawJSON)
+
 	return handler(message)
 }
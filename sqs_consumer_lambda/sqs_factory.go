@@ -1,11 +1,22 @@
 package sqsConsumerLambda
 
 import (
+	"github.com/HomesNZ/go-common/envelope"
 	"github.com/pkg/errors"
 )
 
+// RouterOption configures the router built by New.
+type RouterOption func(*Router)
+
+// WithUpcaster registers an upcaster on the router, see Router.AddUpcaster.
+func WithUpcaster(eventType string, fromVersion int, upcaster envelope.Upcaster) RouterOption {
+	return func(r *Router) {
+		r.AddUpcaster(eventType, fromVersion, upcaster)
+	}
+}
+
 // New for AWS Lambda
-func New(handlers map[string]SNSMessageHandler) (Consumer, error) {
+func New(handlers map[string]SNSMessageHandler, options ...RouterOption) (Consumer, error) {
 	if len(handlers) == 0 {
 		return nil, errors.New("no handlers provided")
 	}
@@ -14,6 +25,9 @@ func New(handlers map[string]SNSMessageHandler) (Consumer, error) {
 	for event, h := range handlers {
 		router.AddRoute(event, h)
 	}
+	for _, opt := range options {
+		opt(router)
+	}
 
 	handler := Handler{
 		Router: router,
@@ -0,0 +1,164 @@
+package sqs_v2
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// ctxKey is the type of context keys set by the consumer.
+type ctxKey int
+
+// orderedKey is set in the handler context when the consumer was created WithOrderedProcessing.
+const orderedKey ctxKey = 1
+
+// SNSMessage is a data struct matching the output from a message pushed through SQS from SNS. It's the equivalent of
+// sqsConsumer.SNSMessage.
+type SNSMessage = Message
+
+// ChangeMessageVisibility sets the visibility timeout for the message, like sqsConsumer.SNSMessage's. Use
+// Consumer.ChangeMessageVisibility to pass a context.
+func (m Message) ChangeMessageVisibility(consumer Consumer, d time.Duration) error {
+	return consumer.ChangeMessageVisibility(context.Background(), m, d)
+}
+
+// SQSMessage is a raw SQS message. It's the equivalent of sqsConsumer.SQSMessage, with the fields of the goamz
+// sqs.Message it's based on.
+type SQSMessage struct {
+	MessageId              string
+	Body                   string
+	MD5OfBody              string
+	ReceiptHandle          string
+	Attribute              []Attribute
+	MessageAttribute       []MessageAttribute
+	MD5OfMessageAttributes string
+}
+
+// Attribute is a system attribute of an SQSMessage, e.g. ApproximateReceiveCount.
+type Attribute struct {
+	Name  string
+	Value string
+}
+
+// MessageAttribute is a message attribute of an SQSMessage.
+type MessageAttribute struct {
+	Name  string
+	Value MessageAttributeValue
+}
+
+// MessageAttributeValue is the value of a MessageAttribute.
+type MessageAttributeValue struct {
+	DataType    string
+	BinaryValue []byte
+	StringValue string
+}
+
+func newSQSMessage(m types.Message) SQSMessage {
+	message := SQSMessage{
+		MessageId:              aws.ToString(m.MessageId),
+		Body:                   aws.ToString(m.Body),
+		MD5OfBody:              aws.ToString(m.MD5OfBody),
+		ReceiptHandle:          aws.ToString(m.ReceiptHandle),
+		MD5OfMessageAttributes: aws.ToString(m.MD5OfMessageAttributes),
+	}
+	for name, value := range m.Attributes {
+		message.Attribute = append(message.Attribute, Attribute{Name: name, Value: value})
+	}
+	for name, value := range m.MessageAttributes {
+		message.MessageAttribute = append(message.MessageAttribute, MessageAttribute{
+			Name: name,
+			Value: MessageAttributeValue{
+				DataType:    aws.ToString(value.DataType),
+				BinaryValue: value.BinaryValue,
+				StringValue: aws.ToString(value.StringValue),
+			},
+		})
+	}
+	// The SDK returns attributes as maps, sort them so they're in a stable order.
+	sort.Slice(message.Attribute, func(i, j int) bool {
+		return message.Attribute[i].Name < message.Attribute[j].Name
+	})
+	sort.Slice(message.MessageAttribute, func(i, j int) bool {
+		return message.MessageAttribute[i].Name < message.MessageAttribute[j].Name
+	})
+	return message
+}
+
+// Receipt returns the receipt handle of the message, used to change its visibility.
+func (m SQSMessage) Receipt() string {
+	return m.ReceiptHandle
+}
+
+// ChangeMessageVisibility sets the visibility timeout for the message, like sqsConsumer.SQSMessage's. Use
+// Consumer.ChangeMessageVisibility to pass a context.
+func (m SQSMessage) ChangeMessageVisibility(consumer Consumer, d time.Duration) error {
+	return consumer.ChangeMessageVisibility(context.Background(), m, d)
+}
+
+// SNSMessageHandler has the signature of sqsConsumer.SNSMessageHandler, so handlers written for the goamz based
+// sqs_consumer package can be used with SNSHandler. It should handle errors internally and return a simple boolean to
+// indicate if handling was successful.
+type SNSMessageHandler func(message SNSMessage) bool
+
+// SQSMessageHandler has the signature of sqsConsumer.MessageHandler, for use with SQSHandler.
+type SQSMessageHandler func(message SQSMessage) bool
+
+// SNSHandler adapts a per message SNSMessageHandler to a MessageHandler. Messages in a batch are handled concurrently,
+// or one at a time in the order received when the consumer was created WithOrderedProcessing. Only the messages the
+// handler returned true for are deleted.
+func SNSHandler(handler SNSMessageHandler) MessageHandler {
+	return func(ctx context.Context, messages []Message) error {
+		return handleEach(ctx, len(messages), func(i int) (string, bool) {
+			return messages[i].Receipt(), handler(messages[i])
+		})
+	}
+}
+
+// SQSHandler adapts a per message SQSMessageHandler to a MessageHandler, see SNSHandler.
+func SQSHandler(handler SQSMessageHandler) MessageHandler {
+	return func(ctx context.Context, messages []Message) error {
+		return handleEach(ctx, len(messages), func(i int) (string, bool) {
+			message := newSQSMessage(messages[i].sqsMessage)
+			return message.Receipt(), handler(message)
+		})
+	}
+}
+
+// handleEach calls handle for each of n messages and returns FailedMessages with the receipts of the messages which
+// weren't handled.
+func handleEach(ctx context.Context, n int, handle func(i int) (string, bool)) error {
+	var mu sync.Mutex
+	var failed FailedMessages
+	run := func(i int) {
+		if receipt, ok := handle(i); !ok {
+			mu.Lock()
+			failed = append(failed, receipt)
+			mu.Unlock()
+		}
+	}
+
+	if ordered, _ := ctx.Value(orderedKey).(bool); ordered {
+		for i := 0; i < n; i++ {
+			run(i)
+		}
+	} else {
+		wg := sync.WaitGroup{}
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				run(i)
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	if len(failed) > 0 {
+		return failed
+	}
+	return nil
+}
@@ -15,11 +15,34 @@ const (
 	defaultWaitSeconds    = 10
 	secondsToSleepOnError = 10
 	maxRetries            = 5
+	// dedupeLockPrefix is the prefix added to the dedupe lock key (to prevent multiple processing of the same
+	// message).
+	dedupeLockPrefix = "sqs:message:"
 )
 
 type MessageHandler func(ctx context.Context, message []Message) error
 type Notifier func(err error, rawData ...interface{})
 
+// FailedMessages can be returned by a MessageHandler when only some of the messages failed. It holds the receipts
+// (see Message.Receipt) of the failed messages; the other messages are deleted from the queue.
+type FailedMessages []string
+
+func (f FailedMessages) Error() string {
+	return fmt.Sprintf("%d messages failed", len(f))
+}
+
+// Locker acquires a distributed lock, used to prevent multiple processing of the same message when SQS delivers it
+// more than once. See the lock package for a redsync implementation.
+type Locker interface {
+	// Lock returns an error if the key is already locked, otherwise a func which releases the lock.
+	Lock(ctx context.Context, key string) (unlock func(), err error)
+}
+
+// ReceivedMessage is a message received from the queue.
+type ReceivedMessage interface {
+	Receipt() string
+}
+
 type Consumer struct {
 	client   *SQS
 	config   *config.Config
@@ -28,6 +51,8 @@ type Consumer struct {
 	queueUrl *string
 	notifier Notifier
 	log      Logger
+	locker   Locker
+	ordered  bool
 }
 
 func (c *Consumer) Start(ctx context.Context) {
@@ -55,6 +80,13 @@ func (c *Consumer) SetNotifier(f Notifier) {
 	c.notifier = f
 }
 
+// ChangeMessageVisibility sets the visibility timeout of a received message, e.g. to extend it while a slow message
+// is handled, or to retry a failed message sooner than the queue visibility timeout.
+// http://docs.aws.amazon.com/AWSSimpleQueueService/latest/SQSDeveloperGuide/sqs-visibility-timeout.html
+func (c *Consumer) ChangeMessageVisibility(ctx context.Context, message ReceivedMessage, d time.Duration) error {
+	return c.client.ChangeVisibility(ctx, *c.queueUrl, message.Receipt(), d)
+}
+
 func (c *Consumer) worker(ctx context.Context, wg *sync.WaitGroup) {
 	for {
 		select {
@@ -68,7 +100,7 @@ func (c *Consumer) worker(ctx context.Context, wg *sync.WaitGroup) {
 		//	c.log.Info("stopped polling SQS queue:", c.config.QueueName)
 		//	return
 		default:
-			msgs, err := c.client.Receive(ctx, c.config.QueueName, defaultWaitSeconds, c.config.MaxMsg)
+			msgs, err := c.client.Receive(ctx, *c.queueUrl, defaultWaitSeconds, c.config.MaxMsg)
 			if err != nil {
 				msg := fmt.Sprintf("Error occurred while receiving from SQS queue (%s), sleeping for %d seconds", err.Error(), secondsToSleepOnError)
 				if c.notifier != nil {
@@ -102,6 +134,18 @@ func (c *Consumer) async(ctx context.Context, msgs []types.Message) {
 }
 
 func (c *Consumer) consume(ctx context.Context, msgs []types.Message) {
+	if c.locker != nil {
+		var unlock func()
+		msgs, unlock = c.lock(ctx, msgs)
+		defer unlock()
+		if len(msgs) == 0 {
+			return
+		}
+	}
+	if c.ordered {
+		ctx = context.WithValue(ctx, orderedKey, true)
+	}
+
 	messages := make([]Message, 0, len(msgs))
 	for _, m := range msgs {
 		msg, err := newMessage(m)
@@ -110,16 +154,52 @@ func (c *Consumer) consume(ctx context.Context, msgs []types.Message) {
 		}
 		messages = append(messages, msg)
 	}
-	if err := c.handler(ctx, messages); err != nil && c.log != nil {
-		// Failed to handle message, do nothing. It's the responsibility of the
-		// handler to communicate the failure via logs/bugsnag etc.
-		c.log.Error(err, "failed to handle message")
-		return
+	failed := map[string]bool{}
+	if err := c.handler(ctx, messages); err != nil {
+		var failedMessages FailedMessages
+		if !errors.As(err, &failedMessages) {
+			// Failed to handle message, do nothing. It's the responsibility of the
+			// handler to communicate the failure via logs/bugsnag etc.
+			if c.log != nil {
+				c.log.Error(err, "failed to handle message")
+			}
+			return
+		}
+		// Only some messages failed, the others are deleted.
+		for _, receipt := range failedMessages {
+			failed[receipt] = true
+		}
 	}
 
 	for _, msg := range msgs {
-		if err := c.client.Delete(ctx, c.config.QueueName, *msg.ReceiptHandle); err != nil && c.log != nil {
+		if failed[*msg.ReceiptHandle] {
+			continue
+		}
+		if err := c.client.Delete(ctx, *c.queueUrl, *msg.ReceiptHandle); err != nil && c.log != nil {
 			c.log.Error(err, "failed to delete message")
 		}
 	}
 }
+
+// lock acquires the dedupe lock for each message and returns the messages which were locked, and a func which
+// releases the locks. Messages which can't be locked are being handled by another consumer and are skipped.
+func (c *Consumer) lock(ctx context.Context, msgs []types.Message) ([]types.Message, func()) {
+	locked := make([]types.Message, 0, len(msgs))
+	unlocks := make([]func(), 0, len(msgs))
+	for _, msg := range msgs {
+		unlock, err := c.locker.Lock(ctx, dedupeLockPrefix+*msg.MessageId)
+		if err != nil {
+			if c.log != nil {
+				c.log.Error(err, "can't acquire dedupe lock, refusing to handle message (duplicate?)")
+			}
+			continue
+		}
+		locked = append(locked, msg)
+		unlocks = append(unlocks, unlock)
+	}
+	return locked, func() {
+		for _, unlock := range unlocks {
+			unlock()
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package sqs_v2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/HomesNZ/go-common/sqs_v2/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+const testQueueURL = "https://sqs.ap-southeast-2.amazonaws.com/123456789012/test"
+
+// fakeSQS records the messages deleted and the visibility changes made.
+type fakeSQS struct {
+	mu         sync.Mutex
+	deleted    []string
+	visibility map[string]int32
+}
+
+func (f *fakeSQS) ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	return &sqs.ReceiveMessageOutput{}, nil
+}
+
+func (f *fakeSQS) ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+	if aws.ToString(params.QueueUrl) != testQueueURL {
+		return nil, fmt.Errorf("unexpected queue url %s", aws.ToString(params.QueueUrl))
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.visibility == nil {
+		f.visibility = map[string]int32{}
+	}
+	f.visibility[aws.ToString(params.ReceiptHandle)] = params.VisibilityTimeout
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+func (f *fakeSQS) DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
+	if aws.ToString(params.QueueUrl) != testQueueURL {
+		return nil, fmt.Errorf("unexpected queue url %s", aws.ToString(params.QueueUrl))
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted = append(f.deleted, aws.ToString(params.ReceiptHandle))
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+// fakeLocker fails to lock the keys in held, as if another consumer is handling them.
+type fakeLocker struct {
+	held     map[string]bool
+	unlocked []string
+}
+
+func (l *fakeLocker) Lock(ctx context.Context, key string) (func(), error) {
+	if l.held[key] {
+		return nil, errors.New("lock already taken")
+	}
+	return func() {
+		l.unlocked = append(l.unlocked, key)
+	}, nil
+}
+
+func newTestConsumer(handler MessageHandler, options ...Options) (*Consumer, *fakeSQS) {
+	return newTestConsumerWithConfig(&config.Config{QueueName: "test", MaxMsg: 10, MaxWorker: 4}, handler, options...)
+}
+
+func newTestConsumerWithConfig(cfg *config.Config, handler MessageHandler, options ...Options) (*Consumer, *fakeSQS) {
+	api := &fakeSQS{}
+	c := &Consumer{
+		client:   &SQS{client: api, timeout: time.Second},
+		config:   cfg,
+		queueUrl: aws.String(testQueueURL),
+		handler:  handler,
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+	return c, api
+}
+
+// snsMessages returns SQS messages with SNS notification bodies, with message IDs m1, m2... and receipts r1, r2...
+func snsMessages(n int) []types.Message {
+	msgs := make([]types.Message, n)
+	for i := range msgs {
+		msgs[i] = types.Message{
+			MessageId:     aws.String(fmt.Sprintf("m%d", i+1)),
+			ReceiptHandle: aws.String(fmt.Sprintf("r%d", i+1)),
+			Body:          aws.String(fmt.Sprintf(`{"Type":"Notification","MessageId":"sns%d","Message":"message %d"}`, i+1, i+1)),
+		}
+	}
+	return msgs
+}
+
+func TestConsumeDeletesHandledMessages(t *testing.T) {
+	var handled []string
+	c, api := newTestConsumer(func(ctx context.Context, messages []Message) error {
+		for _, m := range messages {
+			handled = append(handled, m.Message)
+		}
+		return nil
+	})
+
+	c.consume(context.Background(), snsMessages(3))
+
+	if want := []string{"message 1", "message 2", "message 3"}; !reflect.DeepEqual(handled, want) {
+		t.Errorf("handled %v, want %v", handled, want)
+	}
+	if want := []string{"r1", "r2", "r3"}; !reflect.DeepEqual(api.deleted, want) {
+		t.Errorf("deleted %v, want %v", api.deleted, want)
+	}
+}
+
+func TestConsumeDeletesOnlyMessagesWhichDidNotFail(t *testing.T) {
+	c, api := newTestConsumer(func(ctx context.Context, messages []Message) error {
+		return FailedMessages{messages[1].Receipt()}
+	})
+
+	c.consume(context.Background(), snsMessages(3))
+
+	if want := []string{"r1", "r3"}; !reflect.DeepEqual(api.deleted, want) {
+		t.Errorf("deleted %v, want %v", api.deleted, want)
+	}
+}
+
+func TestConsumeKeepsAllMessagesOnError(t *testing.T) {
+	c, api := newTestConsumer(func(ctx context.Context, messages []Message) error {
+		return errors.New("handler failed")
+	})
+
+	c.consume(context.Background(), snsMessages(3))
+
+	if len(api.deleted) != 0 {
+		t.Errorf("deleted %v, want none", api.deleted)
+	}
+}
+
+func TestConsumeSkipsMessagesLockedByAnotherConsumer(t *testing.T) {
+	locker := &fakeLocker{held: map[string]bool{dedupeLockPrefix + "m2": true}}
+	var handled []string
+	c, api := newTestConsumer(func(ctx context.Context, messages []Message) error {
+		for _, m := range messages {
+			handled = append(handled, m.Message)
+		}
+		return nil
+	}, WithDedupeLock(locker))
+
+	c.consume(context.Background(), snsMessages(3))
+
+	if want := []string{"message 1", "message 3"}; !reflect.DeepEqual(handled, want) {
+		t.Errorf("handled %v, want %v", handled, want)
+	}
+	if want := []string{"r1", "r3"}; !reflect.DeepEqual(api.deleted, want) {
+		t.Errorf("deleted %v, want %v", api.deleted, want)
+	}
+	if want := []string{dedupeLockPrefix + "m1", dedupeLockPrefix + "m3"}; !reflect.DeepEqual(locker.unlocked, want) {
+		t.Errorf("unlocked %v, want %v", locker.unlocked, want)
+	}
+}
+
+func TestConsumeSkipsBatchWhenAllMessagesAreLocked(t *testing.T) {
+	locker := &fakeLocker{held: map[string]bool{dedupeLockPrefix + "m1": true}}
+	called := false
+	c, api := newTestConsumer(func(ctx context.Context, messages []Message) error {
+		called = true
+		return nil
+	}, WithDedupeLock(locker))
+
+	c.consume(context.Background(), snsMessages(1))
+
+	if called {
+		t.Error("handler called with no messages")
+	}
+	if len(api.deleted) != 0 {
+		t.Errorf("deleted %v, want none", api.deleted)
+	}
+}
+
+func TestOrderedProcessing(t *testing.T) {
+	var mu sync.Mutex
+	var handled []string
+	cfg := &config.Config{QueueName: "test", MaxMsg: 10, MaxWorker: 4}
+	c, api := newTestConsumerWithConfig(cfg, SNSHandler(func(m SNSMessage) bool {
+		// Without ordered processing the later messages would usually finish first.
+		if m.Message == "message 1" {
+			time.Sleep(20 * time.Millisecond)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, m.Message)
+		return true
+	}), WithOrderedProcessing())
+
+	if c.config.MaxWorker != 1 {
+		t.Errorf("MaxWorker is %d, want 1", c.config.MaxWorker)
+	}
+	if cfg.MaxWorker != 4 {
+		t.Errorf("caller's MaxWorker changed to %d, want 4", cfg.MaxWorker)
+	}
+
+	c.consume(context.Background(), snsMessages(5))
+
+	want := []string{"message 1", "message 2", "message 3", "message 4", "message 5"}
+	if !reflect.DeepEqual(handled, want) {
+		t.Errorf("handled %v, want %v", handled, want)
+	}
+	if len(api.deleted) != 5 {
+		t.Errorf("deleted %v, want all 5", api.deleted)
+	}
+}
+
+func TestSNSHandlerDeletesOnlyHandledMessages(t *testing.T) {
+	c, api := newTestConsumer(SNSHandler(func(m SNSMessage) bool {
+		return m.MessageID != "sns2"
+	}))
+
+	c.consume(context.Background(), snsMessages(3))
+
+	deleted := map[string]bool{}
+	for _, receipt := range api.deleted {
+		deleted[receipt] = true
+	}
+	if want := map[string]bool{"r1": true, "r3": true}; !reflect.DeepEqual(deleted, want) {
+		t.Errorf("deleted %v, want %v", deleted, want)
+	}
+}
+
+func TestSQSHandler(t *testing.T) {
+	msg := types.Message{
+		MessageId:     aws.String("m1"),
+		ReceiptHandle: aws.String("r1"),
+		Body:          aws.String("raw body"),
+		MD5OfBody:     aws.String("md5"),
+		Attributes: map[string]string{
+			"SentTimestamp":           "1650000000000",
+			"ApproximateReceiveCount": "2",
+		},
+		MessageAttributes: map[string]types.MessageAttributeValue{
+			"event_type": {DataType: aws.String("String"), StringValue: aws.String("listing.updated")},
+		},
+	}
+	var got SQSMessage
+	c, api := newTestConsumer(SQSHandler(func(m SQSMessage) bool {
+		got = m
+		return false
+	}))
+
+	c.consume(context.Background(), []types.Message{msg})
+
+	want := SQSMessage{
+		MessageId:     "m1",
+		Body:          "raw body",
+		MD5OfBody:     "md5",
+		ReceiptHandle: "r1",
+		Attribute: []Attribute{
+			{Name: "ApproximateReceiveCount", Value: "2"},
+			{Name: "SentTimestamp", Value: "1650000000000"},
+		},
+		MessageAttribute: []MessageAttribute{
+			{Name: "event_type", Value: MessageAttributeValue{DataType: "String", StringValue: "listing.updated"}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("handled %+v, want %+v", got, want)
+	}
+	if len(api.deleted) != 0 {
+		t.Errorf("deleted %v, want none", api.deleted)
+	}
+}
+
+func TestChangeMessageVisibility(t *testing.T) {
+	var c *Consumer
+	c, api := newTestConsumer(func(ctx context.Context, messages []Message) error {
+		if err := c.ChangeMessageVisibility(ctx, messages[0], time.Minute); err != nil {
+			return err
+		}
+		// The legacy sqs_consumer methods take the consumer by value.
+		if err := messages[1].ChangeMessageVisibility(*c, 30*time.Second); err != nil {
+			return err
+		}
+		return newSQSMessage(messages[2].sqsMessage).ChangeMessageVisibility(*c, 0)
+	})
+
+	c.consume(context.Background(), snsMessages(3))
+
+	if want := map[string]int32{"r1": 60, "r2": 30, "r3": 0}; !reflect.DeepEqual(api.visibility, want) {
+		t.Errorf("visibility %v, want %v", api.visibility, want)
+	}
+}
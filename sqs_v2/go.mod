@@ -10,10 +10,9 @@ require (
 	github.com/aws/aws-sdk-go-v2/credentials v1.11.2
 	github.com/aws/aws-sdk-go-v2/service/sqs v1.18.0
 	github.com/go-ozzo/ozzo-validation v3.6.0+incompatible
+	github.com/go-redsync/redsync/v4 v4.8.1
+	github.com/gomodule/redigo v1.8.9
 	github.com/pkg/errors v0.9.1
 	github.com/sirupsen/logrus v1.8.1 // indirect
-	github.com/stretchr/testify v1.5.1 // indirect
 	golang.org/x/sys v0.1.0 // indirect
-	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
-	gopkg.in/yaml.v2 v2.3.0 // indirect
 )
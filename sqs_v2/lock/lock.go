@@ -0,0 +1,53 @@
+// Package lock provides a redsync backed sqs_v2.Locker, replacing the redsync dedupe built into the sqs_consumer
+// package.
+package lock
+
+import (
+	"context"
+	"time"
+
+	"github.com/HomesNZ/go-common/sqs_v2"
+	"github.com/go-redsync/redsync/v4"
+	"github.com/go-redsync/redsync/v4/redis/redigo"
+	redigolib "github.com/gomodule/redigo/redis"
+)
+
+const (
+	// DefaultExpiry is the default duration a message is locked for. Like sqs_consumer, it should be longer than a
+	// message takes to handle.
+	DefaultExpiry = time.Second * 120
+	// DefaultTries is the default number of attempts made to lock a message. A message which is already locked is
+	// being handled by another consumer, so it isn't retried.
+	DefaultTries = 1
+)
+
+// Redsync locks messages with redsync.
+type Redsync struct {
+	redsync *redsync.Redsync
+	options []redsync.Option
+}
+
+// NewRedsync returns a Redsync locker using pool. The options are applied after the defaults of DefaultExpiry and
+// DefaultTries.
+func NewRedsync(pool *redigolib.Pool, options ...redsync.Option) *Redsync {
+	return &Redsync{
+		redsync: redsync.New(redigo.NewPool(pool)),
+		options: append([]redsync.Option{
+			redsync.WithExpiry(DefaultExpiry),
+			redsync.WithTries(DefaultTries),
+		}, options...),
+	}
+}
+
+var _ sqs_v2.Locker = &Redsync{}
+
+// Lock locks key, returning a func which releases the lock.
+func (r *Redsync) Lock(ctx context.Context, key string) (func(), error) {
+	mutex := r.redsync.NewMutex(key, r.options...)
+	if err := mutex.LockContext(ctx); err != nil {
+		return nil, err
+	}
+	return func() {
+		mutex.Unlock()
+	}, nil
+}
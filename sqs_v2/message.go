@@ -2,8 +2,10 @@ package sqs_v2
 
 import (
 	"encoding/json"
-	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 )
 
 type Message struct {
@@ -20,6 +22,11 @@ type Message struct {
 	sqsMessage types.Message
 }
 
+// Receipt returns the receipt handle of the SQS message, used to change its visibility.
+func (m Message) Receipt() string {
+	return aws.ToString(m.sqsMessage.ReceiptHandle)
+}
+
 func newMessage(sqsMessage types.Message) (Message, error) {
 	m := Message{
 		sqsMessage: sqsMessage,
@@ -10,8 +10,15 @@ import (
 	"time"
 )
 
+// sqsAPI is the part of *sqs.Client used by SQS.
+type sqsAPI interface {
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error)
+	DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+}
+
 type SQS struct {
-	client  *sqs.Client
+	client  sqsAPI
 	timeout time.Duration
 }
 
@@ -33,6 +40,21 @@ func (s SQS) Receive(ctx context.Context, queueURL string, waitTimeSeconds int32
 	return res.Messages, nil
 }
 
+func (s SQS) ChangeVisibility(ctx context.Context, queueURL, rcvHandle string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	if _, err := s.client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(queueURL),
+		ReceiptHandle:     aws.String(rcvHandle),
+		VisibilityTimeout: int32(timeout.Seconds()),
+	}); err != nil {
+		return fmt.Errorf("change visibility: %w", err)
+	}
+
+	return nil
+}
+
 func (s SQS) Delete(ctx context.Context, queueURL, rcvHandle string) error {
 	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
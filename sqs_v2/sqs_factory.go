@@ -35,6 +35,27 @@ func WithCredentials(key, secret, session string) Options {
 	}
 }
 
+// WithDedupeLock locks each message with locker before it's handled, so a message delivered to more than one consumer
+// is only handled once at a time. Messages which can't be locked are skipped and become visible again after the
+// visibility timeout.
+func WithDedupeLock(locker Locker) Options {
+	return func(c *Consumer) {
+		c.locker = locker
+	}
+}
+
+// WithOrderedProcessing makes the consumer wait for each batch of messages to finish processing before it requests
+// the next batch, using a single worker, and makes SNSHandler and SQSHandler handle messages one at a time in the
+// order they were received. The config passed to the consumer is copied rather than changed.
+func WithOrderedProcessing() Options {
+	return func(c *Consumer) {
+		cfg := *c.config
+		cfg.MaxWorker = 1
+		c.ordered = true
+		c.config = &cfg
+	}
+}
+
 func NewFromEnv(ctx context.Context, handler MessageHandler, options ...Options) (*Consumer, error) {
 	config, err := config.NewFromEnv()
 	if err != nil {
@@ -0,0 +1,137 @@
+package zip
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// File is a file to add to an archive.
+type File struct {
+	Name    string    // - is the slash separated path of the file within the archive
+	Body    io.Reader // - is the contents of the file
+	Size    int64     // - is the length of Body, needed by tar archives, which buffer Body if it is unset and unknown
+	Mode    os.FileMode
+	ModTime time.Time
+}
+
+// name returns the cleaned name of the file, and an error if it's unsafe or the file has no Body.
+func (f File) name() (string, error) {
+	name := path.Clean(strings.TrimPrefix(f.Name, "/"))
+	if name == "." || name == ".." || strings.HasPrefix(name, "../") {
+		return "", errors.Wrap(ErrUnsafePath, f.Name)
+	}
+	if f.Body == nil {
+		return "", errors.Errorf("%s has no body", f.Name)
+	}
+	return name, nil
+}
+
+func (f File) mode() os.FileMode {
+	if f.Mode == 0 {
+		return 0644
+	}
+	return f.Mode
+}
+
+func (f File) modTime() time.Time {
+	if f.ModTime.IsZero() {
+		return time.Now()
+	}
+	return f.ModTime
+}
+
+// Create writes a ZIP archive of files to w.
+func Create(w io.Writer, files []File) error {
+	archive := zip.NewWriter(w)
+	for _, f := range files {
+		name, err := f.name()
+		if err != nil {
+			return err
+		}
+		header := &zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: f.modTime(),
+		}
+		header.SetMode(f.mode())
+
+		fw, err := archive.CreateHeader(header)
+		if err != nil {
+			return errors.Wrapf(err, "create %s", name)
+		}
+		if _, err := io.Copy(fw, f.Body); err != nil {
+			return errors.Wrapf(err, "write %s", name)
+		}
+	}
+	return archive.Close()
+}
+
+// CreateTar writes a tar archive of files to w.
+func CreateTar(w io.Writer, files []File) error {
+	archive := tar.NewWriter(w)
+	for _, f := range files {
+		name, err := f.name()
+		if err != nil {
+			return err
+		}
+		size, body, err := f.size()
+		if err != nil {
+			return errors.Wrapf(err, "size %s", name)
+		}
+		header := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Size:     size,
+			Mode:     int64(f.mode().Perm()),
+			ModTime:  f.modTime(),
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			return errors.Wrapf(err, "create %s", name)
+		}
+		if _, err := io.Copy(archive, body); err != nil {
+			return errors.Wrapf(err, "write %s", name)
+		}
+	}
+	return archive.Close()
+}
+
+// CreateTarGz writes a gzip compressed tar archive of files to w.
+func CreateTarGz(w io.Writer, files []File) error {
+	gz := gzip.NewWriter(w)
+	if err := CreateTar(gz, files); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// size returns the length of the body, buffering it if the length isn't known.
+func (f File) size() (int64, io.Reader, error) {
+	if f.Size > 0 {
+		return f.Size, f.Body, nil
+	}
+	switch body := f.Body.(type) {
+	case interface{ Len() int }:
+		return int64(body.Len()), f.Body, nil
+	case *os.File:
+		info, err := body.Stat()
+		if err != nil {
+			return 0, nil, err
+		}
+		return info.Size(), body, nil
+	}
+	b, err := ioutil.ReadAll(f.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return int64(len(b)), bytes.NewReader(b), nil
+}
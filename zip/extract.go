@@ -0,0 +1,174 @@
+package zip
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrUnsafePath is returned when an entry would be extracted outside of the destination directory.
+	ErrUnsafePath = errors.New("zip: entry path is outside the destination")
+	// ErrSymlink is returned when an archive contains a symlink or hard link, which are never extracted.
+	ErrSymlink = errors.New("zip: archive contains a link")
+	// ErrTooManyFiles is returned when an archive has more entries than the WithMaxFiles limit.
+	ErrTooManyFiles = errors.New("zip: archive has too many entries")
+	// ErrTooLarge is returned when extracting an archive would exceed the WithMaxFileSize or WithMaxTotalSize limits.
+	ErrTooLarge = errors.New("zip: archive is too large")
+	// ErrUnknownFormat is returned by ExtractFile when the archive format can't be determined from its name.
+	ErrUnknownFormat = errors.New("zip: unknown archive format")
+)
+
+// Extract extracts the ZIP archive in src, which is size bytes long, into dest. dest is created if it doesn't exist.
+// Entries that would be written outside of dest, links and archives exceeding the limits return an error, in which
+// case dest may contain some of the extracted files.
+func Extract(ctx context.Context, src io.ReaderAt, size int64, dest string, opts ...Option) error {
+	x, err := newExtractor(dest)
+	if err != nil {
+		return err
+	}
+	return Walk(ctx, src, size, x.extract, opts...)
+}
+
+// ExtractTar extracts the tar archive read from r into dest, with the same protections as Extract.
+func ExtractTar(ctx context.Context, r io.Reader, dest string, opts ...Option) error {
+	x, err := newExtractor(dest)
+	if err != nil {
+		return err
+	}
+	return WalkTar(ctx, r, x.extract, opts...)
+}
+
+// ExtractTarGz extracts the gzip compressed tar archive read from r into dest, with the same protections as Extract.
+func ExtractTarGz(ctx context.Context, r io.Reader, dest string, opts ...Option) error {
+	x, err := newExtractor(dest)
+	if err != nil {
+		return err
+	}
+	return WalkTarGz(ctx, r, x.extract, opts...)
+}
+
+// ExtractFile extracts the archive at the path into dest, detecting the format from the .zip, .tar, .tar.gz or .tgz
+// extension.
+func ExtractFile(ctx context.Context, archive, dest string, opts ...Option) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	name := strings.ToLower(archive)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		return Extract(ctx, f, info.Size(), dest, opts...)
+	case isTarGz(name):
+		return ExtractTarGz(ctx, f, dest, opts...)
+	case strings.HasSuffix(name, ".tar"):
+		return ExtractTar(ctx, f, dest, opts...)
+	default:
+		return errors.Wrap(ErrUnknownFormat, archive)
+	}
+}
+
+// extractor writes archive entries into a destination directory.
+type extractor struct {
+	dest string
+}
+
+func newExtractor(dest string) (*extractor, error) {
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return nil, err
+	}
+	// Resolve dest so that paths within it can be compared after resolving symlinks.
+	dest, err := filepath.Abs(dest)
+	if err != nil {
+		return nil, err
+	}
+	dest, err = filepath.EvalSymlinks(dest)
+	if err != nil {
+		return nil, err
+	}
+	return &extractor{dest: dest}, nil
+}
+
+func (x *extractor) extract(entry Entry, r io.Reader) error {
+	target, err := x.path(entry.Name)
+	if err != nil {
+		return err
+	}
+	if target == x.dest {
+		return nil
+	}
+
+	if entry.Mode.IsDir() {
+		return x.mkdir(target)
+	}
+	if err := x.mkdir(filepath.Dir(target)); err != nil {
+		return err
+	}
+	return x.write(target, entry, r)
+}
+
+// path returns the path name is extracted to, or ErrUnsafePath if it is outside of dest.
+func (x *extractor) path(name string) (string, error) {
+	name = path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") || filepath.VolumeName(name) != "" {
+		return "", errors.Wrap(ErrUnsafePath, name)
+	}
+	target := filepath.Join(x.dest, filepath.FromSlash(name))
+	if !x.within(target) {
+		return "", errors.Wrap(ErrUnsafePath, name)
+	}
+	return target, nil
+}
+
+func (x *extractor) within(target string) bool {
+	return target == x.dest || strings.HasPrefix(target, x.dest+string(filepath.Separator))
+}
+
+// mkdir creates dir and its parents, failing if an existing directory resolves outside of dest, e.g. through a
+// symlink placed in dest before extraction.
+func (x *extractor) mkdir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	if !x.within(resolved) {
+		return errors.Wrap(ErrUnsafePath, dir)
+	}
+	return nil
+}
+
+func (x *extractor) write(target string, entry Entry, r io.Reader) error {
+	// Remove any existing file rather than writing through it, in case it's a symlink.
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, entry.Mode.Perm()|0600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "extract %s", entry.Name)
+	}
+	if !entry.ModTime.IsZero() {
+		return os.Chtimes(target, entry.ModTime, entry.ModTime)
+	}
+	return nil
+}
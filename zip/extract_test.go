@@ -0,0 +1,184 @@
+package zip_test
+
+import (
+	"archive/tar"
+	stdzip "archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/HomesNZ/go-common/zip"
+	"github.com/pkg/errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func rawZip(entries map[string]string) *bytes.Reader {
+	buf := &bytes.Buffer{}
+	w := stdzip.NewWriter(buf)
+	for name, body := range entries {
+		f, err := w.Create(name)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = f.Write([]byte(body))
+		Expect(err).NotTo(HaveOccurred())
+	}
+	Expect(w.Close()).To(Succeed())
+	return bytes.NewReader(buf.Bytes())
+}
+
+var _ = Describe("Extract", func() {
+	var dest string
+
+	BeforeEach(func() {
+		var err error
+		dest, err = ioutil.TempDir("", "zip")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dest)
+	})
+
+	readFile := func(name string) string {
+		b, err := ioutil.ReadFile(filepath.Join(dest, name))
+		Expect(err).NotTo(HaveOccurred())
+		return string(b)
+	}
+
+	It("extracts an archive created by Create", func() {
+		files := []zip.File{
+			{Name: "feed/listings.csv", Body: strings.NewReader("id\n1\n")},
+			{Name: "feed/photos/1.jpg", Body: strings.NewReader("jpeg")},
+		}
+		buf := &bytes.Buffer{}
+		Expect(zip.Create(buf, files)).To(Succeed())
+
+		err := zip.Extract(context.Background(), bytes.NewReader(buf.Bytes()), int64(buf.Len()), dest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(readFile("feed/listings.csv")).To(Equal("id\n1\n"))
+		Expect(readFile("feed/photos/1.jpg")).To(Equal("jpeg"))
+	})
+
+	It("extracts a tar.gz archive created by CreateTarGz", func() {
+		files := []zip.File{{Name: "feed/listings.csv", Body: strings.NewReader("id\n1\n")}}
+		buf := &bytes.Buffer{}
+		Expect(zip.CreateTarGz(buf, files)).To(Succeed())
+
+		Expect(zip.ExtractTarGz(context.Background(), buf, dest)).To(Succeed())
+		Expect(readFile("feed/listings.csv")).To(Equal("id\n1\n"))
+	})
+
+	It("returns an error naming a file without a body", func() {
+		files := []zip.File{{Name: "feed/listings.csv", Body: strings.NewReader("id\n1\n")}, {Name: "feed/photos/1.jpg"}}
+		Expect(zip.Create(&bytes.Buffer{}, files)).To(MatchError(ContainSubstring("feed/photos/1.jpg")))
+		Expect(zip.CreateTar(&bytes.Buffer{}, files)).To(MatchError(ContainSubstring("feed/photos/1.jpg")))
+	})
+
+	It("rejects entries outside of the destination", func() {
+		r := rawZip(map[string]string{"../evil.txt": "evil"})
+
+		err := zip.Extract(context.Background(), r, r.Size(), dest)
+		Expect(errors.Cause(err)).To(Equal(zip.ErrUnsafePath))
+		Expect(filepath.Join(dest, "..", "evil.txt")).NotTo(BeAnExistingFile())
+	})
+
+	It("rejects symlinks", func() {
+		buf := &bytes.Buffer{}
+		w := tar.NewWriter(buf)
+		Expect(w.WriteHeader(&tar.Header{Typeflag: tar.TypeSymlink, Name: "passwd", Linkname: "/etc/passwd"})).To(Succeed())
+		Expect(w.Close()).To(Succeed())
+
+		err := zip.ExtractTar(context.Background(), buf, dest)
+		Expect(errors.Cause(err)).To(Equal(zip.ErrSymlink))
+	})
+
+	It("doesn't write through a symlink in the destination", func() {
+		outside, err := ioutil.TempDir("", "outside")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(outside)
+		Expect(os.Symlink(outside, filepath.Join(dest, "feed"))).To(Succeed())
+
+		r := rawZip(map[string]string{"feed/listings.csv": "id"})
+		err = zip.Extract(context.Background(), r, r.Size(), dest)
+		Expect(errors.Cause(err)).To(Equal(zip.ErrUnsafePath))
+		Expect(filepath.Join(outside, "listings.csv")).NotTo(BeAnExistingFile())
+	})
+
+	It("enforces the size limits", func() {
+		r := rawZip(map[string]string{"big.txt": strings.Repeat("a", 1024)})
+
+		err := zip.Extract(context.Background(), r, r.Size(), dest, zip.WithMaxFileSize(100))
+		Expect(errors.Cause(err)).To(Equal(zip.ErrTooLarge))
+
+		err = zip.Extract(context.Background(), r, r.Size(), dest, zip.WithMaxTotalSize(1023))
+		Expect(errors.Cause(err)).To(Equal(zip.ErrTooLarge))
+	})
+
+	It("enforces the entry count limit", func() {
+		r := rawZip(map[string]string{"a": "", "b": "", "c": ""})
+
+		err := zip.Extract(context.Background(), r, r.Size(), dest, zip.WithMaxFiles(2))
+		Expect(err).To(Equal(zip.ErrTooManyFiles))
+	})
+
+	It("only extracts entries accepted by the filter", func() {
+		r := rawZip(map[string]string{"listings.csv": "id", "notes.txt": "skip"})
+
+		err := zip.Extract(context.Background(), r, r.Size(), dest, zip.WithFilter(func(e zip.Entry) bool {
+			return filepath.Ext(e.Name) == ".csv"
+		}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(filepath.Join(dest, "listings.csv")).To(BeAnExistingFile())
+		Expect(filepath.Join(dest, "notes.txt")).NotTo(BeAnExistingFile())
+	})
+
+	It("stops when the context is cancelled", func() {
+		r := rawZip(map[string]string{"listings.csv": "id"})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := zip.Extract(ctx, r, r.Size(), dest)
+		Expect(err).To(Equal(context.Canceled))
+	})
+})
+
+var _ = Describe("Walk", func() {
+	It("calls fn with each entry", func() {
+		buf := &bytes.Buffer{}
+		Expect(zip.Create(buf, []zip.File{
+			{Name: "feed/listings.csv", Body: strings.NewReader("id\n1\n")},
+			{Name: "feed/agents.csv", Body: strings.NewReader("id\n2\n")},
+		})).To(Succeed())
+
+		contents := map[string]string{}
+		err := zip.Walk(context.Background(), bytes.NewReader(buf.Bytes()), int64(buf.Len()), func(e zip.Entry, r io.Reader) error {
+			b, err := ioutil.ReadAll(r)
+			contents[e.Name] = string(b)
+			return err
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(contents).To(Equal(map[string]string{"feed/listings.csv": "id\n1\n", "feed/agents.csv": "id\n2\n"}))
+	})
+
+	It("stops when fn returns an error", func() {
+		buf := &bytes.Buffer{}
+		Expect(zip.CreateTar(buf, []zip.File{
+			{Name: "a", Body: strings.NewReader("a")},
+			{Name: "b", Body: strings.NewReader("b")},
+		})).To(Succeed())
+
+		stop := errors.New("stop")
+		visited := 0
+		err := zip.WalkTar(context.Background(), buf, func(e zip.Entry, r io.Reader) error {
+			visited++
+			return stop
+		})
+		Expect(err).To(Equal(stop))
+		Expect(visited).To(Equal(1))
+	})
+})
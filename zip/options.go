@@ -0,0 +1,74 @@
+package zip
+
+import (
+	"os"
+	"time"
+)
+
+const (
+	// DefaultMaxFiles is the default limit on the number of entries extracted from an archive.
+	DefaultMaxFiles = 10000
+	// DefaultMaxTotalSize is the default limit on the total uncompressed size extracted from an archive.
+	DefaultMaxTotalSize = 1 << 30
+)
+
+// Entry describes a file in an archive.
+type Entry struct {
+	Name    string // - is the slash separated path of the entry within the archive
+	Size    int64  // - is the uncompressed size declared by the archive, which may not be accurate
+	Mode    os.FileMode
+	ModTime time.Time
+}
+
+// Filter decides whether an entry is extracted.
+type Filter func(Entry) bool
+
+// Option configures extraction.
+type Option func(*options)
+
+type options struct {
+	maxFiles     int
+	maxFileSize  int64
+	maxTotalSize int64
+	filter       Filter
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		maxFiles:     DefaultMaxFiles,
+		maxTotalSize: DefaultMaxTotalSize,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithMaxFiles limits the number of entries in the archive, including directories and filtered entries. Zero disables
+// the limit.
+func WithMaxFiles(n int) Option {
+	return func(o *options) {
+		o.maxFiles = n
+	}
+}
+
+// WithMaxFileSize limits the uncompressed size of each extracted file. Zero disables the limit.
+func WithMaxFileSize(size int64) Option {
+	return func(o *options) {
+		o.maxFileSize = size
+	}
+}
+
+// WithMaxTotalSize limits the total uncompressed size of the extracted files. Zero disables the limit.
+func WithMaxTotalSize(size int64) Option {
+	return func(o *options) {
+		o.maxTotalSize = size
+	}
+}
+
+// WithFilter only extracts the entries for which filter returns true.
+func WithFilter(filter Filter) Option {
+	return func(o *options) {
+		o.filter = filter
+	}
+}
@@ -0,0 +1,36 @@
+package zip
+
+import (
+	"context"
+	"strings"
+
+	"github.com/HomesNZ/go-common/s3"
+	"github.com/pkg/errors"
+)
+
+// WalkS3 calls fn with each file and directory of the archive stored at key, without downloading it to disk. ZIP
+// archives are read with ranged requests for the central directory and each entry, and tar and tar.gz archives are
+// streamed. The format is detected from the .zip, .tar, .tar.gz or .tgz extension of key.
+func WalkS3(ctx context.Context, service s3.Service, key string, fn WalkFunc, opts ...Option) error {
+	name := strings.ToLower(key)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		r, err := s3.NewReaderAt(ctx, service, key)
+		if err != nil {
+			return err
+		}
+		return Walk(ctx, r, r.Size(), fn, opts...)
+	case isTarGz(name), strings.HasSuffix(name, ".tar"):
+		body, _, err := service.Open(ctx, key)
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+		if isTarGz(name) {
+			return WalkTarGz(ctx, body, fn, opts...)
+		}
+		return WalkTar(ctx, body, fn, opts...)
+	default:
+		return errors.Wrap(ErrUnknownFormat, key)
+	}
+}
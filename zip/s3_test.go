@@ -0,0 +1,72 @@
+package zip_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/HomesNZ/go-common/s3"
+	mock_s3 "github.com/HomesNZ/go-common/s3/mock"
+	"github.com/HomesNZ/go-common/zip"
+	"github.com/golang/mock/gomock"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WalkS3", func() {
+	var (
+		ctrl    *gomock.Controller
+		service *mock_s3.MockService
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		service = mock_s3.NewMockService(ctrl)
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	files := func() []zip.File {
+		return []zip.File{{Name: "listings.csv", Body: strings.NewReader("id\n1\n")}}
+	}
+
+	walk := func(key string) map[string]string {
+		contents := map[string]string{}
+		err := zip.WalkS3(context.Background(), service, key, func(e zip.Entry, r io.Reader) error {
+			b, err := ioutil.ReadAll(r)
+			contents[e.Name] = string(b)
+			return err
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return contents
+	}
+
+	It("reads zip archives with ranged requests", func() {
+		buf := &bytes.Buffer{}
+		Expect(zip.Create(buf, files())).To(Succeed())
+		archive := buf.Bytes()
+
+		service.EXPECT().Head(gomock.Any(), "feed.zip").Return(s3.ObjectInfo{Size: int64(len(archive))}, nil)
+		service.EXPECT().Open(gomock.Any(), "feed.zip", gomock.Any()).
+			DoAndReturn(func(ctx context.Context, key string, opts ...s3.TransferOption) (io.ReadCloser, s3.ObjectInfo, error) {
+				Expect(opts).To(HaveLen(1))
+				return ioutil.NopCloser(bytes.NewReader(archive)), s3.ObjectInfo{}, nil
+			})
+
+		Expect(walk("feed.zip")).To(Equal(map[string]string{"listings.csv": "id\n1\n"}))
+	})
+
+	It("streams tar.gz archives", func() {
+		buf := &bytes.Buffer{}
+		Expect(zip.CreateTarGz(buf, files())).To(Succeed())
+
+		service.EXPECT().Open(gomock.Any(), "feed.tar.gz").Return(ioutil.NopCloser(buf), s3.ObjectInfo{}, nil)
+
+		Expect(walk("feed.tar.gz")).To(Equal(map[string]string{"listings.csv": "id\n1\n"}))
+	})
+})
@@ -1,63 +1,41 @@
 package zip
 
 import (
-	"archive/zip"
-	"io"
+	"context"
 	"os"
-	"path"
 	"path/filepath"
 	"strings"
 
 	"github.com/sirupsen/logrus"
 )
 
-// UnpackZIP unpacks the ZIP file at the given path
-func Unpack(dir string) (string, error) {
-	wd, err := os.Getwd()
-	dir = path.Join(wd, dir)
+// Unpack extracts the ZIP file at the path into a directory of the same name without its extension, returning the
+// directory. A relative path is resolved against the working directory. The file is read as a ZIP archive whatever its
+// extension, and unlike Extract no size or entry count limits are applied unless they're passed in opts.
+func Unpack(archive string, opts ...Option) (string, error) {
+	archive, err := filepath.Abs(archive)
+	if err != nil {
+		logrus.WithField("ZIP", "resolving the ZIP archive path").WithError(err).Error(err)
+		return "", err
+	}
 
-	reader, err := zip.OpenReader(dir)
+	f, err := os.Open(archive)
 	if err != nil {
 		logrus.WithField("ZIP", "reading the ZIP archive").WithError(err).Error(err)
 		return "", err
 	}
-
-	unpackPath := strings.Replace(dir, ".zip", "", 1)
-
-	err = os.MkdirAll(unpackPath, 0755)
+	defer f.Close()
+	info, err := f.Stat()
 	if err != nil {
-		logrus.WithField("ZIP", "creating ZIP file directory").WithError(err).Error(err)
+		logrus.WithField("ZIP", "reading the ZIP archive").WithError(err).Error(err)
 		return "", err
 	}
 
-	for _, file := range reader.File {
-		// ensure that any sub folders are created
-		path := filepath.Join(unpackPath, file.Name)
-		if file.FileInfo().IsDir() {
-			os.MkdirAll(path, file.Mode())
-			continue
-		}
-
-		fileReader, err := file.Open()
-		if err != nil {
-			logrus.WithField("ZIP", "opening a ZIP file").WithError(err).Error(err)
-			return "", err
-		}
-
-		targetFile, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, file.Mode())
-		if err != nil {
-			logrus.WithField("ZIP", "opening ZIP target").WithError(err).Error(err)
-			return "", err
-		}
-
-		_, err = io.Copy(targetFile, fileReader)
-		if err != nil {
-			logrus.WithField("ZIP", "copying ZIP file").WithError(err).Error(err)
-			return "", err
-		}
-
-		fileReader.Close()
-		targetFile.Close()
+	unpackPath := strings.TrimSuffix(archive, filepath.Ext(archive))
+	opts = append([]Option{WithMaxFiles(0), WithMaxTotalSize(0)}, opts...)
+	if err := Extract(context.Background(), f, info.Size(), unpackPath, opts...); err != nil {
+		logrus.WithField("ZIP", "extracting the ZIP archive").WithError(err).Error(err)
+		return "", err
 	}
 
 	return unpackPath, nil
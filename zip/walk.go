@@ -0,0 +1,181 @@
+package zip
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// WalkFunc is called with each entry of an archive. r reads the contents of the entry, is empty for directories, and
+// is only valid until WalkFunc returns. Returning an error stops the walk.
+type WalkFunc func(entry Entry, r io.Reader) error
+
+// Walk calls fn with each file and directory of the ZIP archive in src, which is size bytes long, without writing to
+// disk. The options limit and filter the entries as they do for Extract, and links return ErrSymlink.
+func Walk(ctx context.Context, src io.ReaderAt, size int64, fn WalkFunc, opts ...Option) error {
+	reader, err := zip.NewReader(src, size)
+	if err != nil {
+		return errors.Wrap(err, "read zip")
+	}
+	w := newWalker(ctx, fn, opts)
+	if w.options.maxFiles > 0 && len(reader.File) > w.options.maxFiles {
+		return ErrTooManyFiles
+	}
+
+	for _, file := range reader.File {
+		entry := Entry{
+			Name:    file.Name,
+			Size:    int64(file.UncompressedSize64),
+			Mode:    file.Mode(),
+			ModTime: file.Modified,
+		}
+		err := w.visit(entry, func() (io.ReadCloser, error) {
+			return file.Open()
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WalkTar calls fn with each file and directory of the tar archive read from r, in the same way as Walk. The archive
+// is streamed, so r can be a network body.
+func WalkTar(ctx context.Context, r io.Reader, fn WalkFunc, opts ...Option) error {
+	w := newWalker(ctx, fn, opts)
+
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "read tar")
+		}
+		if w.options.maxFiles > 0 && w.files >= w.options.maxFiles {
+			return ErrTooManyFiles
+		}
+
+		entry := Entry{
+			Name:    header.Name,
+			Size:    header.Size,
+			Mode:    header.FileInfo().Mode(),
+			ModTime: header.ModTime,
+		}
+		switch header.Typeflag {
+		case tar.TypeXGlobalHeader:
+			continue
+		case tar.TypeLink:
+			entry.Mode |= os.ModeSymlink
+		}
+		err = w.visit(entry, func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(reader), nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// WalkTarGz calls fn with each file and directory of the gzip compressed tar archive read from r, in the same way as
+// WalkTar.
+func WalkTarGz(ctx context.Context, r io.Reader, fn WalkFunc, opts ...Option) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return errors.Wrap(err, "read gzip")
+	}
+	defer gz.Close()
+	return WalkTar(ctx, gz, fn, opts...)
+}
+
+// isTarGz reports whether name has a .tar.gz or .tgz extension.
+func isTarGz(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz")
+}
+
+// walker calls a WalkFunc with archive entries, enforcing the options.
+type walker struct {
+	ctx     context.Context
+	fn      WalkFunc
+	options *options
+	files   int
+	read    int64
+}
+
+func newWalker(ctx context.Context, fn WalkFunc, opts []Option) *walker {
+	return &walker{ctx: ctx, fn: fn, options: newOptions(opts)}
+}
+
+func (w *walker) visit(entry Entry, open func() (io.ReadCloser, error)) error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+	w.files++
+
+	if w.options.filter != nil && !w.options.filter(entry) {
+		return nil
+	}
+	if entry.Mode&os.ModeSymlink != 0 {
+		return errors.Wrap(ErrSymlink, entry.Name)
+	}
+	if entry.Mode.IsDir() {
+		return w.fn(entry, strings.NewReader(""))
+	}
+	if !entry.Mode.IsRegular() {
+		return nil
+	}
+
+	r, err := open()
+	if err != nil {
+		return errors.Wrapf(err, "open %s", entry.Name)
+	}
+	defer r.Close()
+	return w.fn(entry, w.limit(entry, r))
+}
+
+// limit returns a reader of r which returns ErrTooLarge once the size limits are exceeded, regardless of the size
+// declared by the archive, and stops once ctx is done.
+func (w *walker) limit(entry Entry, r io.Reader) io.Reader {
+	limit := int64(-1)
+	if w.options.maxFileSize > 0 {
+		limit = w.options.maxFileSize
+	}
+	if w.options.maxTotalSize > 0 && (limit < 0 || w.options.maxTotalSize-w.read < limit) {
+		limit = w.options.maxTotalSize - w.read
+	}
+	if limit >= 0 {
+		// Read one byte past the limit so that exceeding it can be detected.
+		r = io.LimitReader(r, limit+1)
+	}
+	return &limitedReader{walker: w, name: entry.Name, r: r, limit: limit}
+}
+
+type limitedReader struct {
+	walker *walker
+	name   string
+	r      io.Reader
+	limit  int64
+	read   int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if err := l.walker.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	l.walker.read += int64(n)
+	if l.limit >= 0 && l.read > l.limit {
+		return n, errors.Wrap(ErrTooLarge, l.name)
+	}
+	return n, err
+}
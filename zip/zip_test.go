@@ -1,11 +1,15 @@
 package zip_test
 
 import (
-	. "github.com/onsi/ginkgo"
-	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
 	"path"
+	"path/filepath"
+
 	"github.com/HomesNZ/go-common/zip"
-	"os"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
 )
 
 var _ = Describe("Download", func() {
@@ -19,5 +23,32 @@ var _ = Describe("Download", func() {
 			err = os.RemoveAll(val)
 			Expect(err).NotTo(HaveOccurred())
 		})
+
+		It("extracts a zip file without a .zip extension", func() {
+			dir, err := ioutil.TempDir("", "unpack")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+			b, err := ioutil.ReadFile("test/Archive.zip")
+			Expect(err).NotTo(HaveOccurred())
+			archive := filepath.Join(dir, "Archive.upload")
+			Expect(ioutil.WriteFile(archive, b, 0644)).To(Succeed())
+
+			val, err := zip.Unpack(archive)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(val).To(Equal(filepath.Join(dir, "Archive")))
+		})
+
+		It("applies the limits passed", func() {
+			dir, err := ioutil.TempDir("", "unpack")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+			b, err := ioutil.ReadFile("test/Archive.zip")
+			Expect(err).NotTo(HaveOccurred())
+			archive := filepath.Join(dir, "Archive.zip")
+			Expect(ioutil.WriteFile(archive, b, 0644)).To(Succeed())
+
+			_, err = zip.Unpack(archive, zip.WithMaxTotalSize(1))
+			Expect(errors.Is(err, zip.ErrTooLarge)).To(BeTrue())
+		})
 	})
-})
\ No newline at end of file
+})